@@ -0,0 +1,202 @@
+// Package catalog загружает справочник предметов из файла.
+// Справочник по умолчанию встроен в бинарник (items.json).
+package catalog
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+//go:embed items.json
+var defaultItems []byte
+
+// Item - предмет справочника
+type Item struct {
+	Key           string   `json:"key"`  // Постоянный идентификатор, не меняется при переименовании
+	Name          string   `json:"name"` // Отображаемое название, по нему предметы ищутся в инвентаре
+	Type          string   `json:"type"`
+	DurabilityMax int      `json:"durability_max"`
+	BurnValue     int      `json:"burn_value"` // Единиц топлива, которые предмет дает в печи и костре; 0 - не горит
+	Satiety       int      `json:"satiety"`    // Сколько сытости восстанавливает съедобный предмет
+	Tool          string   `json:"tool"`       // Вид инструмента: кирка, топор...; пусто для остальных предметов
+	Tier          int      `json:"tier"`       // Уровень инструмента, от 1 у простых
+	Speed         int      `json:"speed"`      // На сколько процентов инструмент сокращает время работы
+	Yield         int      `json:"yield"`      // Шанс в процентах добыть инструментом лишнюю единицу ресурса
+	Description   string   `json:"description"`
+	Flags         []string `json:"flags"`
+}
+
+// Has сообщает, есть ли у предмета флаг
+func (i Item) Has(flag string) bool {
+	for _, f := range i.Flags {
+		if f == flag {
+			return true
+		}
+	}
+	return false
+}
+
+// Types - допустимые типы предметов
+var Types = map[string]bool{
+	"tool":       true,
+	"food":       true,
+	"material":   true,
+	"ammunition": true,
+	"quest_item": true,
+}
+
+// ToolKinds - допустимые виды инструментов
+var ToolKinds = map[string]bool{
+	"pickaxe":     true,
+	"axe":         true,
+	"knife":       true,
+	"bow":         true,
+	"fishing_rod": true,
+}
+
+// Flags - допустимые флаги предметов
+var Flags = map[string]bool{
+	"craftable": true, // Можно создать в мастерской
+	"edible":    true, // Можно съесть
+	"lore":      true, // Страница книги лора
+}
+
+var keyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// Catalog - проверенный справочник предметов
+type Catalog struct {
+	Items []Item `json:"items"`
+
+	byKey  map[string]Item
+	byName map[string]Item
+}
+
+// Load читает справочник из файла. Пустой путь означает встроенный справочник.
+func Load(path string) (*Catalog, error) {
+	if path == "" {
+		return Parse(defaultItems)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	catalog, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return catalog, nil
+}
+
+// Default возвращает встроенный справочник
+func Default() *Catalog {
+	catalog, err := Parse(defaultItems)
+	if err != nil {
+		panic(fmt.Sprintf("catalog: embedded items.json is invalid: %v", err))
+	}
+	return catalog
+}
+
+// Parse разбирает и проверяет справочник в формате JSON
+func Parse(data []byte) (*Catalog, error) {
+	var catalog Catalog
+	if err := json.Unmarshal(data, &catalog); err != nil {
+		return nil, fmt.Errorf("invalid catalog: %w", err)
+	}
+	if err := catalog.validate(); err != nil {
+		return nil, err
+	}
+	return &catalog, nil
+}
+
+// validate проверяет все предметы и собирает все ошибки сразу
+func (c *Catalog) validate() error {
+	c.byKey = make(map[string]Item, len(c.Items))
+	c.byName = make(map[string]Item, len(c.Items))
+
+	var problems []string
+	for i, item := range c.Items {
+		where := fmt.Sprintf("item %d (%q)", i+1, item.Key)
+
+		if !keyPattern.MatchString(item.Key) {
+			problems = append(problems, where+": key must match "+keyPattern.String())
+		}
+		if strings.TrimSpace(item.Name) == "" {
+			problems = append(problems, where+": name is required")
+		}
+		if len([]rune(item.Name)) > 100 {
+			problems = append(problems, where+": name is longer than 100 characters")
+		}
+		if !Types[item.Type] {
+			problems = append(problems, fmt.Sprintf("%s: unknown type %q", where, item.Type))
+		}
+		if item.DurabilityMax < 0 {
+			problems = append(problems, where+": durability_max must not be negative")
+		}
+		if item.BurnValue < 0 {
+			problems = append(problems, where+": burn_value must not be negative")
+		}
+		if item.Has("edible") && item.Satiety <= 0 {
+			problems = append(problems, where+": edible items need satiety")
+		}
+		if !item.Has("edible") && item.Satiety != 0 {
+			problems = append(problems, where+": only edible items restore satiety")
+		}
+		if item.Type == "tool" && item.DurabilityMax == 0 {
+			problems = append(problems, where+": tools need durability_max")
+		}
+		if item.Type == "tool" && !ToolKinds[item.Tool] {
+			problems = append(problems, fmt.Sprintf("%s: unknown tool kind %q", where, item.Tool))
+		}
+		if item.Type == "tool" && item.Tier < 1 {
+			problems = append(problems, where+": tools need tier")
+		}
+		if item.Type != "tool" && (item.Tool != "" || item.Tier != 0 || item.Speed != 0 || item.Yield != 0) {
+			problems = append(problems, where+": only tools have tool, tier, speed and yield")
+		}
+		if item.Speed < 0 || item.Speed >= 100 {
+			problems = append(problems, where+": speed must be between 0 and 99")
+		}
+		if item.Yield < 0 || item.Yield > 100 {
+			problems = append(problems, where+": yield must be between 0 and 100")
+		}
+		for _, flag := range item.Flags {
+			if !Flags[flag] {
+				problems = append(problems, fmt.Sprintf("%s: unknown flag %q", where, flag))
+			}
+		}
+
+		if _, exists := c.byKey[item.Key]; exists {
+			problems = append(problems, where+": duplicate key")
+		}
+		if _, exists := c.byName[item.Name]; exists {
+			problems = append(problems, fmt.Sprintf("%s: duplicate name %q", where, item.Name))
+		}
+		c.byKey[item.Key] = item
+		c.byName[item.Name] = item
+	}
+
+	if len(c.Items) == 0 {
+		problems = append(problems, "catalog has no items")
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid catalog:\n  %s", strings.Join(problems, "\n  "))
+	}
+	return nil
+}
+
+// ByKey ищет предмет по ключу
+func (c *Catalog) ByKey(key string) (Item, bool) {
+	item, ok := c.byKey[key]
+	return item, ok
+}
+
+// ByName ищет предмет по отображаемому названию
+func (c *Catalog) ByName(name string) (Item, bool) {
+	item, ok := c.byName[name]
+	return item, ok
+}
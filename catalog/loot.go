@@ -0,0 +1,148 @@
+package catalog
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+//go:embed loot.json
+var defaultLoot []byte
+
+// Drop - предмет таблицы добычи
+type Drop struct {
+	Item      string `json:"item"`
+	Chance    int    `json:"chance"` // Шанс в процентах, 100 - выпадает всегда
+	Min       int    `json:"min"`    // Сколько выпадает: от Min до Max
+	Max       int    `json:"max"`
+	PerLevel  int    `json:"per_level"`  // Прибавка к шансу за каждый уровень локации сверх первого
+	PerTier   int    `json:"per_tier"`   // Прибавка к шансу за каждый уровень инструмента сверх первого
+	MaxChance int    `json:"max_chance"` // Предел шанса с прибавками, 0 - без предела
+}
+
+// Guaranteed сообщает, что предмет выпадает всегда
+func (d Drop) Guaranteed() bool {
+	return d.Chance >= 100
+}
+
+// Rare сообщает, что шанс предмета растет с уровнем локации или инструмента
+func (d Drop) Rare() bool {
+	return d.PerLevel > 0 || d.PerTier > 0
+}
+
+// ChanceAt - шанс выпадения в процентах в локации уровня level инструментом уровня tier
+func (d Drop) ChanceAt(level, tier int) int {
+	chance := d.Chance + d.PerLevel*max(0, level-1) + d.PerTier*max(0, tier-1)
+	if d.MaxChance > 0 {
+		chance = min(chance, d.MaxChance)
+	}
+	return min(chance, 100)
+}
+
+// LootTable - добыча с одного ресурса локации
+type LootTable struct {
+	Location string `json:"location"` // Ключ локации: "mine", "forest"...
+	Resource string `json:"resource"` // Ключ ресурса, как в callback данных поля
+	Drops    []Drop `json:"drops"`
+}
+
+// Loot - проверенный набор таблиц добычи
+type Loot struct {
+	Tables []LootTable `json:"tables"`
+}
+
+// Table ищет таблицу добычи ресурса локации
+func (l *Loot) Table(location, resource string) (*LootTable, bool) {
+	for i := range l.Tables {
+		if l.Tables[i].Location == location && l.Tables[i].Resource == resource {
+			return &l.Tables[i], true
+		}
+	}
+	return nil, false
+}
+
+// LoadLoot читает таблицы добычи из файла и проверяет их по справочнику предметов.
+// Пустой путь означает встроенные таблицы.
+func LoadLoot(path string, items *Catalog) (*Loot, error) {
+	if path == "" {
+		return ParseLoot(defaultLoot, items)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	loot, err := ParseLoot(data, items)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return loot, nil
+}
+
+// DefaultLoot возвращает встроенные таблицы добычи
+func DefaultLoot(items *Catalog) *Loot {
+	loot, err := ParseLoot(defaultLoot, items)
+	if err != nil {
+		panic(fmt.Sprintf("catalog: embedded loot.json is invalid: %v", err))
+	}
+	return loot
+}
+
+// ParseLoot разбирает и проверяет таблицы добычи в формате JSON
+func ParseLoot(data []byte, items *Catalog) (*Loot, error) {
+	var loot Loot
+	if err := json.Unmarshal(data, &loot); err != nil {
+		return nil, fmt.Errorf("invalid loot: %w", err)
+	}
+	if err := loot.validate(items); err != nil {
+		return nil, err
+	}
+	return &loot, nil
+}
+
+func (l *Loot) validate(items *Catalog) error {
+	var problems []string
+	seen := make(map[string]bool)
+
+	for i, table := range l.Tables {
+		where := fmt.Sprintf("table %d (%s/%s)", i+1, table.Location, table.Resource)
+
+		if table.Location == "" || table.Resource == "" {
+			problems = append(problems, where+": location and resource are required")
+		}
+		node := table.Location + "/" + table.Resource
+		if seen[node] {
+			problems = append(problems, where+": duplicate table")
+		}
+		seen[node] = true
+
+		guaranteed := false
+		for _, drop := range table.Drops {
+			if _, ok := items.ByName(drop.Item); !ok {
+				problems = append(problems, fmt.Sprintf("%s: unknown item %q", where, drop.Item))
+			}
+			if drop.Chance < 0 || drop.Chance > 100 || drop.PerLevel < 0 || drop.PerTier < 0 || drop.MaxChance < 0 || drop.MaxChance > 100 {
+				problems = append(problems, fmt.Sprintf("%s: chances of %q must be between 0 and 100", where, drop.Item))
+			}
+			if drop.Chance == 0 && !drop.Rare() {
+				problems = append(problems, fmt.Sprintf("%s: %q never drops", where, drop.Item))
+			}
+			if drop.Min <= 0 || drop.Max < drop.Min {
+				problems = append(problems, fmt.Sprintf("%s: %q needs 0 < min <= max", where, drop.Item))
+			}
+			if drop.Guaranteed() {
+				guaranteed = true
+			}
+		}
+		if !guaranteed {
+			problems = append(problems, where+": needs a guaranteed drop with chance 100")
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid loot:\n  %s", strings.Join(problems, "\n  "))
+	}
+	return nil
+}
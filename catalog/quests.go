@@ -0,0 +1,169 @@
+package catalog
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+//go:embed quests.json
+var defaultQuests []byte
+
+// Типы целей квестов. Совпадают с типами игровых событий, которые их продвигают.
+const (
+	ObjectiveGather   = "gather"    // Добыть ресурс; цель - название предмета
+	ObjectiveCraft    = "craft"     // Создать предмет; цель - название предмета
+	ObjectiveBuild    = "build"     // Возвести постройку; цель - ключ рецепта постройки
+	ObjectiveEat      = "eat"       // Съесть еду; цель - название предмета
+	ObjectiveHunt     = "hunt"      // Поохотиться; цель - название добычи
+	ObjectiveReadPage = "read_page" // Прочитать страницу лора; цель - номер страницы
+)
+
+// ObjectiveTypes - допустимые типы целей
+var ObjectiveTypes = map[string]bool{
+	ObjectiveGather:   true,
+	ObjectiveCraft:    true,
+	ObjectiveBuild:    true,
+	ObjectiveEat:      true,
+	ObjectiveHunt:     true,
+	ObjectiveReadPage: true,
+}
+
+// Objective - цель квеста
+type Objective struct {
+	Type    string `json:"type"`
+	Target  string `json:"target"` // Пусто - подходит любая цель этого типа
+	Count   int    `json:"count"`
+	Ordered bool   `json:"ordered"` // Цели засчитываются только по порядку номеров: 1, 2, 3...
+}
+
+// Rewards - награда за квест
+type Rewards struct {
+	Experience int          `json:"experience"`
+	Items      []Ingredient `json:"items"`
+}
+
+// Quest - описание квеста
+type Quest struct {
+	ID        int       `json:"id"`
+	Title     string    `json:"title"`
+	Task      string    `json:"task"`
+	Hint      string    `json:"hint"`
+	Requires  []int     `json:"requires"` // Квесты, которые нужно выполнить до этого
+	Objective Objective `json:"objective"`
+	Rewards   Rewards   `json:"rewards"`
+}
+
+// Quests - проверенный набор квестов в порядке цепочки
+type Quests struct {
+	Quests []Quest `json:"quests"`
+}
+
+// LoadQuests читает квесты из файла и проверяет их по предметам и рецептам.
+// Пустой путь означает встроенные квесты.
+func LoadQuests(path string, items *Catalog, recipes *Recipes) (*Quests, error) {
+	if path == "" {
+		return ParseQuests(defaultQuests, items, recipes)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	quests, err := ParseQuests(data, items, recipes)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return quests, nil
+}
+
+// DefaultQuests возвращает встроенные квесты
+func DefaultQuests(items *Catalog, recipes *Recipes) *Quests {
+	quests, err := ParseQuests(defaultQuests, items, recipes)
+	if err != nil {
+		panic(fmt.Sprintf("catalog: embedded quests.json is invalid: %v", err))
+	}
+	return quests
+}
+
+// ParseQuests разбирает и проверяет квесты в формате JSON
+func ParseQuests(data []byte, items *Catalog, recipes *Recipes) (*Quests, error) {
+	var quests Quests
+	if err := json.Unmarshal(data, &quests); err != nil {
+		return nil, fmt.Errorf("invalid quests: %w", err)
+	}
+	if err := quests.validate(items, recipes); err != nil {
+		return nil, err
+	}
+	return &quests, nil
+}
+
+func (q *Quests) validate(items *Catalog, recipes *Recipes) error {
+	buildings := make(map[string]bool)
+	for _, recipe := range recipes.Recipes {
+		if recipe.Station == StationConstruction {
+			buildings[recipe.Key] = true
+		}
+	}
+
+	var problems []string
+	seen := make(map[int]bool)
+
+	for i, quest := range q.Quests {
+		where := fmt.Sprintf("quest %d (id %d)", i+1, quest.ID)
+
+		if quest.ID <= 0 {
+			problems = append(problems, where+": id must be positive")
+		}
+		if seen[quest.ID] {
+			problems = append(problems, where+": duplicate id")
+		}
+		if strings.TrimSpace(quest.Title) == "" || strings.TrimSpace(quest.Task) == "" {
+			problems = append(problems, where+": title and task are required")
+		}
+		// Цепочка идет сверху вниз, поэтому требуемые квесты должны быть описаны раньше
+		for _, required := range quest.Requires {
+			if !seen[required] {
+				problems = append(problems, fmt.Sprintf("%s: requires quest %d that is not defined above it", where, required))
+			}
+		}
+		seen[quest.ID] = true
+
+		objective := quest.Objective
+		if !ObjectiveTypes[objective.Type] {
+			problems = append(problems, fmt.Sprintf("%s: unknown objective type %q", where, objective.Type))
+		}
+		if objective.Count <= 0 {
+			problems = append(problems, where+": objective count must be positive")
+		}
+		switch objective.Type {
+		case ObjectiveGather, ObjectiveCraft, ObjectiveEat, ObjectiveHunt:
+			if _, ok := items.ByName(objective.Target); objective.Target != "" && !ok {
+				problems = append(problems, fmt.Sprintf("%s: unknown objective item %q", where, objective.Target))
+			}
+		case ObjectiveBuild:
+			if !buildings[objective.Target] {
+				problems = append(problems, fmt.Sprintf("%s: unknown building %q", where, objective.Target))
+			}
+		}
+
+		if quest.Rewards.Experience < 0 {
+			problems = append(problems, where+": reward experience must not be negative")
+		}
+		for _, reward := range quest.Rewards.Items {
+			if _, ok := items.ByName(reward.Item); !ok {
+				problems = append(problems, fmt.Sprintf("%s: unknown reward item %q", where, reward.Item))
+			}
+			if reward.Quantity <= 0 {
+				problems = append(problems, fmt.Sprintf("%s: reward %q needs a positive quantity", where, reward.Item))
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid quests:\n  %s", strings.Join(problems, "\n  "))
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package catalog
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+//go:embed recipes.json
+var defaultRecipes []byte
+
+// Станции, на которых создаются предметы
+const (
+	StationWorkbench    = "верстак"
+	StationFurnace      = "печь"
+	StationCampfire     = "костер"
+	StationConstruction = "постройки" // Результат - постройка, а не предмет инвентаря
+)
+
+// Stations - допустимые станции рецептов
+var Stations = map[string]bool{
+	StationWorkbench:    true,
+	StationFurnace:      true,
+	StationCampfire:     true,
+	StationConstruction: true,
+}
+
+// FuelStations - станции, которые жгут топливо
+var FuelStations = map[string]bool{
+	StationFurnace:  true,
+	StationCampfire: true,
+}
+
+// Ingredient - ингредиент рецепта на одно создание
+type Ingredient struct {
+	Item     string `json:"item"`
+	Quantity int    `json:"quantity"`
+}
+
+// Recipe - рецепт. Ключ используется в команде /create_<key>.
+type Recipe struct {
+	Key            string       `json:"key"`
+	Output         string       `json:"output"`
+	OutputQuantity int          `json:"output_quantity"`
+	Station        string       `json:"station"`
+	CraftTime      int          `json:"craft_time"`   // Секунд на одно создание
+	SatietyCost    int          `json:"satiety_cost"` // Сытости на одно создание
+	Fuel           int          `json:"fuel"`         // Единиц топлива на одно создание в печи или костре
+	Ingredients    []Ingredient `json:"ingredients"`
+}
+
+// Recipes - проверенный набор рецептов
+type Recipes struct {
+	Recipes []Recipe `json:"recipes"`
+}
+
+// LoadRecipes читает рецепты из файла и проверяет их по справочнику предметов.
+// Пустой путь означает встроенные рецепты.
+func LoadRecipes(path string, items *Catalog) (*Recipes, error) {
+	if path == "" {
+		return ParseRecipes(defaultRecipes, items)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	recipes, err := ParseRecipes(data, items)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return recipes, nil
+}
+
+// DefaultRecipes возвращает встроенные рецепты
+func DefaultRecipes(items *Catalog) *Recipes {
+	recipes, err := ParseRecipes(defaultRecipes, items)
+	if err != nil {
+		panic(fmt.Sprintf("catalog: embedded recipes.json is invalid: %v", err))
+	}
+	return recipes
+}
+
+// ParseRecipes разбирает и проверяет рецепты в формате JSON
+func ParseRecipes(data []byte, items *Catalog) (*Recipes, error) {
+	var recipes Recipes
+	if err := json.Unmarshal(data, &recipes); err != nil {
+		return nil, fmt.Errorf("invalid recipes: %w", err)
+	}
+	if err := recipes.validate(items); err != nil {
+		return nil, err
+	}
+	return &recipes, nil
+}
+
+func (r *Recipes) validate(items *Catalog) error {
+	var problems []string
+	keys := make(map[string]bool)
+
+	for i, recipe := range r.Recipes {
+		where := fmt.Sprintf("recipe %d (%q)", i+1, recipe.Key)
+
+		if !keyPattern.MatchString(recipe.Key) {
+			problems = append(problems, where+": key must match "+keyPattern.String())
+		}
+		if keys[recipe.Key] {
+			problems = append(problems, where+": duplicate key")
+		}
+		keys[recipe.Key] = true
+
+		if !Stations[recipe.Station] {
+			problems = append(problems, fmt.Sprintf("%s: unknown station %q", where, recipe.Station))
+		}
+		// Постройки не хранятся в инвентаре, поэтому их нет в справочнике предметов
+		if recipe.Station != StationConstruction {
+			if _, ok := items.ByName(recipe.Output); !ok {
+				problems = append(problems, fmt.Sprintf("%s: unknown output item %q", where, recipe.Output))
+			}
+		} else if strings.TrimSpace(recipe.Output) == "" {
+			problems = append(problems, where+": output is required")
+		}
+		if recipe.OutputQuantity <= 0 {
+			problems = append(problems, where+": output_quantity must be positive")
+		}
+		if recipe.CraftTime <= 0 {
+			problems = append(problems, where+": craft_time must be positive")
+		}
+		if recipe.SatietyCost < 0 {
+			problems = append(problems, where+": satiety_cost must not be negative")
+		}
+		if recipe.Fuel < 0 {
+			problems = append(problems, where+": fuel must not be negative")
+		}
+		if recipe.Fuel > 0 && !FuelStations[recipe.Station] {
+			problems = append(problems, fmt.Sprintf("%s: station %q does not use fuel", where, recipe.Station))
+		}
+
+		if len(recipe.Ingredients) == 0 {
+			problems = append(problems, where+": ingredients are required")
+		}
+		seen := make(map[string]bool)
+		for _, ingredient := range recipe.Ingredients {
+			if _, ok := items.ByName(ingredient.Item); !ok {
+				problems = append(problems, fmt.Sprintf("%s: unknown ingredient %q", where, ingredient.Item))
+			}
+			if ingredient.Quantity <= 0 {
+				problems = append(problems, fmt.Sprintf("%s: ingredient %q needs a positive quantity", where, ingredient.Item))
+			}
+			if seen[ingredient.Item] {
+				problems = append(problems, fmt.Sprintf("%s: duplicate ingredient %q", where, ingredient.Item))
+			}
+			seen[ingredient.Item] = true
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid recipes:\n  %s", strings.Join(problems, "\n  "))
+	}
+	return nil
+}
@@ -0,0 +1,195 @@
+package catalog
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+//go:embed survival.json
+var defaultSurvival []byte
+
+// MaxSpeedBonus - предел ускорения действий от всех баффов вместе, в процентах
+const MaxSpeedBonus = 50
+
+// Penalty - штраф голода
+type Penalty struct {
+	Below    int    `json:"below"`    // Штраф действует, пока сытость ниже этого значения
+	Slowdown int    `json:"slowdown"` // На сколько процентов дольше идут действия
+	Name     string `json:"name"`
+}
+
+// Buff - временный бафф от еды
+type Buff struct {
+	Item    string `json:"item"` // Еда, которая дает бафф
+	Name    string `json:"name"`
+	Speed   int    `json:"speed"`   // На сколько процентов быстрее идут действия
+	Yield   int    `json:"yield"`   // Шанс в процентах добыть лишнюю единицу ресурса
+	Minutes int    `json:"minutes"` // Сколько длится бафф
+}
+
+// Duration - длительность баффа
+func (b Buff) Duration() time.Duration {
+	return time.Duration(b.Minutes) * time.Minute
+}
+
+// Survival - правила сытости, с которыми сверяются все действия: убывание со временем,
+// траты на действия, штрафы голода и баффы от еды
+type Survival struct {
+	DecayMinutes int            `json:"decay_minutes"` // Раз в столько минут сытость падает на единицу
+	Actions      map[string]int `json:"actions"`       // Сытости за одно действие по его виду: "mining", "chopping"...
+	Rest         int            `json:"rest"`          // Сколько сытости восстанавливает отдых в хижине
+	Penalties    []Penalty      `json:"penalties"`
+	Buffs        []Buff         `json:"buffs"`
+}
+
+// DecayEvery - за сколько времени сытость падает на единицу
+func (s *Survival) DecayEvery() time.Duration {
+	return time.Duration(s.DecayMinutes) * time.Minute
+}
+
+// ActionCost - сколько сытости тратит одно действие вида kind
+func (s *Survival) ActionCost(kind string) int {
+	return s.Actions[kind]
+}
+
+// CanWork сообщает, хватает ли сытости, чтобы работать
+func (s *Survival) CanWork(satiety int) bool {
+	return satiety > 0
+}
+
+// PenaltyAt возвращает самый сильный штраф, который действует при этой сытости
+func (s *Survival) PenaltyAt(satiety int) (Penalty, bool) {
+	var worst Penalty
+	found := false
+	for _, penalty := range s.Penalties {
+		if satiety < penalty.Below && (!found || penalty.Slowdown > worst.Slowdown) {
+			worst, found = penalty, true
+		}
+	}
+	return worst, found
+}
+
+// BuffFor ищет бафф, который дает еда
+func (s *Survival) BuffFor(item string) (Buff, bool) {
+	for _, buff := range s.Buffs {
+		if buff.Item == item {
+			return buff, true
+		}
+	}
+	return Buff{}, false
+}
+
+// Duration - время действия с учетом штрафа голода и ускорения от баффов
+func (s *Survival) Duration(base int, satiety int, speed int) int {
+	slowdown := 0
+	if penalty, ok := s.PenaltyAt(satiety); ok {
+		slowdown = penalty.Slowdown
+	}
+	speed = min(max(speed, 0), MaxSpeedBonus)
+	return max(1, base*(100+slowdown)/100*(100-speed)/100)
+}
+
+// LoadSurvival читает правила сытости из файла и проверяет их по справочнику предметов.
+// Пустой путь означает встроенные правила.
+func LoadSurvival(path string, items *Catalog) (*Survival, error) {
+	if path == "" {
+		return ParseSurvival(defaultSurvival, items)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	survival, err := ParseSurvival(data, items)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return survival, nil
+}
+
+// DefaultSurvival возвращает встроенные правила сытости
+func DefaultSurvival(items *Catalog) *Survival {
+	survival, err := ParseSurvival(defaultSurvival, items)
+	if err != nil {
+		panic(fmt.Sprintf("catalog: embedded survival.json is invalid: %v", err))
+	}
+	return survival
+}
+
+// ParseSurvival разбирает и проверяет правила сытости в формате JSON
+func ParseSurvival(data []byte, items *Catalog) (*Survival, error) {
+	var survival Survival
+	if err := json.Unmarshal(data, &survival); err != nil {
+		return nil, fmt.Errorf("invalid survival rules: %w", err)
+	}
+	if err := survival.validate(items); err != nil {
+		return nil, err
+	}
+	return &survival, nil
+}
+
+func (s *Survival) validate(items *Catalog) error {
+	var problems []string
+
+	if s.DecayMinutes <= 0 {
+		problems = append(problems, "decay_minutes must be positive")
+	}
+	if s.Rest < 0 {
+		problems = append(problems, "rest must not be negative")
+	}
+	for kind, cost := range s.Actions {
+		if cost < 0 {
+			problems = append(problems, fmt.Sprintf("action %q: cost must not be negative", kind))
+		}
+	}
+
+	for i, penalty := range s.Penalties {
+		where := fmt.Sprintf("penalty %d (%s)", i+1, penalty.Name)
+		if penalty.Name == "" {
+			problems = append(problems, where+": name is required")
+		}
+		if penalty.Below <= 0 || penalty.Below > 100 {
+			problems = append(problems, where+": below must be between 1 and 100")
+		}
+		if penalty.Slowdown <= 0 {
+			problems = append(problems, where+": slowdown must be positive")
+		}
+	}
+
+	seen := make(map[string]bool)
+	for i, buff := range s.Buffs {
+		where := fmt.Sprintf("buff %d (%s)", i+1, buff.Item)
+		item, ok := items.ByName(buff.Item)
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s: unknown item %q", where, buff.Item))
+		} else if !item.Has("edible") {
+			problems = append(problems, fmt.Sprintf("%s: %q is not edible", where, buff.Item))
+		}
+		if seen[buff.Item] {
+			problems = append(problems, where+": duplicate buff")
+		}
+		seen[buff.Item] = true
+
+		if buff.Name == "" {
+			problems = append(problems, where+": name is required")
+		}
+		if buff.Speed < 0 || buff.Speed > MaxSpeedBonus || buff.Yield < 0 || buff.Yield > 100 {
+			problems = append(problems, fmt.Sprintf("%s: speed must be between 0 and %d, yield between 0 and 100", where, MaxSpeedBonus))
+		}
+		if buff.Speed == 0 && buff.Yield == 0 {
+			problems = append(problems, where+": buff gives nothing")
+		}
+		if buff.Minutes <= 0 {
+			problems = append(problems, where+": minutes must be positive")
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid survival rules:\n  %s", strings.Join(problems, "\n  "))
+	}
+	return nil
+}
@@ -0,0 +1,194 @@
+// Package clock отделяет игровые таймеры от системного времени,
+// чтобы в тестах длительные действия завершались мгновенно.
+package clock
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock - источник времени и таймеров для игровых действий
+type Clock interface {
+	Now() time.Time
+	Sleep(d time.Duration)
+	After(d time.Duration) <-chan time.Time
+	NewTicker(d time.Duration) Ticker
+}
+
+// Ticker - периодический сигнал, аналог time.Ticker
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// Real возвращает часы на системном времени
+func Real() Clock {
+	return realClock{}
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) Sleep(d time.Duration)                  { time.Sleep(d) }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+func (realClock) NewTicker(d time.Duration) Ticker       { return realTicker{time.NewTicker(d)} }
+
+type realTicker struct {
+	ticker *time.Ticker
+}
+
+func (t realTicker) C() <-chan time.Time { return t.ticker.C }
+func (t realTicker) Stop()               { t.ticker.Stop() }
+
+// Fake - часы, время на которых идет только при вызове Advance.
+// Таймеры и тикеры срабатывают, когда время доходит до их срока.
+type Fake struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []*waiter
+	changed chan struct{} // закрывается при добавлении ожидающего
+}
+
+// waiter - ожидающий таймер или тикер (period > 0)
+type waiter struct {
+	deadline time.Time
+	period   time.Duration
+	ch       chan time.Time
+	stopped  bool
+}
+
+// NewFake создает часы, показывающие время start
+func NewFake(start time.Time) *Fake {
+	return &Fake{now: start, changed: make(chan struct{})}
+}
+
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.now
+}
+
+func (f *Fake) Sleep(d time.Duration) {
+	<-f.After(d)
+}
+
+func (f *Fake) After(d time.Duration) <-chan time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	w := &waiter{deadline: f.now.Add(d), ch: make(chan time.Time, 1)}
+	if d <= 0 {
+		w.ch <- f.now
+		return w.ch
+	}
+	f.add(w)
+	return w.ch
+}
+
+func (f *Fake) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("clock: non-positive interval for NewTicker")
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	w := &waiter{deadline: f.now.Add(d), period: d, ch: make(chan time.Time, 1)}
+	f.add(w)
+	return &fakeTicker{clock: f, waiter: w}
+}
+
+func (f *Fake) add(w *waiter) {
+	f.waiters = append(f.waiters, w)
+	close(f.changed)
+	f.changed = make(chan struct{})
+}
+
+// Advance переводит часы вперед на d и запускает все наступившие таймеры.
+// Как и у time.Ticker, пропущенные срабатывания тикера не накапливаются.
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.now = f.now.Add(d)
+
+	sort.SliceStable(f.waiters, func(i, j int) bool {
+		return f.waiters[i].deadline.Before(f.waiters[j].deadline)
+	})
+
+	active := f.waiters[:0]
+	for _, w := range f.waiters {
+		if w.stopped {
+			continue
+		}
+		if w.deadline.After(f.now) {
+			active = append(active, w)
+			continue
+		}
+
+		select {
+		case w.ch <- w.deadline:
+		default:
+		}
+
+		if w.period > 0 {
+			for !w.deadline.After(f.now) {
+				w.deadline = w.deadline.Add(w.period)
+			}
+			active = append(active, w)
+		}
+	}
+	f.waiters = active
+}
+
+// Waiters возвращает количество ожидающих таймеров и тикеров
+func (f *Fake) Waiters() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.pending()
+}
+
+func (f *Fake) pending() int {
+	count := 0
+	for _, w := range f.waiters {
+		if !w.stopped {
+			count++
+		}
+	}
+	return count
+}
+
+// BlockUntil ждет, пока на часах не окажется хотя бы n ожидающих таймеров и тикеров.
+// Позволяет тесту дождаться, пока фоновая горутина дойдет до ожидания.
+func (f *Fake) BlockUntil(n int) {
+	for {
+		f.mu.Lock()
+		count := f.pending()
+		changed := f.changed
+		f.mu.Unlock()
+
+		if count >= n {
+			return
+		}
+		<-changed
+	}
+}
+
+type fakeTicker struct {
+	clock  *Fake
+	waiter *waiter
+}
+
+func (t *fakeTicker) C() <-chan time.Time {
+	return t.waiter.ch
+}
+
+func (t *fakeTicker) Stop() {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	t.waiter.stopped = true
+}
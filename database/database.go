@@ -2,25 +2,24 @@ package database
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"log"
+	"reborn_land/catalog"
 	"reborn_land/models"
+	"strings"
+	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
 type DB struct {
 	conn *sql.DB
 }
 
-// Item представляет предмет в инвентаре игрока
-type Item struct {
-	ItemName string
-	ItemType string
-	Quantity int
-}
-
-func New(databaseURL string) (*DB, error) {
+// Open подключается к базе данных без применения миграций.
+// Используется командой migrate.
+func Open(databaseURL string) (*DB, error) {
 	conn, err := sql.Open("postgres", databaseURL)
 	if err != nil {
 		return nil, err
@@ -30,147 +29,147 @@ func New(databaseURL string) (*DB, error) {
 		return nil, err
 	}
 
-	db := &DB{conn: conn}
+	return &DB{conn: conn}, nil
+}
+
+func New(databaseURL string, items *catalog.Catalog, recipes *catalog.Recipes) (*DB, error) {
+	db, err := Open(databaseURL)
+	if err != nil {
+		return nil, err
+	}
 
-	// Создаем таблицы при инициализации
-	if err := db.createTables(); err != nil {
+	// Применяем миграции при инициализации
+	if err := db.MigrateUp(); err != nil {
 		return nil, err
 	}
 
-	// Добавляем начальные предметы
-	if err := db.seedItems(); err != nil {
+	// Синхронизируем справочник предметов
+	if err := db.SyncItems(items); err != nil {
+		return nil, err
+	}
+
+	// Рецепты ссылаются на предметы, поэтому синхронизируются после них
+	if err := db.SyncRecipes(recipes); err != nil {
 		return nil, err
 	}
 
 	return db, nil
 }
 
-func (db *DB) createTables() error {
-	queries := []string{
-		`CREATE TABLE IF NOT EXISTS players (
-			id SERIAL PRIMARY KEY,
-			telegram_id BIGINT UNIQUE NOT NULL,
-			name VARCHAR(30) NOT NULL,
-			level INTEGER DEFAULT 1,
-			experience INTEGER DEFAULT 0,
-			satiety INTEGER DEFAULT 100,
-			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-			simple_hut_built BOOLEAN DEFAULT false
-		)`,
-		`CREATE TABLE IF NOT EXISTS items (
-			id SERIAL PRIMARY KEY,
-			name VARCHAR(100) NOT NULL,
-			type VARCHAR(50) NOT NULL,
-			durability_max INTEGER DEFAULT 0
-		)`,
-		`CREATE TABLE IF NOT EXISTS inventory (
-			id SERIAL PRIMARY KEY,
-			player_id INTEGER REFERENCES players(id),
-			item_id INTEGER REFERENCES items(id),
-			quantity INTEGER DEFAULT 1,
-			durability INTEGER DEFAULT 0
-		)`,
-		`CREATE TABLE IF NOT EXISTS mines (
-			id SERIAL PRIMARY KEY,
-			player_id INTEGER REFERENCES players(id),
-			level INTEGER DEFAULT 1,
-			experience INTEGER DEFAULT 0,
-			last_used TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-			is_exhausted BOOLEAN DEFAULT false
-		)`,
-		`CREATE TABLE IF NOT EXISTS forests (
-			id SERIAL PRIMARY KEY,
-			player_id INTEGER REFERENCES players(id),
-			level INTEGER DEFAULT 1,
-			experience INTEGER DEFAULT 0,
-			last_used TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-			is_exhausted BOOLEAN DEFAULT false
-		)`,
-		`CREATE TABLE IF NOT EXISTS gathering (
-			id SERIAL PRIMARY KEY,
-			player_id INTEGER REFERENCES players(id),
-			level INTEGER DEFAULT 1,
-			experience INTEGER DEFAULT 0,
-			last_used TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-			is_exhausted BOOLEAN DEFAULT false
-		)`,
-		`CREATE TABLE IF NOT EXISTS quests (
-			id SERIAL PRIMARY KEY,
-			player_id INTEGER REFERENCES players(id),
-			quest_id INTEGER NOT NULL,
-			status VARCHAR(20) DEFAULT 'available',
-			progress INTEGER DEFAULT 0,
-			target INTEGER NOT NULL,
-			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-			completed_at TIMESTAMP NULL
-		)`,
-	}
-
-	for _, query := range queries {
-		if _, err := db.conn.Exec(query); err != nil {
+// SyncItems приводит таблицу items в соответствие со справочником.
+// Предметы ищутся по названию; предметы, которых нет в справочнике, не удаляются,
+// потому что на них могут ссылаться записи инвентаря.
+func (db *DB) SyncItems(items *catalog.Catalog) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, item := range items.Items {
+		result, err := tx.Exec(`
+			INSERT INTO items (key, name, type, durability_max, burn_value, satiety, tool, tier, speed, yield, description, flags)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+			ON CONFLICT (name) DO UPDATE
+			SET key = EXCLUDED.key,
+				type = EXCLUDED.type,
+				durability_max = EXCLUDED.durability_max,
+				burn_value = EXCLUDED.burn_value,
+				satiety = EXCLUDED.satiety,
+				tool = EXCLUDED.tool,
+				tier = EXCLUDED.tier,
+				speed = EXCLUDED.speed,
+				yield = EXCLUDED.yield,
+				description = EXCLUDED.description,
+				flags = EXCLUDED.flags
+			WHERE (items.key, items.type, items.durability_max, items.burn_value, items.satiety, items.tool, items.tier, items.speed, items.yield, items.description, items.flags)
+				IS DISTINCT FROM (EXCLUDED.key, EXCLUDED.type, EXCLUDED.durability_max, EXCLUDED.burn_value, EXCLUDED.satiety, EXCLUDED.tool, EXCLUDED.tier, EXCLUDED.speed, EXCLUDED.yield, EXCLUDED.description, EXCLUDED.flags)`,
+			item.Key, item.Name, item.Type, item.DurabilityMax, item.BurnValue, item.Satiety, item.Tool, item.Tier, item.Speed, item.Yield, item.Description, strings.Join(item.Flags, ","),
+		)
+		if err != nil {
+			return fmt.Errorf("sync item %s: %w", item.Key, err)
+		}
+		if rows, _ := result.RowsAffected(); rows > 0 {
+			log.Printf("Synced item: %s", item.Name)
+		}
+	}
+
+	rows, err := tx.Query("SELECT name FROM items")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
 			return err
 		}
+		if _, ok := items.ByName(name); !ok {
+			log.Printf("Item %q is in the database but not in the catalog", name)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
 	}
 
-	return nil
+	return tx.Commit()
 }
 
-func (db *DB) seedItems() error {
-	// Список всех необходимых предметов
-	items := []struct {
-		name          string
-		itemType      string
-		durabilityMax int
-	}{
-		{"Простой топор", "tool", 100},
-		{"Простой нож", "tool", 100},
-		{"Лесная ягода", "food", 0},
-		{"Простая кирка", "tool", 100},
-		{"Простой лук", "tool", 100},
-		{"Стрелы", "ammunition", 0},
-		{"Простая удочка", "tool", 100},
-		{"Березовый брус", "material", 0},
-		{"Камень", "material", 0},
-		{"Уголь", "material", 0},
-		{"Сухожилие", "material", 0},
-		{"Перо", "material", 0},
-		{"Кость", "material", 0},
-		{"Веревка", "material", 0},
-		{"Крючок", "material", 0},
-		{"Береза", "material", 0},
-		{"📖 Страница 1 «Забытая тишина»", "quest_item", 0},
-		{"📖 Страница 2 «Пепел памяти»", "quest_item", 0},
-		{"📖 Страница 3 «Пробуждение»", "quest_item", 0},
-		{"📖 Страница 4 «Без имени»", "quest_item", 0},
-		{"📖 Страница 5 «Искра перемен»", "quest_item", 0},
-		{"📖 Страница 6 «Наблюдающий лес»", "quest_item", 0},
-	}
-
-	// Добавляем каждый предмет, если его нет
-	for _, item := range items {
-		var exists bool
-		err := db.conn.QueryRow(
-			"SELECT EXISTS(SELECT 1 FROM items WHERE name = $1)",
-			item.name,
-		).Scan(&exists)
-
+// SyncRecipes приводит таблицы recipes и recipe_ingredients в соответствие с файлом рецептов.
+// Рецепты ищутся по ключу; рецепты, которых нет в файле, удаляются.
+func (db *DB) SyncRecipes(book *catalog.Recipes) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	keys := make([]string, 0, len(book.Recipes))
+	for position, recipe := range book.Recipes {
+		var recipeID int
+		err := tx.QueryRow(`
+			INSERT INTO recipes (key, output_item, output_quantity, station, craft_time, satiety_cost, fuel, position)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			ON CONFLICT (key) DO UPDATE
+			SET output_item = EXCLUDED.output_item,
+				output_quantity = EXCLUDED.output_quantity,
+				station = EXCLUDED.station,
+				craft_time = EXCLUDED.craft_time,
+				satiety_cost = EXCLUDED.satiety_cost,
+				fuel = EXCLUDED.fuel,
+				position = EXCLUDED.position
+			RETURNING id`,
+			recipe.Key, recipe.Output, recipe.OutputQuantity, recipe.Station, recipe.CraftTime, recipe.SatietyCost, recipe.Fuel, position,
+		).Scan(&recipeID)
 		if err != nil {
-			return err
+			return fmt.Errorf("sync recipe %s: %w", recipe.Key, err)
 		}
 
-		if !exists {
-			_, err := db.conn.Exec(
-				"INSERT INTO items (name, type, durability_max) VALUES ($1, $2, $3)",
-				item.name, item.itemType, item.durabilityMax,
+		if _, err := tx.Exec(`DELETE FROM recipe_ingredients WHERE recipe_id = $1`, recipeID); err != nil {
+			return err
+		}
+		for i, ingredient := range recipe.Ingredients {
+			_, err := tx.Exec(`
+				INSERT INTO recipe_ingredients (recipe_id, item_id, quantity, position)
+				SELECT $1, id, $3, $4 FROM items WHERE name = $2`,
+				recipeID, ingredient.Item, ingredient.Quantity, i,
 			)
 			if err != nil {
-				return err
+				return fmt.Errorf("sync recipe %s: %w", recipe.Key, err)
 			}
-			log.Printf("Added item: %s", item.name)
 		}
+		keys = append(keys, recipe.Key)
 	}
 
-	return nil
+	result, err := tx.Exec(`DELETE FROM recipes WHERE NOT (key = ANY($1))`, pq.Array(keys))
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows > 0 {
+		log.Printf("Removed %d recipes that are not in the recipes file", rows)
+	}
+
+	return tx.Commit()
 }
 
 func (db *DB) PlayerExists(telegramID int64) (bool, error) {
@@ -182,10 +181,10 @@ func (db *DB) PlayerExists(telegramID int64) (bool, error) {
 func (db *DB) GetPlayer(telegramID int64) (*models.Player, error) {
 	var player models.Player
 	err := db.conn.QueryRow(`
-		SELECT id, telegram_id, name, level, experience, satiety, created_at, simple_hut_built
+		SELECT id, telegram_id, name, level, experience, satiety, satiety_updated_at, created_at, simple_hut_built
 		FROM players WHERE telegram_id = $1`,
 		telegramID,
-	).Scan(&player.ID, &player.TelegramID, &player.Name, &player.Level, &player.Experience, &player.Satiety, &player.CreatedAt, &player.SimpleHutBuilt)
+	).Scan(&player.ID, &player.TelegramID, &player.Name, &player.Level, &player.Experience, &player.Satiety, &player.SatietyUpdatedAt, &player.CreatedAt, &player.SimpleHutBuilt)
 
 	if err != nil {
 		return nil, err
@@ -194,13 +193,32 @@ func (db *DB) GetPlayer(telegramID int64) (*models.Player, error) {
 	return &player, nil
 }
 
+// inventoryColumns - поля записи инвентаря для scanInventoryItem
+const inventoryColumns = `
+	SELECT i.id, i.player_id, i.item_id, it.name, i.quantity, i.durability, it.durability_max, it.type,
+		i.equipped, i.attributes
+	FROM inventory i
+	JOIN items it ON i.item_id = it.id`
+
+func scanInventoryItem(row interface{ Scan(...interface{}) error }) (models.InventoryItem, error) {
+	var item models.InventoryItem
+	var attributes []byte
+	err := row.Scan(&item.ID, &item.PlayerID, &item.ItemID, &item.ItemName, &item.Quantity, &item.Durability,
+		&item.DurabilityMax, &item.Type, &item.Equipped, &attributes)
+	if err != nil {
+		return item, err
+	}
+	if err := json.Unmarshal(attributes, &item.Attributes); err != nil {
+		return item, fmt.Errorf("inventory %d attributes: %w", item.ID, err)
+	}
+	return item, nil
+}
+
+// GetPlayerInventory возвращает инвентарь игрока. Каждый экземпляр инструмента - отдельная запись.
 func (db *DB) GetPlayerInventory(playerID int) ([]models.InventoryItem, error) {
-	rows, err := db.conn.Query(`
-		SELECT i.id, i.player_id, i.item_id, it.name, i.quantity, i.durability, it.type
-		FROM inventory i
-		JOIN items it ON i.item_id = it.id
+	rows, err := db.conn.Query(inventoryColumns+`
 		WHERE i.player_id = $1 AND i.quantity > 0
-		ORDER BY it.type, it.name`,
+		ORDER BY it.type, it.name, i.id`,
 		playerID,
 	)
 	if err != nil {
@@ -210,15 +228,14 @@ func (db *DB) GetPlayerInventory(playerID int) ([]models.InventoryItem, error) {
 
 	var items []models.InventoryItem
 	for rows.Next() {
-		var item models.InventoryItem
-		err := rows.Scan(&item.ID, &item.PlayerID, &item.ItemID, &item.ItemName, &item.Quantity, &item.Durability, &item.Type)
+		item, err := scanInventoryItem(rows)
 		if err != nil {
 			return nil, err
 		}
 		items = append(items, item)
 	}
 
-	return items, nil
+	return items, rows.Err()
 }
 
 func (db *DB) GetItemQuantityInInventory(playerID int, itemName string) (int, error) {
@@ -234,435 +251,689 @@ func (db *DB) GetItemQuantityInInventory(playerID int, itemName string) (int, er
 	return quantity, err
 }
 
-func (db *DB) GetRecipeRequirements(itemName string) ([]models.RecipeIngredient, error) {
-	// Возвращаем статические рецепты для демонстрации
-	// В будущем это можно вынести в отдельную таблицу
-	recipes := map[string][]models.RecipeIngredient{
-		"Простой топор": {
-			{ItemName: "Березовый брус", Quantity: 1},
-			{ItemName: "Камень", Quantity: 1},
-		},
-		"Простая кирка": {
-			{ItemName: "Березовый брус", Quantity: 1},
-			{ItemName: "Камень", Quantity: 1},
-		},
-		"Простой лук": {
-			{ItemName: "Березовый брус", Quantity: 1},
-			{ItemName: "Сухожилие", Quantity: 1},
-		},
-		"Стрелы": {
-			{ItemName: "Березовый брус", Quantity: 1},
-			{ItemName: "Камень", Quantity: 1},
-			{ItemName: "Перо", Quantity: 1},
-		},
-		"Простой нож": {
-			{ItemName: "Березовый брус", Quantity: 1},
-			{ItemName: "Кость", Quantity: 1},
-		},
-		"Простая удочка": {
-			{ItemName: "Березовый брус", Quantity: 1},
-			{ItemName: "Веревка", Quantity: 1},
-			{ItemName: "Крючок", Quantity: 1},
-		},
-		"Березовый брус": {
-			{ItemName: "Береза", Quantity: 2},
-		},
-	}
-
-	if recipe, exists := recipes[itemName]; exists {
-		return recipe, nil
-	}
-
-	return nil, fmt.Errorf("recipe not found for item: %s", itemName)
-}
-
-func (db *DB) GetOrCreateMine(playerID int) (*models.Mine, error) {
-	var mine models.Mine
-
-	// Пытаемся найти существующую шахту
-	err := db.conn.QueryRow(`
-		SELECT id, player_id, level, experience, last_used, is_exhausted 
-		FROM mines WHERE player_id = $1`, playerID,
-	).Scan(&mine.ID, &mine.PlayerID, &mine.Level, &mine.Experience, &mine.LastUsed, &mine.IsExhausted)
+// InsufficientItemError - у игрока не хватает ингредиента
+type InsufficientItemError struct {
+	ItemName string
+}
 
-	if err == sql.ErrNoRows {
-		// Создаем новую шахту
-		err = db.conn.QueryRow(`
-			INSERT INTO mines (player_id, level, experience, is_exhausted) 
-			VALUES ($1, 1, 0, false) 
-			RETURNING id, player_id, level, experience, last_used, is_exhausted`,
-			playerID,
-		).Scan(&mine.ID, &mine.PlayerID, &mine.Level, &mine.Experience, &mine.LastUsed, &mine.IsExhausted)
-	}
+func (e *InsufficientItemError) Error() string {
+	return fmt.Sprintf("insufficient quantity of item %s", e.ItemName)
+}
 
-	return &mine, err
+// recipeColumns - поля рецепта для scanRecipe. Прочность результата берется из items,
+// у построек записи в items нет.
+const recipeColumns = `
+	SELECT r.id, r.key, r.output_item, r.output_quantity, COALESCE(it.durability_max, 0),
+		r.station, r.craft_time, r.satiety_cost, r.fuel
+	FROM recipes r
+	LEFT JOIN items it ON it.name = r.output_item`
+
+func scanRecipe(row interface{ Scan(...interface{}) error }) (models.Recipe, error) {
+	var recipe models.Recipe
+	err := row.Scan(&recipe.ID, &recipe.Key, &recipe.ItemName, &recipe.OutputQuantity, &recipe.OutputDurability,
+		&recipe.Station, &recipe.CraftTime, &recipe.SatietyCost, &recipe.Fuel)
+	return recipe, err
 }
 
-func (db *DB) UpdateMineExperience(playerID int, expGained int) (bool, int, error) {
-	// Получаем текущий уровень и опыт
-	var currentLevel, currentExp int
-	err := db.conn.QueryRow(`
-		SELECT level, experience 
-		FROM mines WHERE player_id = $1`,
-		playerID,
-	).Scan(&currentLevel, &currentExp)
+// GetRecipe возвращает рецепт по ключу или nil, если рецепта нет
+func (db *DB) GetRecipe(key string) (*models.Recipe, error) {
+	recipe, err := scanRecipe(db.conn.QueryRow(recipeColumns+` WHERE r.key = $1`, key))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
 	if err != nil {
-		return false, 0, err
+		return nil, err
 	}
 
-	// Вычисляем новый опыт
-	newExp := currentExp + expGained
-
-	// Вычисляем новый уровень
-	newLevel := currentLevel
-	for newExp >= newLevel*100 {
-		newLevel++
+	recipe.Ingredients, err = db.getRecipeIngredients(recipe.ID)
+	if err != nil {
+		return nil, err
 	}
+	return &recipe, nil
+}
 
-	// Обновляем данные в базе
-	_, err = db.conn.Exec(`
-		UPDATE mines 
-		SET experience = $1, level = $2
-		WHERE player_id = $3`,
-		newExp, newLevel, playerID,
-	)
+// GetStationRecipes возвращает рецепты станции в порядке из файла рецептов
+func (db *DB) GetStationRecipes(station string) ([]models.Recipe, error) {
+	rows, err := db.conn.Query(recipeColumns+` WHERE r.station = $1 ORDER BY r.position`, station)
 	if err != nil {
-		return false, 0, err
+		return nil, err
 	}
+	defer rows.Close()
 
-	// Возвращаем информацию о повышении уровня
-	levelUp := newLevel > currentLevel
-	return levelUp, newLevel, nil
-}
+	var recipes []models.Recipe
+	for rows.Next() {
+		recipe, err := scanRecipe(rows)
+		if err != nil {
+			return nil, err
+		}
+		recipes = append(recipes, recipe)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
-func (db *DB) SetMineExhausted(playerID int, exhausted bool) error {
-	_, err := db.conn.Exec(`
-		UPDATE mines 
-		SET is_exhausted = $1, last_used = CURRENT_TIMESTAMP
-		WHERE player_id = $2`,
-		exhausted, playerID,
-	)
-	return err
+	for i := range recipes {
+		recipes[i].Ingredients, err = db.getRecipeIngredients(recipes[i].ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return recipes, nil
 }
 
-func (db *DB) UpdateItemDurability(playerID int, itemName string, durabilityLoss int) error {
-	_, err := db.conn.Exec(`
-		UPDATE inventory 
-		SET durability = durability - $1
-		FROM items 
-		WHERE inventory.item_id = items.id 
-		AND inventory.player_id = $2 
-		AND items.name = $3 
-		AND inventory.durability > 0`,
-		durabilityLoss, playerID, itemName,
+func (db *DB) getRecipeIngredients(recipeID int) ([]models.RecipeIngredient, error) {
+	rows, err := db.conn.Query(`
+		SELECT ri.item_id, it.name, ri.quantity
+		FROM recipe_ingredients ri
+		JOIN items it ON ri.item_id = it.id
+		WHERE ri.recipe_id = $1
+		ORDER BY ri.position`,
+		recipeID,
 	)
-	return err
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ingredients []models.RecipeIngredient
+	for rows.Next() {
+		var ingredient models.RecipeIngredient
+		if err := rows.Scan(&ingredient.ItemID, &ingredient.ItemName, &ingredient.Quantity); err != nil {
+			return nil, err
+		}
+		ingredients = append(ingredients, ingredient)
+	}
+	return ingredients, rows.Err()
 }
 
-func (db *DB) AddItemToInventory(playerID int, itemName string, quantity int) error {
-	// Сначала получаем ID предмета
-	var itemID int
-	err := db.conn.QueryRow("SELECT id FROM items WHERE name = $1", itemName).Scan(&itemID)
+// ApplyInventoryChange списывает, изнашивает и выдает предметы в одной транзакции
+// и записывает каждое изменение в inventory_ledger. Если чего-то не хватает,
+// ничего не меняется и возвращается *InsufficientItemError.
+func (db *DB) ApplyInventoryChange(playerID int, change models.InventoryChange) error {
+	tx, err := db.conn.Begin()
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
 
-	// Проверяем, есть ли уже этот предмет в инвентаре
-	var existingQuantity int
-	err = db.conn.QueryRow(`
-		SELECT quantity FROM inventory 
-		WHERE player_id = $1 AND item_id = $2`,
-		playerID, itemID,
-	).Scan(&existingQuantity)
+	if err := applyInventoryChange(tx, playerID, change); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// applyInventoryChange применяет изменение инвентаря внутри транзакции вызывающего
+func applyInventoryChange(tx *sql.Tx, playerID int, change models.InventoryChange) error {
+	for _, delta := range change.Consume {
+		if err := consumeItem(tx, playerID, delta, change.Reason); err != nil {
+			return err
+		}
+	}
+	for _, delta := range change.Wear {
+		if err := wearTool(tx, playerID, delta, change.Reason); err != nil {
+			return err
+		}
+	}
+	for _, delta := range change.Grant {
+		if err := grantItem(tx, playerID, delta, change.Reason); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
+// itemID возвращает ID предмета и его максимальную прочность (0 - не инструмент)
+func itemID(tx *sql.Tx, itemName string) (id int, durabilityMax int, err error) {
+	err = tx.QueryRow(`SELECT id, durability_max FROM items WHERE name = $1`, itemName).Scan(&id, &durabilityMax)
 	if err == sql.ErrNoRows {
-		// Добавляем новый предмет
-		_, err = db.conn.Exec(`
-			INSERT INTO inventory (player_id, item_id, quantity, durability) 
-			VALUES ($1, $2, $3, 0)`,
-			playerID, itemID, quantity,
-		)
-	} else if err == nil {
-		// Увеличиваем количество существующего предмета
-		_, err = db.conn.Exec(`
-			UPDATE inventory 
-			SET quantity = quantity + $1
-			WHERE player_id = $2 AND item_id = $3`,
-			quantity, playerID, itemID,
-		)
+		return 0, 0, fmt.Errorf("unknown item %q", itemName)
 	}
+	return id, durabilityMax, err
+}
 
+func recordLedger(tx *sql.Tx, playerID, itemID, quantityDelta, durabilityDelta int, reason string) error {
+	_, err := tx.Exec(`
+		INSERT INTO inventory_ledger (player_id, item_id, quantity_delta, durability_delta, reason)
+		VALUES ($1, $2, $3, $4, $5)`,
+		playerID, itemID, quantityDelta, durabilityDelta, reason,
+	)
 	return err
 }
 
-func (db *DB) AddItemToInventoryWithDurability(playerID int, itemName string, quantity int, durability int) error {
-	// Сначала получаем ID предмета
-	var itemID int
-	err := db.conn.QueryRow("SELECT id FROM items WHERE name = $1", itemName).Scan(&itemID)
+// consumeItem списывает предмет со стопок игрока, начиная с самой старой
+func consumeItem(tx *sql.Tx, playerID int, delta models.ItemDelta, reason string) error {
+	if delta.Quantity <= 0 {
+		return fmt.Errorf("consume %s: quantity must be positive", delta.ItemName)
+	}
+	id, _, err := itemID(tx, delta.ItemName)
 	if err != nil {
 		return err
 	}
 
-	// Проверяем, есть ли уже этот предмет в инвентаре
-	var existingQuantity int
-	err = db.conn.QueryRow(`
-		SELECT quantity FROM inventory 
-		WHERE player_id = $1 AND item_id = $2`,
-		playerID, itemID,
-	).Scan(&existingQuantity)
+	// Блокируем записи инвентаря, чтобы параллельный запрос не списал их же.
+	// Из экземпляров инструмента первыми списываются самые изношенные.
+	rows, err := tx.Query(`
+		SELECT id, quantity FROM inventory
+		WHERE player_id = $1 AND item_id = $2 AND ($3 = 0 OR id = $3)
+		ORDER BY durability, id
+		FOR UPDATE`,
+		playerID, id, delta.InstanceID,
+	)
+	if err != nil {
+		return err
+	}
 
-	if err == sql.ErrNoRows {
-		// Добавляем новый предмет с указанной прочностью
-		_, err = db.conn.Exec(`
-			INSERT INTO inventory (player_id, item_id, quantity, durability) 
-			VALUES ($1, $2, $3, $4)`,
-			playerID, itemID, quantity, durability,
-		)
-	} else if err == nil {
-		// Увеличиваем количество существующего предмета
-		_, err = db.conn.Exec(`
-			UPDATE inventory 
-			SET quantity = quantity + $1
-			WHERE player_id = $2 AND item_id = $3`,
-			quantity, playerID, itemID,
-		)
+	type stack struct{ id, quantity int }
+	var stacks []stack
+	total := 0
+	for rows.Next() {
+		var s stack
+		if err := rows.Scan(&s.id, &s.quantity); err != nil {
+			rows.Close()
+			return err
+		}
+		stacks = append(stacks, s)
+		total += s.quantity
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if total < delta.Quantity {
+		return &InsufficientItemError{ItemName: delta.ItemName}
 	}
 
-	return err
-}
+	need := delta.Quantity
+	for _, s := range stacks {
+		if need == 0 {
+			break
+		}
+		take := min(s.quantity, need)
+		need -= take
+		if take == s.quantity {
+			_, err = tx.Exec(`DELETE FROM inventory WHERE id = $1`, s.id)
+		} else {
+			_, err = tx.Exec(`UPDATE inventory SET quantity = quantity - $1 WHERE id = $2`, take, s.id)
+		}
+		if err != nil {
+			return err
+		}
+	}
 
-func (db *DB) UpdatePlayerSatiety(playerID int, satietyChange int) error {
-	_, err := db.conn.Exec(`
-		UPDATE players 
-		SET satiety = LEAST(GREATEST(satiety + $1, 0), 100)
-		WHERE id = $2`,
-		satietyChange, playerID,
-	)
-	return err
+	return recordLedger(tx, playerID, id, -delta.Quantity, 0, reason)
 }
 
-func (db *DB) ConsumeItem(playerID int, itemName string, quantity int) error {
-	// Получаем ID предмета
-	var itemID int
-	err := db.conn.QueryRow("SELECT id FROM items WHERE name = $1", itemName).Scan(&itemID)
+// wearTool снимает прочность с указанного экземпляра инструмента или с того, который вернул бы GetTool.
+// Сломанный инструмент остается в инвентаре.
+func wearTool(tx *sql.Tx, playerID int, delta models.ItemDelta, reason string) error {
+	if delta.Durability <= 0 {
+		return fmt.Errorf("wear %s: durability must be positive", delta.ItemName)
+	}
+	id, _, err := itemID(tx, delta.ItemName)
 	if err != nil {
 		return err
 	}
 
-	// Уменьшаем количество предмета в инвентаре
-	result, err := db.conn.Exec(`
-		UPDATE inventory 
-		SET quantity = quantity - $1
-		WHERE player_id = $2 AND item_id = $3 AND quantity >= $1`,
-		quantity, playerID, itemID,
-	)
+	var rowID, durability int
+	err = tx.QueryRow(`
+		SELECT id, durability FROM inventory
+		WHERE player_id = $1 AND item_id = $2 AND ($3 = 0 OR id = $3) AND quantity > 0 AND durability > 0
+		ORDER BY equipped DESC, durability DESC, id
+		LIMIT 1
+		FOR UPDATE`,
+		playerID, id, delta.InstanceID,
+	).Scan(&rowID, &durability)
+	if err == sql.ErrNoRows {
+		return &InsufficientItemError{ItemName: delta.ItemName}
+	}
 	if err != nil {
 		return err
 	}
 
-	// Проверяем, был ли обновлен хотя бы один ряд
-	rowsAffected, err := result.RowsAffected()
+	loss := min(delta.Durability, durability)
+	if _, err := tx.Exec(`UPDATE inventory SET durability = durability - $1 WHERE id = $2`, loss, rowID); err != nil {
+		return err
+	}
+	return recordLedger(tx, playerID, id, 0, -loss, reason)
+}
+
+// grantItem добавляет предмет к существующей стопке или создает новую.
+// Инструменты выдаются отдельными экземплярами, по умолчанию с полной прочностью.
+func grantItem(tx *sql.Tx, playerID int, delta models.ItemDelta, reason string) error {
+	if delta.Quantity <= 0 {
+		return fmt.Errorf("grant %s: quantity must be positive", delta.ItemName)
+	}
+	id, durabilityMax, err := itemID(tx, delta.ItemName)
 	if err != nil {
 		return err
 	}
 
-	if rowsAffected == 0 {
-		return fmt.Errorf("insufficient quantity of item %s", itemName)
+	if durabilityMax > 0 {
+		durability := delta.Durability
+		if durability <= 0 {
+			durability = durabilityMax
+		}
+		attributes, err := json.Marshal(delta.Attributes)
+		if err != nil {
+			return err
+		}
+		if delta.Attributes == nil {
+			attributes = []byte("{}")
+		}
+		for i := 0; i < delta.Quantity; i++ {
+			_, err := tx.Exec(`
+				INSERT INTO inventory (player_id, item_id, quantity, durability, attributes)
+				VALUES ($1, $2, 1, $3, $4)`,
+				playerID, id, durability, attributes,
+			)
+			if err != nil {
+				return err
+			}
+		}
+		return recordLedger(tx, playerID, id, delta.Quantity, 0, reason)
 	}
 
-	// Удаляем предметы с количеством 0
-	_, err = db.conn.Exec(`
-		DELETE FROM inventory 
-		WHERE player_id = $1 AND item_id = $2 AND quantity <= 0`,
-		playerID, itemID,
+	var rowID int
+	err = tx.QueryRow(`
+		SELECT id FROM inventory
+		WHERE player_id = $1 AND item_id = $2
+		ORDER BY id
+		LIMIT 1
+		FOR UPDATE`,
+		playerID, id,
+	).Scan(&rowID)
+	if err == sql.ErrNoRows {
+		_, err = tx.Exec(`
+			INSERT INTO inventory (player_id, item_id, quantity, durability)
+			VALUES ($1, $2, $3, 0)`,
+			playerID, id, delta.Quantity,
+		)
+	} else if err == nil {
+		_, err = tx.Exec(`UPDATE inventory SET quantity = quantity + $1 WHERE id = $2`, delta.Quantity, rowID)
+	}
+	if err != nil {
+		return err
+	}
+
+	return recordLedger(tx, playerID, id, delta.Quantity, 0, reason)
+}
+
+// GetInventoryLedger возвращает последние записи журнала инвентаря игрока, новые первыми
+func (db *DB) GetInventoryLedger(playerID int, limit int) ([]models.LedgerEntry, error) {
+	rows, err := db.conn.Query(`
+		SELECT l.id, l.player_id, it.name, l.quantity_delta, l.durability_delta, l.reason, l.created_at
+		FROM inventory_ledger l
+		JOIN items it ON l.item_id = it.id
+		WHERE l.player_id = $1
+		ORDER BY l.id DESC
+		LIMIT $2`,
+		playerID, limit,
 	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
-	return err
+	var entries []models.LedgerEntry
+	for rows.Next() {
+		var entry models.LedgerEntry
+		err := rows.Scan(&entry.ID, &entry.PlayerID, &entry.ItemName, &entry.QuantityDelta,
+			&entry.DurabilityDelta, &entry.Reason, &entry.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
 }
 
-func (db *DB) HasToolInInventory(playerID int, toolName string) (bool, int, error) {
-	var durability int
-	err := db.conn.QueryRow(`
-		SELECT i.durability
-		FROM inventory i
-		JOIN items it ON i.item_id = it.id
-		WHERE i.player_id = $1 AND it.name = $2 AND i.quantity > 0 AND i.durability > 0`,
-		playerID, toolName,
-	).Scan(&durability)
+// GetFuels возвращает предметы, которые горят в печи и костре, начиная с самого жаркого
+func (db *DB) GetFuels() ([]models.Item, error) {
+	rows, err := db.conn.Query(`
+		SELECT id, key, name, type, durability_max, burn_value
+		FROM items WHERE burn_value > 0
+		ORDER BY burn_value DESC, name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
-	if err == sql.ErrNoRows {
-		return false, 0, nil
+	var fuels []models.Item
+	for rows.Next() {
+		var item models.Item
+		if err := rows.Scan(&item.ID, &item.Key, &item.Name, &item.Type, &item.DurabilityMax, &item.BurnValue); err != nil {
+			return nil, err
+		}
+		fuels = append(fuels, item)
 	}
+	return fuels, rows.Err()
+}
+
+// GetToolItems возвращает инструменты вида kind, начиная с самого высокого уровня
+func (db *DB) GetToolItems(kind string) ([]models.Item, error) {
+	rows, err := db.conn.Query(`
+		SELECT id, key, name, type, durability_max, tool, tier, speed, yield
+		FROM items WHERE type = 'tool' AND tool = $1
+		ORDER BY tier DESC, name`, kind)
 	if err != nil {
-		return false, 0, err
+		return nil, err
 	}
+	defer rows.Close()
 
-	return true, durability, nil
+	var tools []models.Item
+	for rows.Next() {
+		var item models.Item
+		if err := rows.Scan(&item.ID, &item.Key, &item.Name, &item.Type, &item.DurabilityMax, &item.Tool, &item.Tier, &item.Speed, &item.Yield); err != nil {
+			return nil, err
+		}
+		tools = append(tools, item)
+	}
+	return tools, rows.Err()
 }
 
-func (db *DB) ExhaustMine(playerID int64) error {
-	_, err := db.conn.Exec(`
-		UPDATE mines 
-		SET is_exhausted = true, last_used = CURRENT_TIMESTAMP
-		WHERE player_id = $1`,
-		playerID,
-	)
-	return err
+// GetEdibleItems возвращает съедобные предметы, начиная с самых сытных
+func (db *DB) GetEdibleItems() ([]models.Item, error) {
+	rows, err := db.conn.Query(`
+		SELECT id, key, name, type, durability_max, satiety
+		FROM items WHERE satiety > 0
+		ORDER BY satiety DESC, name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var foods []models.Item
+	for rows.Next() {
+		var item models.Item
+		if err := rows.Scan(&item.ID, &item.Key, &item.Name, &item.Type, &item.DurabilityMax, &item.Satiety); err != nil {
+			return nil, err
+		}
+		foods = append(foods, item)
+	}
+	return foods, rows.Err()
 }
 
-func (db *DB) GetOrCreateForest(playerID int) (*models.Forest, error) {
-	var forest models.Forest
+// QueueFurnaceJob списывает ингредиенты и топливо и ставит партию в очередь печи
+// в одной транзакции. Если чего-то не хватает, возвращается *InsufficientItemError.
+func (db *DB) QueueFurnaceJob(playerID int, job models.FurnaceJob, change models.InventoryChange) (*models.FurnaceJob, error) {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
 
-	// Пытаемся найти существующий лес
-	err := db.conn.QueryRow(`
-		SELECT id, player_id, level, experience, last_used, is_exhausted 
-		FROM forests WHERE player_id = $1`, playerID,
-	).Scan(&forest.ID, &forest.PlayerID, &forest.Level, &forest.Experience, &forest.LastUsed, &forest.IsExhausted)
+	if err := applyInventoryChange(tx, playerID, change); err != nil {
+		return nil, err
+	}
 
-	if err == sql.ErrNoRows {
-		// Создаем новый лес
-		err = db.conn.QueryRow(`
-			INSERT INTO forests (player_id, level, experience, is_exhausted) 
-			VALUES ($1, 1, 0, false) 
-			RETURNING id, player_id, level, experience, last_used, is_exhausted`,
-			playerID,
-		).Scan(&forest.ID, &forest.PlayerID, &forest.Level, &forest.Experience, &forest.LastUsed, &forest.IsExhausted)
+	job.PlayerID = playerID
+	err = tx.QueryRow(`
+		INSERT INTO furnace_jobs (player_id, recipe_key, item_name, quantity, started_at, ends_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id`,
+		playerID, job.RecipeKey, job.ItemName, job.Quantity, job.StartedAt, job.EndsAt,
+	).Scan(&job.ID)
+	if err != nil {
+		return nil, err
 	}
 
-	return &forest, err
+	return &job, tx.Commit()
 }
 
-func (db *DB) UpdateForestExperience(playerID int, expGained int) (bool, int, error) {
-	// Получаем текущий уровень и опыт
-	var currentLevel, currentExp int
-	err := db.conn.QueryRow(`
-		SELECT level, experience 
-		FROM forests WHERE player_id = $1`,
+// GetFurnaceJobs возвращает очередь печи игрока в порядке готовности
+func (db *DB) GetFurnaceJobs(playerID int) ([]models.FurnaceJob, error) {
+	rows, err := db.conn.Query(`
+		SELECT id, player_id, recipe_key, item_name, quantity, started_at, ends_at
+		FROM furnace_jobs WHERE player_id = $1
+		ORDER BY ends_at, id`,
 		playerID,
-	).Scan(&currentLevel, &currentExp)
+	)
 	if err != nil {
-		return false, 0, err
+		return nil, err
 	}
+	defer rows.Close()
 
-	// Вычисляем новый опыт
-	newExp := currentExp + expGained
+	var jobs []models.FurnaceJob
+	for rows.Next() {
+		var job models.FurnaceJob
+		if err := rows.Scan(&job.ID, &job.PlayerID, &job.RecipeKey, &job.ItemName, &job.Quantity, &job.StartedAt, &job.EndsAt); err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
 
-	// Вычисляем новый уровень
-	newLevel := currentLevel
-	for newExp >= newLevel*100 {
-		newLevel++
+// CollectFurnaceJobs выдает игроку результат всех партий, готовых к моменту now,
+// и убирает их из очереди в одной транзакции
+func (db *DB) CollectFurnaceJobs(playerID int, now time.Time) ([]models.FurnaceJob, error) {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return nil, err
 	}
+	defer tx.Rollback()
 
-	// Обновляем данные в базе
-	_, err = db.conn.Exec(`
-		UPDATE forests 
-		SET experience = $1, level = $2
-		WHERE player_id = $3`,
-		newExp, newLevel, playerID,
+	rows, err := tx.Query(`
+		DELETE FROM furnace_jobs
+		WHERE player_id = $1 AND ends_at <= $2
+		RETURNING id, player_id, recipe_key, item_name, quantity, started_at, ends_at`,
+		playerID, now,
 	)
 	if err != nil {
-		return false, 0, err
+		return nil, err
+	}
+	var jobs []models.FurnaceJob
+	for rows.Next() {
+		var job models.FurnaceJob
+		if err := rows.Scan(&job.ID, &job.PlayerID, &job.RecipeKey, &job.ItemName, &job.Quantity, &job.StartedAt, &job.EndsAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, job := range jobs {
+		err := applyInventoryChange(tx, playerID, models.InventoryChange{
+			Reason: "furnace:" + job.RecipeKey,
+			Grant:  []models.ItemDelta{{ItemName: job.ItemName, Quantity: job.Quantity}},
+		})
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	// Возвращаем информацию о повышении уровня
-	levelUp := newLevel > currentLevel
-	return levelUp, newLevel, nil
+	return jobs, tx.Commit()
 }
 
-func (db *DB) SetForestExhausted(playerID int, exhausted bool) error {
+func (db *DB) UpdatePlayerSatiety(playerID int, satietyChange int) error {
 	_, err := db.conn.Exec(`
-		UPDATE forests 
-		SET is_exhausted = $1, last_used = CURRENT_TIMESTAMP
-		WHERE player_id = $2`,
-		exhausted, playerID,
+		UPDATE players 
+		SET satiety = LEAST(GREATEST(satiety + $1, 0), 100)
+		WHERE id = $2`,
+		satietyChange, playerID,
 	)
 	return err
 }
 
-func (db *DB) ExhaustForest(playerID int64) error {
+// DecayPlayerSatiety снимает сытость, убывшую со временем, и запоминает, до какого момента убывание учтено
+func (db *DB) DecayPlayerSatiety(playerID int, loss int, settledAt time.Time) error {
 	_, err := db.conn.Exec(`
-		UPDATE forests 
-		SET is_exhausted = true, last_used = CURRENT_TIMESTAMP
-		WHERE player_id = $1`,
-		playerID,
+		UPDATE players
+		SET satiety = GREATEST(satiety - $1, 0), satiety_updated_at = $2
+		WHERE id = $3`,
+		loss, settledAt, playerID,
 	)
 	return err
 }
 
-func (db *DB) GetOrCreateGathering(playerID int) (*models.Gathering, error) {
-	var gathering models.Gathering
+// AddPlayerBuff накладывает бафф от еды. Бафф той же еды продлевается, баффы, истекшие к моменту now, удаляются.
+func (db *DB) AddPlayerBuff(playerID int, buff models.Buff, now time.Time) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
 
-	// Пытаемся найти существующий сбор
-	err := db.conn.QueryRow(`
-		SELECT id, player_id, level, experience, last_used, is_exhausted 
-		FROM gathering WHERE player_id = $1`, playerID,
-	).Scan(&gathering.ID, &gathering.PlayerID, &gathering.Level, &gathering.Experience, &gathering.LastUsed, &gathering.IsExhausted)
+	if _, err := tx.Exec(`DELETE FROM player_buffs WHERE player_id = $1 AND ends_at <= $2`, playerID, now); err != nil {
+		return err
+	}
+	_, err = tx.Exec(`
+		INSERT INTO player_buffs (player_id, item_name, name, speed, yield, ends_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (player_id, item_name)
+		DO UPDATE SET name = EXCLUDED.name, speed = EXCLUDED.speed, yield = EXCLUDED.yield, ends_at = EXCLUDED.ends_at`,
+		playerID, buff.ItemName, buff.Name, buff.Speed, buff.Yield, buff.EndsAt,
+	)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetPlayerBuffs возвращает баффы, которые еще действуют в момент now, от самого долгого
+func (db *DB) GetPlayerBuffs(playerID int, now time.Time) ([]models.Buff, error) {
+	rows, err := db.conn.Query(`
+		SELECT item_name, name, speed, yield, ends_at
+		FROM player_buffs
+		WHERE player_id = $1 AND ends_at > $2
+		ORDER BY ends_at DESC, item_name`,
+		playerID, now,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var buffs []models.Buff
+	for rows.Next() {
+		var buff models.Buff
+		if err := rows.Scan(&buff.ItemName, &buff.Name, &buff.Speed, &buff.Yield, &buff.EndsAt); err != nil {
+			return nil, err
+		}
+		buffs = append(buffs, buff)
+	}
+	return buffs, rows.Err()
+}
+
+// GetTool возвращает экземпляр инструмента для работы: выбранный игроком, если он цел,
+// иначе самый прочный. Если целого инструмента нет, возвращает nil.
+func (db *DB) GetTool(playerID int, toolName string) (*models.InventoryItem, error) {
+	tool, err := scanInventoryItem(db.conn.QueryRow(inventoryColumns+`
+		WHERE i.player_id = $1 AND it.name = $2 AND i.quantity > 0 AND i.durability > 0
+		ORDER BY i.equipped DESC, i.durability DESC, i.id
+		LIMIT 1`,
+		playerID, toolName,
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &tool, nil
+}
+
+// EquipTool выбирает экземпляр инструмента для работы вместо остальных экземпляров того же предмета.
+// Если у игрока нет такого экземпляра, возвращает nil.
+func (db *DB) EquipTool(playerID int, instanceID int) (*models.InventoryItem, error) {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
 
+	tool, err := scanInventoryItem(tx.QueryRow(inventoryColumns+`
+		WHERE i.id = $1 AND i.player_id = $2 AND it.durability_max > 0
+		FOR UPDATE OF i`,
+		instanceID, playerID,
+	))
 	if err == sql.ErrNoRows {
-		// Создаем новый сбор
-		err = db.conn.QueryRow(`
-			INSERT INTO gathering (player_id, level, experience, is_exhausted) 
-			VALUES ($1, 1, 0, false) 
-			RETURNING id, player_id, level, experience, last_used, is_exhausted`,
-			playerID,
-		).Scan(&gathering.ID, &gathering.PlayerID, &gathering.Level, &gathering.Experience, &gathering.LastUsed, &gathering.IsExhausted)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
 	}
 
-	return &gathering, err
+	_, err = tx.Exec(`UPDATE inventory SET equipped = (id = $1) WHERE player_id = $2 AND item_id = $3`,
+		instanceID, playerID, tool.ItemID)
+	if err != nil {
+		return nil, err
+	}
+	tool.Equipped = true
+	return &tool, tx.Commit()
 }
 
-func (db *DB) UpdateGatheringExperience(playerID int, expGained int) (bool, int, error) {
-	// Получаем текущий уровень и опыт
-	var currentLevel, currentExp int
+// GetOrCreateLocation возвращает прогресс игрока в локации, создавая его при первом посещении
+func (db *DB) GetOrCreateLocation(playerID int, location string) (*models.Location, error) {
+	var loc models.Location
+
 	err := db.conn.QueryRow(`
-		SELECT level, experience 
-		FROM gathering WHERE player_id = $1`,
-		playerID,
+		INSERT INTO player_locations (player_id, location)
+		VALUES ($1, $2)
+		ON CONFLICT (player_id, location) DO UPDATE SET location = EXCLUDED.location
+		RETURNING id, player_id, location, level, experience, last_used, is_exhausted`,
+		playerID, location,
+	).Scan(&loc.ID, &loc.PlayerID, &loc.Location, &loc.Level, &loc.Experience, &loc.LastUsed, &loc.IsExhausted)
+
+	return &loc, err
+}
+
+// UpdateLocationExperience начисляет опыт локации. Каждые 100 опыта - новый уровень.
+// Возвращает, повысился ли уровень, и текущий уровень.
+func (db *DB) UpdateLocationExperience(playerID int, location string, expGained int) (bool, int, error) {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return false, 0, err
+	}
+	defer tx.Rollback()
+
+	var currentLevel, currentExp int
+	err = tx.QueryRow(`
+		SELECT level, experience
+		FROM player_locations WHERE player_id = $1 AND location = $2
+		FOR UPDATE`,
+		playerID, location,
 	).Scan(&currentLevel, &currentExp)
 	if err != nil {
 		return false, 0, err
 	}
 
-	// Вычисляем новый опыт
 	newExp := currentExp + expGained
+	newLevel := nextLocationLevel(currentLevel, newExp)
 
-	// Вычисляем новый уровень (для сбора: 1 уровень = 100 опыта, 2 уровень = 200 опыта и т.д.)
-	newLevel := (newExp / 100) + 1
-
-	// Обновляем данные в базе
-	_, err = db.conn.Exec(`
-		UPDATE gathering 
+	_, err = tx.Exec(`
+		UPDATE player_locations
 		SET experience = $1, level = $2
-		WHERE player_id = $3`,
-		newExp, newLevel, playerID,
+		WHERE player_id = $3 AND location = $4`,
+		newExp, newLevel, playerID, location,
 	)
 	if err != nil {
 		return false, 0, err
 	}
 
-	// Возвращаем информацию о повышении уровня
-	levelUp := newLevel > currentLevel
-	return levelUp, newLevel, nil
+	return newLevel > currentLevel, newLevel, tx.Commit()
 }
 
-func (db *DB) SetGatheringExhausted(playerID int, exhausted bool) error {
+func (db *DB) SetLocationExhausted(playerID int, location string, exhausted bool) error {
 	_, err := db.conn.Exec(`
-		UPDATE gathering 
+		UPDATE player_locations
 		SET is_exhausted = $1, last_used = CURRENT_TIMESTAMP
-		WHERE player_id = $2`,
-		exhausted, playerID,
+		WHERE player_id = $2 AND location = $3`,
+		exhausted, playerID, location,
 	)
 	return err
 }
 
-func (db *DB) ExhaustGathering(playerID int64) error {
-	_, err := db.conn.Exec(`
-		UPDATE gathering 
-		SET is_exhausted = true, last_used = CURRENT_TIMESTAMP
-		WHERE player_id = $1`,
-		playerID,
-	)
-	return err
+// nextLocationLevel - уровень растет, пока опыт не меньше уровня*100
+func nextLocationLevel(level, exp int) int {
+	for exp >= level*100 {
+		level++
+	}
+	return level
 }
 
 // Функции для работы с квестами
@@ -726,6 +997,35 @@ func (db *DB) UpdateQuestProgress(playerID int, questID int, progress int) error
 	return err
 }
 
+// CompleteQuest отмечает квест выполненным, начисляет опыт и выдает предметы награды
+// в одной транзакции. Если что-то не удалось, квест остается невыполненным.
+func (db *DB) CompleteQuest(playerID int, questID int, experience int, reward models.InventoryChange) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		UPDATE quests
+		SET status = 'completed', completed_at = CURRENT_TIMESTAMP
+		WHERE player_id = $1 AND quest_id = $2`,
+		playerID, questID,
+	)
+	if err != nil {
+		return err
+	}
+	if experience > 0 {
+		if _, err := tx.Exec(`UPDATE players SET experience = experience + $2 WHERE id = $1`, playerID, experience); err != nil {
+			return err
+		}
+	}
+	if err := applyInventoryChange(tx, playerID, reward); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
 func (db *DB) UpdatePlayerExperience(playerID int, expGained int) error {
 	_, err := db.conn.Exec(`
 		UPDATE players 
@@ -736,167 +1036,148 @@ func (db *DB) UpdatePlayerExperience(playerID int, expGained int) error {
 	return err
 }
 
-func (db *DB) GetOrCreateHunting(playerID int) (*models.Hunting, error) {
-	var hunting models.Hunting
-
-	// Пытаемся найти существующую охоту
-	err := db.conn.QueryRow(`
-		SELECT id, player_id, level, experience, last_used, is_exhausted 
-		FROM hunting WHERE player_id = $1`, playerID,
-	).Scan(&hunting.ID, &hunting.PlayerID, &hunting.Level, &hunting.Experience, &hunting.LastUsed, &hunting.IsExhausted)
-
-	if err == sql.ErrNoRows {
-		// Создаем новую охоту
-		err = db.conn.QueryRow(`
-			INSERT INTO hunting (player_id, level, experience, is_exhausted) 
-			VALUES ($1, 1, 0, false) 
-			RETURNING id, player_id, level, experience, last_used, is_exhausted`,
-			playerID,
-		).Scan(&hunting.ID, &hunting.PlayerID, &hunting.Level, &hunting.Experience, &hunting.LastUsed, &hunting.IsExhausted)
+// SavePlayerRuntime заменяет сохраненные сессии, действия и кулдауны игрока переданными
+func (db *DB) SavePlayerRuntime(telegramID int64, sessions []models.LocationSession, actions []models.TimedAction, cooldowns []models.Cooldown) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
 	}
+	defer tx.Rollback()
 
-	return &hunting, err
-}
-
-func (db *DB) UpdateHuntingExperience(playerID int, expGained int) (bool, int, error) {
-	// Получаем текущий уровень и опыт
-	var currentLevel, currentExp int
-	err := db.conn.QueryRow(`
-		SELECT level, experience 
-		FROM hunting WHERE player_id = $1`,
-		playerID,
-	).Scan(&currentLevel, &currentExp)
-	if err != nil {
-		return false, 0, err
+	for _, table := range []string{"player_sessions", "player_actions", "player_cooldowns"} {
+		if _, err := tx.Exec("DELETE FROM "+table+" WHERE telegram_id = $1", telegramID); err != nil {
+			return err
+		}
 	}
 
-	// Вычисляем новый опыт
-	newExp := currentExp + expGained
+	for _, session := range sessions {
+		resources, err := json.Marshal(session.Resources)
+		if err != nil {
+			return err
+		}
+		wounds, err := json.Marshal(session.Wounds)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(`
+			INSERT INTO player_sessions (telegram_id, location, chat_id, resources, wounds, field_message_id, info_message_id, result_message_id, started_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+			telegramID, session.Location, session.ChatID, string(resources), string(wounds),
+			session.FieldMessageID, session.InfoMessageID, session.ResultMessageID, session.StartedAt,
+		)
+		if err != nil {
+			return err
+		}
+	}
 
-	// Вычисляем новый уровень
-	newLevel := currentLevel
-	for newExp >= newLevel*100 {
-		newLevel++
+	for _, action := range actions {
+		_, err := tx.Exec(`
+			INSERT INTO player_actions (telegram_id, kind, chat_id, message_id, item_name, quantity, durability, tool_id, tool_name, row_index, col_index, started_at, ends_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`,
+			telegramID, action.Kind, action.ChatID, action.MessageID, action.ItemName, action.Quantity,
+			action.Durability, action.ToolID, action.ToolName, action.Row, action.Col, action.StartedAt, action.EndsAt,
+		)
+		if err != nil {
+			return err
+		}
 	}
 
-	// Обновляем данные в базе
-	_, err = db.conn.Exec(`
-		UPDATE hunting 
-		SET experience = $1, level = $2
-		WHERE player_id = $3`,
-		newExp, newLevel, playerID,
-	)
-	if err != nil {
-		return false, 0, err
+	for _, cooldown := range cooldowns {
+		_, err := tx.Exec(`
+			INSERT INTO player_cooldowns (telegram_id, location, ends_at)
+			VALUES ($1, $2, $3)`,
+			telegramID, cooldown.Location, cooldown.EndsAt,
+		)
+		if err != nil {
+			return err
+		}
 	}
 
-	// Возвращаем информацию о повышении уровня
-	levelUp := newLevel > currentLevel
-	return levelUp, newLevel, nil
+	return tx.Commit()
 }
 
-func (db *DB) SetHuntingExhausted(playerID int, exhausted bool) error {
-	_, err := db.conn.Exec(`
-		UPDATE hunting 
-		SET is_exhausted = $1, last_used = CURRENT_TIMESTAMP
-		WHERE player_id = $2`,
-		exhausted, playerID,
-	)
-	return err
-}
+// GetLocationSessions возвращает все сохраненные сессии локаций
+func (db *DB) GetLocationSessions() ([]models.LocationSession, error) {
+	rows, err := db.conn.Query(`
+		SELECT telegram_id, location, chat_id, resources, wounds, field_message_id, info_message_id, result_message_id, started_at
+		FROM player_sessions`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
-func (db *DB) ExhaustHunting(playerID int64) error {
-	_, err := db.conn.Exec(`
-		UPDATE hunting 
-		SET is_exhausted = true, last_used = CURRENT_TIMESTAMP
-		WHERE player_id = $1`,
-		playerID,
-	)
-	return err
+	var sessions []models.LocationSession
+	for rows.Next() {
+		var session models.LocationSession
+		var resources, wounds string
+		err := rows.Scan(&session.PlayerID, &session.Location, &session.ChatID, &resources, &wounds,
+			&session.FieldMessageID, &session.InfoMessageID, &session.ResultMessageID, &session.StartedAt)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(resources), &session.Resources); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(wounds), &session.Wounds); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+
+	return sessions, rows.Err()
 }
 
-func (db *DB) RemoveItemFromInventory(playerID int, itemName string, quantity int) error {
-	// Обновляем количество через JOIN с таблицей items
-	_, err := db.conn.Exec(`
-		UPDATE inventory 
-		SET quantity = quantity - $3
-		FROM items 
-		WHERE inventory.item_id = items.id 
-		AND inventory.player_id = $1 
-		AND items.name = $2 
-		AND inventory.quantity >= $3`,
-		playerID, itemName, quantity,
-	)
+// GetTimedActions возвращает все незавершенные действия с таймером
+func (db *DB) GetTimedActions() ([]models.TimedAction, error) {
+	rows, err := db.conn.Query(`
+		SELECT telegram_id, kind, chat_id, message_id, item_name, quantity, durability, tool_id, tool_name, row_index, col_index, started_at, ends_at
+		FROM player_actions
+		ORDER BY ends_at`)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	defer rows.Close()
 
-	// Удаляем записи с нулевым количеством
-	_, err = db.conn.Exec(`
-		DELETE FROM inventory 
-		USING items 
-		WHERE inventory.item_id = items.id 
-		AND inventory.player_id = $1 
-		AND items.name = $2 
-		AND inventory.quantity <= 0`,
-		playerID, itemName,
-	)
-	return err
-}
+	var actions []models.TimedAction
+	for rows.Next() {
+		var action models.TimedAction
+		err := rows.Scan(&action.PlayerID, &action.Kind, &action.ChatID, &action.MessageID, &action.ItemName,
+			&action.Quantity, &action.Durability, &action.ToolID, &action.ToolName, &action.Row, &action.Col, &action.StartedAt, &action.EndsAt)
+		if err != nil {
+			return nil, err
+		}
+		actions = append(actions, action)
+	}
 
-func (db *DB) UpdateToolDurability(playerID int, toolName string, newDurability int) error {
-	_, err := db.conn.Exec(`
-		UPDATE inventory 
-		SET durability = $3
-		FROM items 
-		WHERE inventory.item_id = items.id 
-		AND inventory.player_id = $1 
-		AND items.name = $2`,
-		playerID, toolName, newDurability,
-	)
-	return err
+	return actions, rows.Err()
 }
 
-func (db *DB) Close() error {
-	return db.conn.Close()
-}
+// GetCooldowns возвращает активные кулдауны локаций и удаляет истекшие
+func (db *DB) GetCooldowns(now time.Time) ([]models.Cooldown, error) {
+	if _, err := db.conn.Exec("DELETE FROM player_cooldowns WHERE ends_at <= $1", now); err != nil {
+		return nil, err
+	}
 
-// GetPlayerItems возвращает все предметы игрока
-func (db *DB) GetPlayerItems(playerID int) ([]Item, error) {
-	var items []Item
-	query := `SELECT item_name, item_type, quantity FROM player_items WHERE player_id = $1`
-	rows, err := db.conn.Query(query, playerID)
+	rows, err := db.conn.Query("SELECT telegram_id, location, ends_at FROM player_cooldowns")
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
+	var cooldowns []models.Cooldown
 	for rows.Next() {
-		var item Item
-		err := rows.Scan(&item.ItemName, &item.ItemType, &item.Quantity)
-		if err != nil {
+		var cooldown models.Cooldown
+		if err := rows.Scan(&cooldown.PlayerID, &cooldown.Location, &cooldown.EndsAt); err != nil {
 			return nil, err
 		}
-		items = append(items, item)
+		cooldowns = append(cooldowns, cooldown)
 	}
-	return items, nil
-}
 
-// AddPlayerItem добавляет предмет игроку
-func (db *DB) AddPlayerItem(playerID int, itemName string, quantity int) error {
-	query := `INSERT INTO player_items (player_id, item_name, item_type, quantity)
-			  VALUES ($1, $2, (SELECT item_type FROM seed_items WHERE item_name = $2), $3)
-			  ON CONFLICT (player_id, item_name) 
-			  DO UPDATE SET quantity = player_items.quantity + $3`
-	_, err := db.conn.Exec(query, playerID, itemName, quantity)
-	return err
+	return cooldowns, rows.Err()
 }
 
-// AddPlayerExperience добавляет опыт игроку
-func (db *DB) AddPlayerExperience(playerID int, amount int) error {
-	query := `UPDATE players SET experience = experience + $1 WHERE id = $2`
-	_, err := db.conn.Exec(query, amount, playerID)
-	return err
+func (db *DB) Close() error {
+	return db.conn.Close()
 }
 
 // Устанавливает simple_hut_built = true для игрока
@@ -905,47 +1186,34 @@ func (db *DB) UpdateSimpleHutBuilt(playerID int, built bool) error {
 	return err
 }
 
-func (db *DB) CreatePlayer(telegramID int64, name string) (*models.Player, error) {
+// CreatePlayer создает игрока вместе со стартовым инвентарем: либо все, либо ничего.
+// Убывание сытости отсчитывается от момента now.
+func (db *DB) CreatePlayer(telegramID int64, name string, now time.Time) (*models.Player, error) {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
 	var player models.Player
-	err := db.conn.QueryRow(`
-		INSERT INTO players (telegram_id, name, level, experience, satiety, simple_hut_built)
-		VALUES ($1, $2, 1, 0, 100, false)
-		RETURNING id, telegram_id, name, level, experience, satiety, created_at, simple_hut_built`,
-		telegramID, name,
-	).Scan(&player.ID, &player.TelegramID, &player.Name, &player.Level, &player.Experience, &player.Satiety, &player.CreatedAt, &player.SimpleHutBuilt)
+	err = tx.QueryRow(`
+		INSERT INTO players (telegram_id, name, level, experience, satiety, satiety_updated_at, simple_hut_built)
+		VALUES ($1, $2, 1, 0, 100, $3, false)
+		RETURNING id, telegram_id, name, level, experience, satiety, satiety_updated_at, created_at, simple_hut_built`,
+		telegramID, name, now,
+	).Scan(&player.ID, &player.TelegramID, &player.Name, &player.Level, &player.Experience, &player.Satiety, &player.SatietyUpdatedAt, &player.CreatedAt, &player.SimpleHutBuilt)
 
 	if err != nil {
 		return nil, err
 	}
 
-	// Создаем начальный инвентарь
-	if err := db.createStarterInventory(player.ID); err != nil {
+	// Создаем начальный инвентарь в той же транзакции
+	if err := applyInventoryChange(tx, player.ID, starterInventory); err != nil {
 		return nil, err
 	}
 
-	return &player, nil
-}
-
-// createStarterInventory выдает стартовые предметы новому игроку
-func (db *DB) createStarterInventory(playerID int) error {
-	// Добавляем стартовые инструменты и ресурсы
-	if err := db.AddItemToInventoryWithDurability(playerID, "Простой лук", 1, 100); err != nil {
-		return err
-	}
-	if err := db.AddItemToInventoryWithDurability(playerID, "Простой нож", 1, 100); err != nil {
-		return err
-	}
-	if err := db.AddItemToInventoryWithDurability(playerID, "Простая кирка", 1, 100); err != nil {
-		return err
-	}
-	if err := db.AddItemToInventoryWithDurability(playerID, "Простой топор", 1, 100); err != nil {
-		return err
-	}
-	if err := db.AddItemToInventory(playerID, "Стрелы", 100); err != nil {
-		return err
-	}
-	if err := db.AddItemToInventory(playerID, "Лесная ягода", 10); err != nil {
-		return err
+	if err := tx.Commit(); err != nil {
+		return nil, err
 	}
-	return nil
+	return &player, nil
 }
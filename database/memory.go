@@ -0,0 +1,753 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"maps"
+	"reborn_land/catalog"
+	"reborn_land/models"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Memory - хранилище в памяти с тем же поведением, что и DB.
+// Позволяет запускать обработчики в тестах без PostgreSQL.
+type Memory struct {
+	mu sync.Mutex
+
+	players   map[int64]*models.Player // по Telegram ID
+	items     map[string]models.Item   // по названию
+	inventory []*models.InventoryItem
+	locations map[string]map[int]*memoryLocation // локация -> player_id -> прогресс
+	quests    []*models.Quest
+	recipes   []models.Recipe // в порядке из файла рецептов
+	ledger    []models.LedgerEntry
+	furnace   []models.FurnaceJob
+	buffs     map[int][]models.Buff // player_id -> баффы
+
+	sessions  map[int64][]models.LocationSession
+	actions   map[int64][]models.TimedAction
+	cooldowns map[int64][]models.Cooldown
+
+	nextID int
+}
+
+// memoryLocation - строка таблицы player_locations
+type memoryLocation struct {
+	id          int
+	level       int
+	experience  int
+	lastUsed    time.Time
+	isExhausted bool
+}
+
+// NewMemory создает пустое хранилище со справочником предметов и рецептами
+func NewMemory(items *catalog.Catalog, recipes *catalog.Recipes) *Memory {
+	m := &Memory{
+		players:   make(map[int64]*models.Player),
+		items:     make(map[string]models.Item),
+		locations: make(map[string]map[int]*memoryLocation),
+		sessions:  make(map[int64][]models.LocationSession),
+		actions:   make(map[int64][]models.TimedAction),
+		cooldowns: make(map[int64][]models.Cooldown),
+		buffs:     make(map[int][]models.Buff),
+	}
+
+	for _, item := range items.Items {
+		m.items[item.Name] = models.Item{
+			ID: m.newID(), Key: item.Key, Name: item.Name, Type: item.Type,
+			DurabilityMax: item.DurabilityMax, BurnValue: item.BurnValue, Satiety: item.Satiety,
+			Tool: item.Tool, Tier: item.Tier, Speed: item.Speed, Yield: item.Yield, Description: item.Description, Flags: item.Flags,
+		}
+	}
+	for _, recipe := range recipes.Recipes {
+		var ingredients []models.RecipeIngredient
+		for _, ingredient := range recipe.Ingredients {
+			ingredients = append(ingredients, models.RecipeIngredient{
+				ItemID: m.items[ingredient.Item].ID, ItemName: ingredient.Item, Quantity: ingredient.Quantity,
+			})
+		}
+		m.recipes = append(m.recipes, models.Recipe{
+			ID: m.newID(), Key: recipe.Key, ItemName: recipe.Output, OutputQuantity: recipe.OutputQuantity,
+			OutputDurability: m.items[recipe.Output].DurabilityMax, Station: recipe.Station,
+			CraftTime: recipe.CraftTime, SatietyCost: recipe.SatietyCost, Fuel: recipe.Fuel, Ingredients: ingredients,
+		})
+	}
+	return m
+}
+
+func (m *Memory) newID() int {
+	m.nextID++
+	return m.nextID
+}
+
+func (m *Memory) playerByID(playerID int) *models.Player {
+	for _, player := range m.players {
+		if player.ID == playerID {
+			return player
+		}
+	}
+	return nil
+}
+
+func (m *Memory) PlayerExists(telegramID int64) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, exists := m.players[telegramID]
+	return exists, nil
+}
+
+func (m *Memory) GetPlayer(telegramID int64) (*models.Player, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	player, exists := m.players[telegramID]
+	if !exists {
+		return nil, sql.ErrNoRows
+	}
+	copied := *player
+	return &copied, nil
+}
+
+func (m *Memory) CreatePlayer(telegramID int64, name string, now time.Time) (*models.Player, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.players[telegramID]; exists {
+		return nil, fmt.Errorf("player with telegram_id %d already exists", telegramID)
+	}
+
+	player := &models.Player{
+		ID: m.newID(), TelegramID: telegramID, Name: name,
+		Level: 1, Experience: 0, Satiety: 100, SatietyUpdatedAt: now, CreatedAt: now,
+	}
+	// Игрок появляется только вместе со стартовым инвентарем
+	if err := m.applyInventoryChange(player.ID, starterInventory); err != nil {
+		return nil, err
+	}
+	m.players[telegramID] = player
+
+	copied := *player
+	return &copied, nil
+}
+
+func (m *Memory) UpdatePlayerSatiety(playerID int, satietyChange int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if player := m.playerByID(playerID); player != nil {
+		player.Satiety = min(max(player.Satiety+satietyChange, 0), 100)
+	}
+	return nil
+}
+
+func (m *Memory) DecayPlayerSatiety(playerID int, loss int, settledAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if player := m.playerByID(playerID); player != nil {
+		player.Satiety = max(player.Satiety-loss, 0)
+		player.SatietyUpdatedAt = settledAt
+	}
+	return nil
+}
+
+func (m *Memory) AddPlayerBuff(playerID int, buff models.Buff, now time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	buffs := []models.Buff{buff}
+	for _, active := range m.buffs[playerID] {
+		if active.ItemName != buff.ItemName && active.EndsAt.After(now) {
+			buffs = append(buffs, active)
+		}
+	}
+	m.buffs[playerID] = buffs
+	return nil
+}
+
+func (m *Memory) GetPlayerBuffs(playerID int, now time.Time) ([]models.Buff, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var buffs []models.Buff
+	for _, buff := range m.buffs[playerID] {
+		if buff.EndsAt.After(now) {
+			buffs = append(buffs, buff)
+		}
+	}
+	sort.Slice(buffs, func(i, j int) bool {
+		if !buffs[i].EndsAt.Equal(buffs[j].EndsAt) {
+			return buffs[i].EndsAt.After(buffs[j].EndsAt)
+		}
+		return buffs[i].ItemName < buffs[j].ItemName
+	})
+	return buffs, nil
+}
+
+func (m *Memory) UpdatePlayerExperience(playerID int, expGained int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if player := m.playerByID(playerID); player != nil {
+		player.Experience += expGained
+	}
+	return nil
+}
+
+func (m *Memory) UpdateSimpleHutBuilt(playerID int, built bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if player := m.playerByID(playerID); player != nil {
+		player.SimpleHutBuilt = built
+	}
+	return nil
+}
+
+func (m *Memory) GetPlayerInventory(playerID int) ([]models.InventoryItem, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var items []models.InventoryItem
+	for _, row := range m.inventory {
+		if row.PlayerID == playerID && row.Quantity > 0 {
+			item := *row
+			item.Attributes = maps.Clone(row.Attributes)
+			items = append(items, item)
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].Type != items[j].Type {
+			return items[i].Type < items[j].Type
+		}
+		return items[i].ItemName < items[j].ItemName
+	})
+	return items, nil
+}
+
+func (m *Memory) GetItemQuantityInInventory(playerID int, itemName string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	quantity := 0
+	for _, row := range m.inventory {
+		if row.PlayerID == playerID && row.ItemName == itemName {
+			quantity += row.Quantity
+		}
+	}
+	return quantity, nil
+}
+
+func (m *Memory) inventoryRow(playerID int, itemName string) *models.InventoryItem {
+	for _, row := range m.inventory {
+		if row.PlayerID == playerID && row.ItemName == itemName {
+			return row
+		}
+	}
+	return nil
+}
+
+// removeEmpty удаляет записи инвентаря с нулевым количеством
+func (m *Memory) removeEmpty(playerID int, itemName string) {
+	kept := m.inventory[:0]
+	for _, row := range m.inventory {
+		if row.PlayerID == playerID && row.ItemName == itemName && row.Quantity <= 0 {
+			continue
+		}
+		kept = append(kept, row)
+	}
+	m.inventory = kept
+}
+
+func (m *Memory) ApplyInventoryChange(playerID int, change models.InventoryChange) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.applyInventoryChange(playerID, change)
+}
+
+// applyInventoryChange сначала проверяет все изменение, чтобы не применить его частично
+func (m *Memory) applyInventoryChange(playerID int, change models.InventoryChange) error {
+	for _, delta := range change.Consume {
+		if _, exists := m.items[delta.ItemName]; !exists {
+			return fmt.Errorf("unknown item %q", delta.ItemName)
+		}
+		if delta.Quantity <= 0 {
+			return fmt.Errorf("consume %s: quantity must be positive", delta.ItemName)
+		}
+		total := 0
+		for _, row := range m.consumable(playerID, delta) {
+			total += row.Quantity
+		}
+		if total < delta.Quantity {
+			return &InsufficientItemError{ItemName: delta.ItemName}
+		}
+	}
+	for _, delta := range change.Wear {
+		if _, exists := m.items[delta.ItemName]; !exists {
+			return fmt.Errorf("unknown item %q", delta.ItemName)
+		}
+		if delta.Durability <= 0 {
+			return fmt.Errorf("wear %s: durability must be positive", delta.ItemName)
+		}
+		if m.wornTool(playerID, delta.ItemName, delta.InstanceID) == nil {
+			return &InsufficientItemError{ItemName: delta.ItemName}
+		}
+	}
+	for _, delta := range change.Grant {
+		if _, exists := m.items[delta.ItemName]; !exists {
+			return fmt.Errorf("unknown item %q", delta.ItemName)
+		}
+		if delta.Quantity <= 0 {
+			return fmt.Errorf("grant %s: quantity must be positive", delta.ItemName)
+		}
+	}
+
+	for _, delta := range change.Consume {
+		need := delta.Quantity
+		for _, row := range m.consumable(playerID, delta) {
+			if need == 0 {
+				break
+			}
+			take := min(row.Quantity, need)
+			row.Quantity -= take
+			need -= take
+		}
+		m.removeEmpty(playerID, delta.ItemName)
+		m.recordLedger(playerID, delta.ItemName, -delta.Quantity, 0, change.Reason)
+	}
+	for _, delta := range change.Wear {
+		row := m.wornTool(playerID, delta.ItemName, delta.InstanceID)
+		loss := min(delta.Durability, row.Durability)
+		row.Durability -= loss
+		m.recordLedger(playerID, delta.ItemName, 0, -loss, change.Reason)
+	}
+	for _, delta := range change.Grant {
+		item := m.items[delta.ItemName]
+		if item.DurabilityMax > 0 {
+			// Инструменты выдаются отдельными экземплярами
+			durability := delta.Durability
+			if durability <= 0 {
+				durability = item.DurabilityMax
+			}
+			for i := 0; i < delta.Quantity; i++ {
+				m.inventory = append(m.inventory, &models.InventoryItem{
+					ID: m.newID(), PlayerID: playerID, ItemID: item.ID, ItemName: item.Name, Quantity: 1,
+					Durability: durability, DurabilityMax: item.DurabilityMax, Type: item.Type,
+					Attributes: maps.Clone(delta.Attributes),
+				})
+			}
+		} else if row := m.inventoryRow(playerID, delta.ItemName); row != nil {
+			row.Quantity += delta.Quantity
+		} else {
+			m.inventory = append(m.inventory, &models.InventoryItem{
+				ID: m.newID(), PlayerID: playerID, ItemID: item.ID, ItemName: item.Name,
+				Quantity: delta.Quantity, Type: item.Type,
+			})
+		}
+		m.recordLedger(playerID, delta.ItemName, delta.Quantity, 0, change.Reason)
+	}
+	return nil
+}
+
+// consumable возвращает записи, с которых списывается предмет: самые изношенные экземпляры первыми
+func (m *Memory) consumable(playerID int, delta models.ItemDelta) []*models.InventoryItem {
+	var rows []*models.InventoryItem
+	for _, row := range m.inventory {
+		if row.PlayerID == playerID && row.ItemName == delta.ItemName && (delta.InstanceID == 0 || row.ID == delta.InstanceID) {
+			rows = append(rows, row)
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].Durability < rows[j].Durability })
+	return rows
+}
+
+// wornTool возвращает целый экземпляр инструмента, как GetTool в DB: указанный,
+// выбранный игроком или самый прочный
+func (m *Memory) wornTool(playerID int, toolName string, instanceID int) *models.InventoryItem {
+	var best *models.InventoryItem
+	for _, row := range m.inventory {
+		if row.PlayerID != playerID || row.ItemName != toolName || row.Quantity <= 0 || row.Durability <= 0 {
+			continue
+		}
+		if instanceID != 0 && row.ID != instanceID {
+			continue
+		}
+		if best == nil || row.Equipped && !best.Equipped ||
+			row.Equipped == best.Equipped && row.Durability > best.Durability {
+			best = row
+		}
+	}
+	return best
+}
+
+func (m *Memory) recordLedger(playerID int, itemName string, quantityDelta, durabilityDelta int, reason string) {
+	m.ledger = append(m.ledger, models.LedgerEntry{
+		ID: m.newID(), PlayerID: playerID, ItemName: itemName, QuantityDelta: quantityDelta,
+		DurabilityDelta: durabilityDelta, Reason: reason, CreatedAt: time.Now(),
+	})
+}
+
+func (m *Memory) GetInventoryLedger(playerID int, limit int) ([]models.LedgerEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var entries []models.LedgerEntry
+	for i := len(m.ledger) - 1; i >= 0 && len(entries) < limit; i-- {
+		if m.ledger[i].PlayerID == playerID {
+			entries = append(entries, m.ledger[i])
+		}
+	}
+	return entries, nil
+}
+
+func (m *Memory) GetTool(playerID int, toolName string) (*models.InventoryItem, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	row := m.wornTool(playerID, toolName, 0)
+	if row == nil {
+		return nil, nil
+	}
+	tool := *row
+	tool.Attributes = maps.Clone(row.Attributes)
+	return &tool, nil
+}
+
+func (m *Memory) EquipTool(playerID int, instanceID int) (*models.InventoryItem, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var chosen *models.InventoryItem
+	for _, row := range m.inventory {
+		if row.ID == instanceID && row.PlayerID == playerID && row.IsTool() {
+			chosen = row
+		}
+	}
+	if chosen == nil {
+		return nil, nil
+	}
+	for _, row := range m.inventory {
+		if row.PlayerID == playerID && row.ItemName == chosen.ItemName {
+			row.Equipped = row == chosen
+		}
+	}
+	tool := *chosen
+	tool.Attributes = maps.Clone(chosen.Attributes)
+	return &tool, nil
+}
+
+func (m *Memory) GetRecipe(key string) (*models.Recipe, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, recipe := range m.recipes {
+		if recipe.Key == key {
+			recipe.Ingredients = append([]models.RecipeIngredient(nil), recipe.Ingredients...)
+			return &recipe, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *Memory) GetStationRecipes(station string) ([]models.Recipe, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var recipes []models.Recipe
+	for _, recipe := range m.recipes {
+		if recipe.Station == station {
+			recipe.Ingredients = append([]models.RecipeIngredient(nil), recipe.Ingredients...)
+			recipes = append(recipes, recipe)
+		}
+	}
+	return recipes, nil
+}
+
+func (m *Memory) GetFuels() ([]models.Item, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var fuels []models.Item
+	for _, item := range m.items {
+		if item.BurnValue > 0 {
+			fuels = append(fuels, item)
+		}
+	}
+	sort.Slice(fuels, func(i, j int) bool {
+		if fuels[i].BurnValue != fuels[j].BurnValue {
+			return fuels[i].BurnValue > fuels[j].BurnValue
+		}
+		return fuels[i].Name < fuels[j].Name
+	})
+	return fuels, nil
+}
+
+func (m *Memory) GetToolItems(kind string) ([]models.Item, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var tools []models.Item
+	for _, item := range m.items {
+		if item.Type == "tool" && item.Tool == kind {
+			tools = append(tools, item)
+		}
+	}
+	sort.Slice(tools, func(i, j int) bool {
+		if tools[i].Tier != tools[j].Tier {
+			return tools[i].Tier > tools[j].Tier
+		}
+		return tools[i].Name < tools[j].Name
+	})
+	return tools, nil
+}
+
+func (m *Memory) GetEdibleItems() ([]models.Item, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var foods []models.Item
+	for _, item := range m.items {
+		if item.Satiety > 0 {
+			foods = append(foods, item)
+		}
+	}
+	sort.Slice(foods, func(i, j int) bool {
+		if foods[i].Satiety != foods[j].Satiety {
+			return foods[i].Satiety > foods[j].Satiety
+		}
+		return foods[i].Name < foods[j].Name
+	})
+	return foods, nil
+}
+
+func (m *Memory) QueueFurnaceJob(playerID int, job models.FurnaceJob, change models.InventoryChange) (*models.FurnaceJob, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.applyInventoryChange(playerID, change); err != nil {
+		return nil, err
+	}
+
+	job.ID = m.newID()
+	job.PlayerID = playerID
+	m.furnace = append(m.furnace, job)
+	return &job, nil
+}
+
+func (m *Memory) GetFurnaceJobs(playerID int) ([]models.FurnaceJob, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var jobs []models.FurnaceJob
+	for _, job := range m.furnace {
+		if job.PlayerID == playerID {
+			jobs = append(jobs, job)
+		}
+	}
+	sort.SliceStable(jobs, func(i, j int) bool { return jobs[i].EndsAt.Before(jobs[j].EndsAt) })
+	return jobs, nil
+}
+
+func (m *Memory) CollectFurnaceJobs(playerID int, now time.Time) ([]models.FurnaceJob, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var ready, waiting []models.FurnaceJob
+	for _, job := range m.furnace {
+		if job.PlayerID == playerID && job.Ready(now) {
+			ready = append(ready, job)
+		} else {
+			waiting = append(waiting, job)
+		}
+	}
+
+	for _, job := range ready {
+		err := m.applyInventoryChange(playerID, models.InventoryChange{
+			Reason: "furnace:" + job.RecipeKey,
+			Grant:  []models.ItemDelta{{ItemName: job.ItemName, Quantity: job.Quantity}},
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	m.furnace = waiting
+	return ready, nil
+}
+
+// GetOrCreateLocation возвращает прогресс игрока в локации, создавая его при первом посещении
+func (m *Memory) GetOrCreateLocation(playerID int, location string) (*models.Location, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.locations[location] == nil {
+		m.locations[location] = make(map[int]*memoryLocation)
+	}
+	row, exists := m.locations[location][playerID]
+	if !exists {
+		row = &memoryLocation{id: m.newID(), level: 1, lastUsed: time.Now()}
+		m.locations[location][playerID] = row
+	}
+	return &models.Location{
+		ID: row.id, PlayerID: playerID, Location: location, Level: row.level,
+		Experience: row.experience, LastUsed: row.lastUsed, IsExhausted: row.isExhausted,
+	}, nil
+}
+
+// UpdateLocationExperience начисляет опыт локации: каждые 100 опыта - новый уровень
+func (m *Memory) UpdateLocationExperience(playerID int, location string, expGained int) (bool, int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	row, exists := m.locations[location][playerID]
+	if !exists {
+		return false, 0, sql.ErrNoRows
+	}
+
+	currentLevel := row.level
+	row.experience += expGained
+	row.level = nextLocationLevel(currentLevel, row.experience)
+	return row.level > currentLevel, row.level, nil
+}
+
+func (m *Memory) SetLocationExhausted(playerID int, location string, exhausted bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if row, exists := m.locations[location][playerID]; exists {
+		row.isExhausted = exhausted
+		row.lastUsed = time.Now()
+	}
+	return nil
+}
+
+func (m *Memory) quest(playerID int, questID int) *models.Quest {
+	for _, quest := range m.quests {
+		if quest.PlayerID == playerID && quest.QuestID == questID {
+			return quest
+		}
+	}
+	return nil
+}
+
+func (m *Memory) GetPlayerQuest(playerID int, questID int) (*models.Quest, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	quest := m.quest(playerID, questID)
+	if quest == nil {
+		return nil, nil // Квест не найден
+	}
+	copied := *quest
+	return &copied, nil
+}
+
+func (m *Memory) CreateQuest(playerID int, questID int, target int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.quests = append(m.quests, &models.Quest{
+		ID: m.newID(), PlayerID: playerID, QuestID: questID,
+		Status: "available", Progress: 0, Target: target, CreatedAt: time.Now(),
+	})
+	return nil
+}
+
+func (m *Memory) UpdateQuestStatus(playerID int, questID int, status string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if quest := m.quest(playerID, questID); quest != nil {
+		quest.Status = status
+		if status == "completed" {
+			now := time.Now()
+			quest.CompletedAt = &now
+		}
+	}
+	return nil
+}
+
+func (m *Memory) CompleteQuest(playerID int, questID int, experience int, reward models.InventoryChange) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Награда проверяется и выдается первой: если она не проходит, квест не меняется
+	if err := m.applyInventoryChange(playerID, reward); err != nil {
+		return err
+	}
+	if quest := m.quest(playerID, questID); quest != nil {
+		quest.Status = "completed"
+		now := time.Now()
+		quest.CompletedAt = &now
+	}
+	if player := m.playerByID(playerID); player != nil {
+		player.Experience += experience
+	}
+	return nil
+}
+
+func (m *Memory) UpdateQuestProgress(playerID int, questID int, progress int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if quest := m.quest(playerID, questID); quest != nil {
+		quest.Progress = progress
+	}
+	return nil
+}
+
+func (m *Memory) SavePlayerRuntime(telegramID int64, sessions []models.LocationSession, actions []models.TimedAction, cooldowns []models.Cooldown) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sessions[telegramID] = append([]models.LocationSession(nil), sessions...)
+	m.actions[telegramID] = append([]models.TimedAction(nil), actions...)
+	m.cooldowns[telegramID] = append([]models.Cooldown(nil), cooldowns...)
+	return nil
+}
+
+func (m *Memory) GetLocationSessions() ([]models.LocationSession, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var sessions []models.LocationSession
+	for _, playerSessions := range m.sessions {
+		sessions = append(sessions, playerSessions...)
+	}
+	return sessions, nil
+}
+
+func (m *Memory) GetTimedActions() ([]models.TimedAction, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var actions []models.TimedAction
+	for _, playerActions := range m.actions {
+		actions = append(actions, playerActions...)
+	}
+	sort.Slice(actions, func(i, j int) bool {
+		return actions[i].EndsAt.Before(actions[j].EndsAt)
+	})
+	return actions, nil
+}
+
+func (m *Memory) GetCooldowns(now time.Time) ([]models.Cooldown, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var cooldowns []models.Cooldown
+	for telegramID, playerCooldowns := range m.cooldowns {
+		active := playerCooldowns[:0]
+		for _, cooldown := range playerCooldowns {
+			if cooldown.EndsAt.After(now) {
+				active = append(active, cooldown)
+			}
+		}
+		m.cooldowns[telegramID] = active
+		cooldowns = append(cooldowns, active...)
+	}
+	return cooldowns, nil
+}
@@ -0,0 +1,272 @@
+package database
+
+import (
+	"errors"
+	"reborn_land/catalog"
+	"reborn_land/models"
+	"testing"
+	"time"
+)
+
+// Тесты проверяют, что Memory соблюдает те же правила, что и запросы DB:
+// изменения инвентаря целиком или никак, экземпляры инструментов, выбор
+// инструмента для работы, атомарное завершение квеста, пределы сытости и баффы.
+
+func newTestMemory(t *testing.T) (*Memory, *models.Player) {
+	t.Helper()
+	items := catalog.Default()
+	m := NewMemory(items, catalog.DefaultRecipes(items))
+	player, err := m.CreatePlayer(1, "Тест", time.Now())
+	if err != nil {
+		t.Fatalf("CreatePlayer: %v", err)
+	}
+	return m, player
+}
+
+func quantity(t *testing.T, m *Memory, playerID int, item string) int {
+	t.Helper()
+	q, err := m.GetItemQuantityInInventory(playerID, item)
+	if err != nil {
+		t.Fatalf("GetItemQuantityInInventory(%s): %v", item, err)
+	}
+	return q
+}
+
+func tools(t *testing.T, m *Memory, playerID int, name string) []models.InventoryItem {
+	t.Helper()
+	inventory, err := m.GetPlayerInventory(playerID)
+	if err != nil {
+		t.Fatalf("GetPlayerInventory: %v", err)
+	}
+	var instances []models.InventoryItem
+	for _, row := range inventory {
+		if row.ItemName == name {
+			instances = append(instances, row)
+		}
+	}
+	return instances
+}
+
+func TestMemoryStarterInventory(t *testing.T) {
+	m, player := newTestMemory(t)
+	for _, delta := range starterInventory.Grant {
+		if got := quantity(t, m, player.ID, delta.ItemName); got != delta.Quantity {
+			t.Errorf("starter %s = %d, want %d", delta.ItemName, got, delta.Quantity)
+		}
+	}
+}
+
+func TestMemoryCreatePlayerIsAllOrNothing(t *testing.T) {
+	m, _ := newTestMemory(t)
+
+	saved := starterInventory
+	defer func() { starterInventory = saved }()
+	starterInventory = models.InventoryChange{Reason: "start", Grant: []models.ItemDelta{{ItemName: "Нет такого", Quantity: 1}}}
+
+	if _, err := m.CreatePlayer(2, "Без инвентаря", time.Now()); err == nil {
+		t.Fatalf("CreatePlayer with a broken starter inventory succeeded")
+	}
+	if exists, _ := m.PlayerExists(2); exists {
+		t.Errorf("player was kept after the starter inventory failed")
+	}
+}
+
+// Время создания игрока и истечения кулдаунов берется из переданных часов, а не из time.Now
+func TestMemoryUsesGivenTime(t *testing.T) {
+	m, _ := newTestMemory(t)
+	now := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	player, err := m.CreatePlayer(2, "Из прошлого", now)
+	if err != nil {
+		t.Fatalf("CreatePlayer: %v", err)
+	}
+	if !player.SatietyUpdatedAt.Equal(now) || !player.CreatedAt.Equal(now) {
+		t.Errorf("satiety updated at %v, created at %v; want %v", player.SatietyUpdatedAt, player.CreatedAt, now)
+	}
+
+	m.SavePlayerRuntime(2, nil, nil, []models.Cooldown{
+		{PlayerID: 2, Location: "mine", EndsAt: now.Add(time.Minute)},
+		{PlayerID: 2, Location: "forest", EndsAt: now.Add(-time.Minute)},
+	})
+	cooldowns, err := m.GetCooldowns(now)
+	if err != nil {
+		t.Fatalf("GetCooldowns: %v", err)
+	}
+	if len(cooldowns) != 1 || cooldowns[0].Location != "mine" {
+		t.Errorf("cooldowns at %v = %+v, want only mine", now, cooldowns)
+	}
+}
+
+func TestMemoryInventoryChangeIsAllOrNothing(t *testing.T) {
+	m, player := newTestMemory(t)
+	ledger, _ := m.GetInventoryLedger(player.ID, 100)
+
+	err := m.ApplyInventoryChange(player.ID, models.InventoryChange{
+		Reason:  "test",
+		Consume: []models.ItemDelta{{ItemName: "Лесная ягода", Quantity: 5}, {ItemName: "Камень", Quantity: 1}},
+		Grant:   []models.ItemDelta{{ItemName: "Уголь", Quantity: 3}},
+	})
+	var insufficient *InsufficientItemError
+	if !errors.As(err, &insufficient) || insufficient.ItemName != "Камень" {
+		t.Fatalf("err = %v, want InsufficientItemError for Камень", err)
+	}
+	if got := quantity(t, m, player.ID, "Лесная ягода"); got != 10 {
+		t.Errorf("berries = %d after failed change, want 10", got)
+	}
+	if got := quantity(t, m, player.ID, "Уголь"); got != 0 {
+		t.Errorf("coal = %d after failed change, want 0", got)
+	}
+	if after, _ := m.GetInventoryLedger(player.ID, 100); len(after) != len(ledger) {
+		t.Errorf("ledger grew from %d to %d entries after failed change", len(ledger), len(after))
+	}
+
+	if err := m.ApplyInventoryChange(player.ID, models.InventoryChange{Reason: "unknown", Grant: []models.ItemDelta{{ItemName: "Нет такого", Quantity: 1}}}); err == nil {
+		t.Errorf("granting an unknown item succeeded")
+	}
+}
+
+func TestMemoryToolInstances(t *testing.T) {
+	m, player := newTestMemory(t)
+
+	// Инструменты выдаются отдельными экземплярами, по умолчанию с полной прочностью
+	err := m.ApplyInventoryChange(player.ID, models.InventoryChange{
+		Reason: "test",
+		Grant: []models.ItemDelta{
+			{ItemName: "Простая кирка", Quantity: 1, Durability: 40},
+			{ItemName: "Простая кирка", Quantity: 1},
+		},
+	})
+	if err != nil {
+		t.Fatalf("grant pickaxes: %v", err)
+	}
+	instances := tools(t, m, player.ID, "Простая кирка")
+	if len(instances) != 3 {
+		t.Fatalf("pickaxe instances = %d, want 3", len(instances))
+	}
+	for _, instance := range instances {
+		if instance.Quantity != 1 {
+			t.Errorf("tool instance %d has quantity %d", instance.ID, instance.Quantity)
+		}
+	}
+
+	// Без выбора для работы берется самый прочный экземпляр
+	tool, err := m.GetTool(player.ID, "Простая кирка")
+	if err != nil || tool == nil || tool.Durability != 100 {
+		t.Fatalf("GetTool = %+v, %v; want durability 100", tool, err)
+	}
+
+	// Выбранный экземпляр берется раньше более прочных
+	var worn models.InventoryItem
+	for _, instance := range instances {
+		if instance.Durability == 40 {
+			worn = instance
+		}
+	}
+	if equipped, err := m.EquipTool(player.ID, worn.ID); err != nil || equipped == nil || !equipped.Equipped {
+		t.Fatalf("EquipTool = %+v, %v", equipped, err)
+	}
+	if tool, _ := m.GetTool(player.ID, "Простая кирка"); tool.ID != worn.ID {
+		t.Errorf("GetTool after equip = instance %d, want %d", tool.ID, worn.ID)
+	}
+	if equipped, _ := m.EquipTool(player.ID, 999999); equipped != nil {
+		t.Errorf("equipped a missing instance: %+v", equipped)
+	}
+
+	// Износ снимает прочность только с указанного экземпляра
+	err = m.ApplyInventoryChange(player.ID, models.InventoryChange{
+		Reason: "test",
+		Wear:   []models.ItemDelta{{ItemName: "Простая кирка", InstanceID: worn.ID, Durability: 1}},
+	})
+	if err != nil {
+		t.Fatalf("wear: %v", err)
+	}
+	for _, instance := range tools(t, m, player.ID, "Простая кирка") {
+		want := 100
+		if instance.ID == worn.ID {
+			want = 39
+		}
+		if instance.Durability != want {
+			t.Errorf("instance %d durability = %d, want %d", instance.ID, instance.Durability, want)
+		}
+	}
+
+	// Без указания экземпляра списывается самый изношенный
+	err = m.ApplyInventoryChange(player.ID, models.InventoryChange{
+		Reason:  "test",
+		Consume: []models.ItemDelta{{ItemName: "Простая кирка", Quantity: 1}},
+	})
+	if err != nil {
+		t.Fatalf("consume: %v", err)
+	}
+	for _, instance := range tools(t, m, player.ID, "Простая кирка") {
+		if instance.ID == worn.ID {
+			t.Errorf("the most worn instance %d was not consumed first", worn.ID)
+		}
+	}
+}
+
+func TestMemoryCompleteQuest(t *testing.T) {
+	m, player := newTestMemory(t)
+	if err := m.CreateQuest(player.ID, 1, 5); err != nil {
+		t.Fatalf("CreateQuest: %v", err)
+	}
+	if err := m.UpdateQuestStatus(player.ID, 1, "active"); err != nil {
+		t.Fatalf("UpdateQuestStatus: %v", err)
+	}
+
+	// Награда не выдается - квест остается активным, опыт не начисляется
+	bad := models.InventoryChange{Reason: "quest:1", Grant: []models.ItemDelta{{ItemName: "Нет такого", Quantity: 1}}}
+	if err := m.CompleteQuest(player.ID, 1, 10, bad); err == nil {
+		t.Fatalf("CompleteQuest with an unknown reward succeeded")
+	}
+	quest, _ := m.GetPlayerQuest(player.ID, 1)
+	current, _ := m.GetPlayer(1)
+	if quest.Status != "active" || current.Experience != 0 {
+		t.Fatalf("after failed completion: status %q, experience %d", quest.Status, current.Experience)
+	}
+
+	reward := models.InventoryChange{Reason: "quest:1", Grant: []models.ItemDelta{{ItemName: "Камень", Quantity: 2}}}
+	if err := m.CompleteQuest(player.ID, 1, 10, reward); err != nil {
+		t.Fatalf("CompleteQuest: %v", err)
+	}
+	quest, _ = m.GetPlayerQuest(player.ID, 1)
+	current, _ = m.GetPlayer(1)
+	if quest.Status != "completed" || quest.CompletedAt == nil || current.Experience != 10 || quantity(t, m, player.ID, "Камень") != 2 {
+		t.Errorf("after completion: quest %+v, experience %d, stones %d", quest, current.Experience, quantity(t, m, player.ID, "Камень"))
+	}
+}
+
+func TestMemorySatietyAndBuffs(t *testing.T) {
+	m, player := newTestMemory(t)
+
+	// Сытость ограничена 0..100, как в LEAST(GREATEST(...)) запроса
+	m.UpdatePlayerSatiety(player.ID, 50)
+	if p, _ := m.GetPlayer(1); p.Satiety != 100 {
+		t.Errorf("satiety = %d, want 100", p.Satiety)
+	}
+	settled := player.SatietyUpdatedAt.Add(time.Hour)
+	m.DecayPlayerSatiety(player.ID, 150, settled)
+	if p, _ := m.GetPlayer(1); p.Satiety != 0 || !p.SatietyUpdatedAt.Equal(settled) {
+		t.Errorf("after decay: satiety %d, settled at %v", p.Satiety, p.SatietyUpdatedAt)
+	}
+
+	// Бафф той же еды продлевается, истекшие баффы не возвращаются
+	now := time.Now()
+	m.AddPlayerBuff(player.ID, models.Buff{ItemName: "Жареный кролик", Speed: 10, EndsAt: now.Add(time.Minute)}, now)
+	m.AddPlayerBuff(player.ID, models.Buff{ItemName: "Жареный кролик", Speed: 10, EndsAt: now.Add(time.Hour)}, now)
+	m.AddPlayerBuff(player.ID, models.Buff{ItemName: "Жареный окунь", Yield: 10, EndsAt: now.Add(time.Minute)}, now)
+	buffs, _ := m.GetPlayerBuffs(player.ID, now)
+	if len(buffs) != 2 || buffs[0].ItemName != "Жареный кролик" || !buffs[0].EndsAt.Equal(now.Add(time.Hour)) {
+		t.Fatalf("buffs = %+v", buffs)
+	}
+	if buffs, _ := m.GetPlayerBuffs(player.ID, now.Add(2*time.Minute)); len(buffs) != 1 {
+		t.Errorf("buffs after the short one expired = %+v", buffs)
+	}
+
+	// Истекшие баффы удаляются по времени игровых часов, а не системных
+	later := now.Add(2 * time.Minute)
+	m.AddPlayerBuff(player.ID, models.Buff{ItemName: "Жареный карась", Speed: 5, EndsAt: later.Add(time.Minute)}, later)
+	if stored := m.buffs[player.ID]; len(stored) != 2 {
+		t.Errorf("stored buffs after cleanup at %v = %+v, want rabbit and crucian", later, stored)
+	}
+}
@@ -0,0 +1,649 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+)
+
+// migration - одно версионированное изменение схемы.
+// Запросы up применяют изменение, down откатывают его.
+type migration struct {
+	version int
+	name    string
+	up      []string
+	down    []string
+}
+
+// MigrationStatus - состояние одной миграции в базе
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// migrations - список миграций по возрастанию версии. Уже выпущенные миграции
+// не меняются: любое изменение схемы добавляется новой миграцией в конец списка.
+var migrations = []migration{
+	{
+		// Схема, которую раньше создавал createTables. На существующих установках
+		// таблицы уже есть, поэтому все запросы идемпотентны.
+		version: 1,
+		name:    "baseline",
+		up: []string{
+			`CREATE TABLE IF NOT EXISTS players (
+				id SERIAL PRIMARY KEY,
+				telegram_id BIGINT UNIQUE NOT NULL,
+				name VARCHAR(30) NOT NULL,
+				level INTEGER DEFAULT 1,
+				experience INTEGER DEFAULT 0,
+				satiety INTEGER DEFAULT 100,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+			)`,
+			`CREATE TABLE IF NOT EXISTS items (
+				id SERIAL PRIMARY KEY,
+				name VARCHAR(100) NOT NULL,
+				type VARCHAR(50) NOT NULL,
+				durability_max INTEGER DEFAULT 0
+			)`,
+			`CREATE TABLE IF NOT EXISTS inventory (
+				id SERIAL PRIMARY KEY,
+				player_id INTEGER REFERENCES players(id),
+				item_id INTEGER REFERENCES items(id),
+				quantity INTEGER DEFAULT 1,
+				durability INTEGER DEFAULT 0
+			)`,
+			`CREATE TABLE IF NOT EXISTS mines (
+				id SERIAL PRIMARY KEY,
+				player_id INTEGER REFERENCES players(id),
+				level INTEGER DEFAULT 1,
+				experience INTEGER DEFAULT 0,
+				last_used TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				is_exhausted BOOLEAN DEFAULT false
+			)`,
+			`CREATE TABLE IF NOT EXISTS forests (
+				id SERIAL PRIMARY KEY,
+				player_id INTEGER REFERENCES players(id),
+				level INTEGER DEFAULT 1,
+				experience INTEGER DEFAULT 0,
+				last_used TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				is_exhausted BOOLEAN DEFAULT false
+			)`,
+			`CREATE TABLE IF NOT EXISTS gathering (
+				id SERIAL PRIMARY KEY,
+				player_id INTEGER REFERENCES players(id),
+				level INTEGER DEFAULT 1,
+				experience INTEGER DEFAULT 0,
+				last_used TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				is_exhausted BOOLEAN DEFAULT false
+			)`,
+			`CREATE TABLE IF NOT EXISTS quests (
+				id SERIAL PRIMARY KEY,
+				player_id INTEGER REFERENCES players(id),
+				quest_id INTEGER NOT NULL,
+				status VARCHAR(20) DEFAULT 'available',
+				progress INTEGER DEFAULT 0,
+				target INTEGER NOT NULL,
+				created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				completed_at TIMESTAMP NULL
+			)`,
+		},
+		down: []string{
+			`DROP TABLE IF EXISTS quests`,
+			`DROP TABLE IF EXISTS gathering`,
+			`DROP TABLE IF EXISTS forests`,
+			`DROP TABLE IF EXISTS mines`,
+			`DROP TABLE IF EXISTS inventory`,
+			`DROP TABLE IF EXISTS items`,
+			`DROP TABLE IF EXISTS players`,
+		},
+	},
+	{
+		// Колонка появилась уже после первых установок, и CREATE TABLE IF NOT EXISTS
+		// до них ее не доносил
+		version: 2,
+		name:    "players_simple_hut_built",
+		up: []string{
+			`ALTER TABLE players ADD COLUMN IF NOT EXISTS simple_hut_built BOOLEAN DEFAULT false`,
+		},
+		down: []string{
+			`ALTER TABLE players DROP COLUMN IF EXISTS simple_hut_built`,
+		},
+	},
+	{
+		// Таблица охоты использовалась кодом, но никогда не создавалась
+		version: 3,
+		name:    "hunting",
+		up: []string{
+			`CREATE TABLE IF NOT EXISTS hunting (
+				id SERIAL PRIMARY KEY,
+				player_id INTEGER REFERENCES players(id),
+				level INTEGER DEFAULT 1,
+				experience INTEGER DEFAULT 0,
+				last_used TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				is_exhausted BOOLEAN DEFAULT false
+			)`,
+		},
+		down: []string{
+			`DROP TABLE IF EXISTS hunting`,
+		},
+	},
+	{
+		version: 4,
+		name:    "player_runtime_state",
+		up: []string{
+			`CREATE TABLE IF NOT EXISTS player_sessions (
+				telegram_id BIGINT NOT NULL,
+				location VARCHAR(20) NOT NULL,
+				chat_id BIGINT NOT NULL,
+				resources TEXT NOT NULL,
+				field_message_id INTEGER DEFAULT 0,
+				info_message_id INTEGER DEFAULT 0,
+				result_message_id INTEGER DEFAULT 0,
+				started_at TIMESTAMPTZ NOT NULL,
+				PRIMARY KEY (telegram_id, location)
+			)`,
+			`CREATE TABLE IF NOT EXISTS player_actions (
+				telegram_id BIGINT NOT NULL,
+				kind VARCHAR(20) NOT NULL,
+				chat_id BIGINT NOT NULL,
+				message_id INTEGER NOT NULL,
+				item_name VARCHAR(100) DEFAULT '',
+				quantity INTEGER DEFAULT 0,
+				durability INTEGER DEFAULT 0,
+				row_index INTEGER DEFAULT 0,
+				col_index INTEGER DEFAULT 0,
+				started_at TIMESTAMPTZ NOT NULL,
+				ends_at TIMESTAMPTZ NOT NULL,
+				PRIMARY KEY (telegram_id, kind)
+			)`,
+			`CREATE TABLE IF NOT EXISTS player_cooldowns (
+				telegram_id BIGINT NOT NULL,
+				location VARCHAR(20) NOT NULL,
+				ends_at TIMESTAMPTZ NOT NULL,
+				PRIMARY KEY (telegram_id, location)
+			)`,
+		},
+		down: []string{
+			`DROP TABLE IF EXISTS player_cooldowns`,
+			`DROP TABLE IF EXISTS player_actions`,
+			`DROP TABLE IF EXISTS player_sessions`,
+		},
+	},
+	{
+		// Поля справочника предметов из catalog/items.json
+		version: 5,
+		name:    "items_catalog",
+		up: []string{
+			`ALTER TABLE items ADD COLUMN IF NOT EXISTS key VARCHAR(50)`,
+			`ALTER TABLE items ADD COLUMN IF NOT EXISTS description TEXT NOT NULL DEFAULT ''`,
+			`ALTER TABLE items ADD COLUMN IF NOT EXISTS flags TEXT NOT NULL DEFAULT ''`,
+			`CREATE UNIQUE INDEX IF NOT EXISTS items_name_idx ON items (name)`,
+			`CREATE UNIQUE INDEX IF NOT EXISTS items_key_idx ON items (key)`,
+		},
+		down: []string{
+			`DROP INDEX IF EXISTS items_key_idx`,
+			`DROP INDEX IF EXISTS items_name_idx`,
+			`ALTER TABLE items DROP COLUMN IF EXISTS flags`,
+			`ALTER TABLE items DROP COLUMN IF EXISTS description`,
+			`ALTER TABLE items DROP COLUMN IF EXISTS key`,
+		},
+	},
+	{
+		// Рецепты из catalog/recipes.json
+		version: 6,
+		name:    "recipes",
+		up: []string{
+			`CREATE TABLE IF NOT EXISTS recipes (
+				id SERIAL PRIMARY KEY,
+				key VARCHAR(50) UNIQUE NOT NULL,
+				output_item VARCHAR(100) NOT NULL,
+				output_quantity INTEGER NOT NULL DEFAULT 1,
+				station VARCHAR(20) NOT NULL,
+				craft_time INTEGER NOT NULL,
+				satiety_cost INTEGER NOT NULL DEFAULT 0,
+				position INTEGER NOT NULL DEFAULT 0
+			)`,
+			`CREATE TABLE IF NOT EXISTS recipe_ingredients (
+				recipe_id INTEGER REFERENCES recipes(id) ON DELETE CASCADE,
+				item_id INTEGER REFERENCES items(id),
+				quantity INTEGER NOT NULL,
+				position INTEGER NOT NULL DEFAULT 0,
+				PRIMARY KEY (recipe_id, item_id)
+			)`,
+		},
+		down: []string{
+			`DROP TABLE IF EXISTS recipe_ingredients`,
+			`DROP TABLE IF EXISTS recipes`,
+		},
+	},
+	{
+		// Журнал всех изменений инвентаря с причиной
+		version: 7,
+		name:    "inventory_ledger",
+		up: []string{
+			`CREATE TABLE IF NOT EXISTS inventory_ledger (
+				id BIGSERIAL PRIMARY KEY,
+				player_id INTEGER REFERENCES players(id),
+				item_id INTEGER REFERENCES items(id),
+				quantity_delta INTEGER NOT NULL DEFAULT 0,
+				durability_delta INTEGER NOT NULL DEFAULT 0,
+				reason VARCHAR(100) NOT NULL,
+				created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+			)`,
+			`CREATE INDEX IF NOT EXISTS inventory_ledger_player_idx ON inventory_ledger (player_id, id)`,
+		},
+		down: []string{
+			`DROP TABLE IF EXISTS inventory_ledger`,
+		},
+	},
+	{
+		// Инструменты хранятся отдельными экземплярами со своей прочностью
+		version: 8,
+		name:    "tool_instances",
+		up: []string{
+			`ALTER TABLE inventory ADD COLUMN IF NOT EXISTS equipped BOOLEAN NOT NULL DEFAULT false`,
+			`ALTER TABLE inventory ADD COLUMN IF NOT EXISTS attributes JSONB NOT NULL DEFAULT '{}'`,
+			// Раскладываем стопки инструментов на экземпляры с прочностью стопки
+			`INSERT INTO inventory (player_id, item_id, quantity, durability)
+			SELECT i.player_id, i.item_id, 1, i.durability
+			FROM inventory i
+			JOIN items it ON i.item_id = it.id
+			CROSS JOIN generate_series(2, i.quantity)
+			WHERE it.durability_max > 0 AND i.quantity > 1`,
+			`UPDATE inventory SET quantity = 1
+			FROM items
+			WHERE inventory.item_id = items.id AND items.durability_max > 0 AND inventory.quantity > 1`,
+			`ALTER TABLE player_actions ADD COLUMN IF NOT EXISTS tool_id INTEGER NOT NULL DEFAULT 0`,
+		},
+		// Экземпляры остаются отдельными записями, старый код читает их как стопки по 1
+		down: []string{
+			`ALTER TABLE player_actions DROP COLUMN IF EXISTS tool_id`,
+			`ALTER TABLE inventory DROP COLUMN IF EXISTS attributes`,
+			`ALTER TABLE inventory DROP COLUMN IF EXISTS equipped`,
+		},
+	},
+	{
+		// Озеро: прогресс рыбалки
+		version: 9,
+		name:    "lakes",
+		up: []string{
+			`CREATE TABLE IF NOT EXISTS lakes (
+				id SERIAL PRIMARY KEY,
+				player_id INTEGER REFERENCES players(id),
+				level INTEGER DEFAULT 1,
+				experience INTEGER DEFAULT 0,
+				last_used TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				is_exhausted BOOLEAN DEFAULT false
+			)`,
+		},
+		down: []string{
+			`DROP TABLE IF EXISTS lakes`,
+		},
+	},
+	{
+		// Поле: прогресс сбора трав и злаков
+		version: 10,
+		name:    "fields",
+		up: []string{
+			`CREATE TABLE IF NOT EXISTS fields (
+				id SERIAL PRIMARY KEY,
+				player_id INTEGER REFERENCES players(id),
+				level INTEGER DEFAULT 1,
+				experience INTEGER DEFAULT 0,
+				last_used TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				is_exhausted BOOLEAN DEFAULT false
+			)`,
+		},
+		down: []string{
+			`DROP TABLE IF EXISTS fields`,
+		},
+	},
+	{
+		// Печь: топливо предметов и рецептов, очередь партий
+		version: 11,
+		name:    "furnace",
+		up: []string{
+			`ALTER TABLE items ADD COLUMN IF NOT EXISTS burn_value INTEGER NOT NULL DEFAULT 0`,
+			`ALTER TABLE recipes ADD COLUMN IF NOT EXISTS fuel INTEGER NOT NULL DEFAULT 0`,
+			`CREATE TABLE IF NOT EXISTS furnace_jobs (
+				id SERIAL PRIMARY KEY,
+				player_id INTEGER NOT NULL REFERENCES players(id),
+				recipe_key VARCHAR(50) NOT NULL,
+				item_name VARCHAR(100) NOT NULL,
+				quantity INTEGER NOT NULL,
+				started_at TIMESTAMPTZ NOT NULL,
+				ends_at TIMESTAMPTZ NOT NULL
+			)`,
+			`CREATE INDEX IF NOT EXISTS furnace_jobs_player_idx ON furnace_jobs (player_id, ends_at)`,
+		},
+		down: []string{
+			`DROP TABLE IF EXISTS furnace_jobs`,
+			`ALTER TABLE recipes DROP COLUMN IF EXISTS fuel`,
+			`ALTER TABLE items DROP COLUMN IF EXISTS burn_value`,
+		},
+	},
+	{
+		// Сытость, которую восстанавливает еда
+		version: 12,
+		name:    "food_satiety",
+		up: []string{
+			`ALTER TABLE items ADD COLUMN IF NOT EXISTS satiety INTEGER NOT NULL DEFAULT 0`,
+		},
+		down: []string{
+			`ALTER TABLE items DROP COLUMN IF EXISTS satiety`,
+		},
+	},
+	{
+		// Виды и уровни инструментов; действие помнит, каким инструментом работает игрок
+		version: 13,
+		name:    "tool_tiers",
+		up: []string{
+			`ALTER TABLE items ADD COLUMN IF NOT EXISTS tool VARCHAR(20) NOT NULL DEFAULT ''`,
+			`ALTER TABLE items ADD COLUMN IF NOT EXISTS tier INTEGER NOT NULL DEFAULT 0`,
+			`ALTER TABLE player_actions ADD COLUMN IF NOT EXISTS tool_name VARCHAR(100) NOT NULL DEFAULT ''`,
+			// Начатые до миграции действия велись простыми инструментами
+			`UPDATE player_actions SET tool_name = CASE kind
+				WHEN 'mining' THEN 'Простая кирка'
+				WHEN 'chopping' THEN 'Простой топор'
+				WHEN 'gathering' THEN 'Простой нож'
+				WHEN 'harvesting' THEN 'Простой нож'
+				WHEN 'hunting' THEN 'Простой лук'
+				WHEN 'fishing' THEN 'Простая удочка'
+				ELSE '' END`,
+		},
+		down: []string{
+			`ALTER TABLE player_actions DROP COLUMN IF EXISTS tool_name`,
+			`ALTER TABLE items DROP COLUMN IF EXISTS tier`,
+			`ALTER TABLE items DROP COLUMN IF EXISTS tool`,
+		},
+	},
+	{
+		// Бонусы инструментов: скорость работы и шанс лишней добычи
+		version: 14,
+		name:    "tool_bonuses",
+		up: []string{
+			`ALTER TABLE items ADD COLUMN IF NOT EXISTS speed INTEGER NOT NULL DEFAULT 0`,
+			`ALTER TABLE items ADD COLUMN IF NOT EXISTS yield INTEGER NOT NULL DEFAULT 0`,
+		},
+		down: []string{
+			`ALTER TABLE items DROP COLUMN IF EXISTS yield`,
+			`ALTER TABLE items DROP COLUMN IF EXISTS speed`,
+		},
+	},
+	{
+		// Прогресс всех локаций в одной таблице вместо mines, forests, gathering,
+		// hunting, lakes и fields. Ключ локации совпадает с player_sessions.location.
+		version: 15,
+		name:    "player_locations",
+		up: []string{
+			`CREATE TABLE IF NOT EXISTS player_locations (
+				id SERIAL PRIMARY KEY,
+				player_id INTEGER NOT NULL REFERENCES players(id),
+				location VARCHAR(20) NOT NULL,
+				level INTEGER NOT NULL DEFAULT 1,
+				experience INTEGER NOT NULL DEFAULT 0,
+				last_used TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				is_exhausted BOOLEAN NOT NULL DEFAULT false,
+				UNIQUE (player_id, location)
+			)`,
+			// В старых таблицах не было уникальности по игроку: берем первую запись
+			`INSERT INTO player_locations (player_id, location, level, experience, last_used, is_exhausted)
+				SELECT DISTINCT ON (player_id) player_id, 'mine', level, experience, last_used, is_exhausted
+				FROM mines WHERE player_id IS NOT NULL ORDER BY player_id, id`,
+			`INSERT INTO player_locations (player_id, location, level, experience, last_used, is_exhausted)
+				SELECT DISTINCT ON (player_id) player_id, 'forest', level, experience, last_used, is_exhausted
+				FROM forests WHERE player_id IS NOT NULL ORDER BY player_id, id`,
+			`INSERT INTO player_locations (player_id, location, level, experience, last_used, is_exhausted)
+				SELECT DISTINCT ON (player_id) player_id, 'gathering', level, experience, last_used, is_exhausted
+				FROM gathering WHERE player_id IS NOT NULL ORDER BY player_id, id`,
+			`INSERT INTO player_locations (player_id, location, level, experience, last_used, is_exhausted)
+				SELECT DISTINCT ON (player_id) player_id, 'hunting', level, experience, last_used, is_exhausted
+				FROM hunting WHERE player_id IS NOT NULL ORDER BY player_id, id`,
+			`INSERT INTO player_locations (player_id, location, level, experience, last_used, is_exhausted)
+				SELECT DISTINCT ON (player_id) player_id, 'lake', level, experience, last_used, is_exhausted
+				FROM lakes WHERE player_id IS NOT NULL ORDER BY player_id, id`,
+			`INSERT INTO player_locations (player_id, location, level, experience, last_used, is_exhausted)
+				SELECT DISTINCT ON (player_id) player_id, 'field', level, experience, last_used, is_exhausted
+				FROM fields WHERE player_id IS NOT NULL ORDER BY player_id, id`,
+			`DROP TABLE IF EXISTS mines`,
+			`DROP TABLE IF EXISTS forests`,
+			`DROP TABLE IF EXISTS gathering`,
+			`DROP TABLE IF EXISTS hunting`,
+			`DROP TABLE IF EXISTS lakes`,
+			`DROP TABLE IF EXISTS fields`,
+		},
+		down: []string{
+			`CREATE TABLE IF NOT EXISTS mines (
+				id SERIAL PRIMARY KEY,
+				player_id INTEGER REFERENCES players(id),
+				level INTEGER DEFAULT 1,
+				experience INTEGER DEFAULT 0,
+				last_used TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				is_exhausted BOOLEAN DEFAULT false
+			)`,
+			`CREATE TABLE IF NOT EXISTS forests (
+				id SERIAL PRIMARY KEY,
+				player_id INTEGER REFERENCES players(id),
+				level INTEGER DEFAULT 1,
+				experience INTEGER DEFAULT 0,
+				last_used TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				is_exhausted BOOLEAN DEFAULT false
+			)`,
+			`CREATE TABLE IF NOT EXISTS gathering (
+				id SERIAL PRIMARY KEY,
+				player_id INTEGER REFERENCES players(id),
+				level INTEGER DEFAULT 1,
+				experience INTEGER DEFAULT 0,
+				last_used TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				is_exhausted BOOLEAN DEFAULT false
+			)`,
+			`CREATE TABLE IF NOT EXISTS hunting (
+				id SERIAL PRIMARY KEY,
+				player_id INTEGER REFERENCES players(id),
+				level INTEGER DEFAULT 1,
+				experience INTEGER DEFAULT 0,
+				last_used TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				is_exhausted BOOLEAN DEFAULT false
+			)`,
+			`CREATE TABLE IF NOT EXISTS lakes (
+				id SERIAL PRIMARY KEY,
+				player_id INTEGER REFERENCES players(id),
+				level INTEGER DEFAULT 1,
+				experience INTEGER DEFAULT 0,
+				last_used TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				is_exhausted BOOLEAN DEFAULT false
+			)`,
+			`CREATE TABLE IF NOT EXISTS fields (
+				id SERIAL PRIMARY KEY,
+				player_id INTEGER REFERENCES players(id),
+				level INTEGER DEFAULT 1,
+				experience INTEGER DEFAULT 0,
+				last_used TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				is_exhausted BOOLEAN DEFAULT false
+			)`,
+			`INSERT INTO mines (player_id, level, experience, last_used, is_exhausted)
+				SELECT player_id, level, experience, last_used, is_exhausted FROM player_locations WHERE location = 'mine'`,
+			`INSERT INTO forests (player_id, level, experience, last_used, is_exhausted)
+				SELECT player_id, level, experience, last_used, is_exhausted FROM player_locations WHERE location = 'forest'`,
+			`INSERT INTO gathering (player_id, level, experience, last_used, is_exhausted)
+				SELECT player_id, level, experience, last_used, is_exhausted FROM player_locations WHERE location = 'gathering'`,
+			`INSERT INTO hunting (player_id, level, experience, last_used, is_exhausted)
+				SELECT player_id, level, experience, last_used, is_exhausted FROM player_locations WHERE location = 'hunting'`,
+			`INSERT INTO lakes (player_id, level, experience, last_used, is_exhausted)
+				SELECT player_id, level, experience, last_used, is_exhausted FROM player_locations WHERE location = 'lake'`,
+			`INSERT INTO fields (player_id, level, experience, last_used, is_exhausted)
+				SELECT player_id, level, experience, last_used, is_exhausted FROM player_locations WHERE location = 'field'`,
+			`DROP TABLE IF EXISTS player_locations`,
+		},
+	},
+	{
+		// Попадания по зверям на поле охоты, чтобы раненый зверь пережил перезапуск
+		version: 16,
+		name:    "session_wounds",
+		up: []string{
+			`ALTER TABLE player_sessions ADD COLUMN IF NOT EXISTS wounds TEXT NOT NULL DEFAULT 'null'`,
+		},
+		down: []string{
+			`ALTER TABLE player_sessions DROP COLUMN IF EXISTS wounds`,
+		},
+	},
+	{
+		// Сытость убывает со временем: запоминаем, до какого момента убывание уже учтено.
+		// Баффы от еды действуют до ends_at и переживают перезапуск.
+		version: 17,
+		name:    "satiety_decay_and_buffs",
+		up: []string{
+			`ALTER TABLE players ADD COLUMN IF NOT EXISTS satiety_updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()`,
+			`CREATE TABLE IF NOT EXISTS player_buffs (
+				player_id INTEGER NOT NULL REFERENCES players(id),
+				item_name VARCHAR(100) NOT NULL,
+				name VARCHAR(100) NOT NULL,
+				speed INTEGER NOT NULL DEFAULT 0,
+				yield INTEGER NOT NULL DEFAULT 0,
+				ends_at TIMESTAMPTZ NOT NULL,
+				PRIMARY KEY (player_id, item_name)
+			)`,
+		},
+		down: []string{
+			`DROP TABLE IF EXISTS player_buffs`,
+			`ALTER TABLE players DROP COLUMN IF EXISTS satiety_updated_at`,
+		},
+	},
+	{
+		// Начало учета сытости нового игрока задают игровые часы бота, а не часы базы.
+		// Существующие игроки уже получили значение при добавлении столбца.
+		version: 18,
+		name:    "satiety_updated_at_from_clock",
+		up: []string{
+			`ALTER TABLE players ALTER COLUMN satiety_updated_at DROP DEFAULT`,
+		},
+		down: []string{
+			`ALTER TABLE players ALTER COLUMN satiety_updated_at SET DEFAULT NOW()`,
+		},
+	},
+}
+
+// ensureMigrationsTable создает таблицу учета примененных миграций
+func (db *DB) ensureMigrationsTable() error {
+	_, err := db.conn.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name VARCHAR(100) NOT NULL,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+	)`)
+	return err
+}
+
+// appliedMigrations возвращает время применения по номеру версии
+func (db *DB) appliedMigrations() (map[int]time.Time, error) {
+	if err := db.ensureMigrationsTable(); err != nil {
+		return nil, err
+	}
+
+	rows, err := db.conn.Query(`SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+	return applied, rows.Err()
+}
+
+// MigrateUp применяет все еще не примененные миграции по порядку
+func (db *DB) MigrateUp() error {
+	applied, err := db.appliedMigrations()
+	if err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		if _, ok := applied[m.version]; ok {
+			continue
+		}
+
+		err := db.inTx(m.up, func(tx *sql.Tx) error {
+			_, err := tx.Exec(`INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`, m.version, m.name)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("migration %d_%s: %w", m.version, m.name, err)
+		}
+		log.Printf("Applied migration %d_%s", m.version, m.name)
+	}
+
+	return nil
+}
+
+// MigrateDown откатывает последнюю примененную миграцию
+func (db *DB) MigrateDown() error {
+	applied, err := db.appliedMigrations()
+	if err != nil {
+		return err
+	}
+
+	for i := len(migrations) - 1; i >= 0; i-- {
+		m := migrations[i]
+		if _, ok := applied[m.version]; !ok {
+			continue
+		}
+
+		err := db.inTx(m.down, func(tx *sql.Tx) error {
+			_, err := tx.Exec(`DELETE FROM schema_migrations WHERE version = $1`, m.version)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("rollback %d_%s: %w", m.version, m.name, err)
+		}
+		log.Printf("Rolled back migration %d_%s", m.version, m.name)
+		return nil
+	}
+
+	log.Println("No migrations to roll back")
+	return nil
+}
+
+// MigrationStatuses возвращает состояние всех известных миграций
+func (db *DB) MigrationStatuses() ([]MigrationStatus, error) {
+	applied, err := db.appliedMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(migrations))
+	for _, m := range migrations {
+		appliedAt, ok := applied[m.version]
+		statuses = append(statuses, MigrationStatus{Version: m.version, Name: m.name, Applied: ok, AppliedAt: appliedAt})
+	}
+	return statuses, nil
+}
+
+// inTx выполняет запросы и record в одной транзакции
+func (db *DB) inTx(queries []string, record func(tx *sql.Tx) error) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, query := range queries {
+		if _, err := tx.Exec(query); err != nil {
+			return err
+		}
+	}
+	if err := record(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
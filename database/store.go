@@ -0,0 +1,80 @@
+package database
+
+import (
+	"reborn_land/models"
+	"time"
+)
+
+// Store - операции с данными, которые используют обработчики бота.
+// Реализации: DB (PostgreSQL) и Memory (в памяти, для тестов).
+type Store interface {
+	// Игроки
+	PlayerExists(telegramID int64) (bool, error)
+	GetPlayer(telegramID int64) (*models.Player, error)
+	CreatePlayer(telegramID int64, name string, now time.Time) (*models.Player, error)
+	UpdatePlayerSatiety(playerID int, satietyChange int) error
+	DecayPlayerSatiety(playerID int, loss int, settledAt time.Time) error
+	UpdatePlayerExperience(playerID int, expGained int) error
+	UpdateSimpleHutBuilt(playerID int, built bool) error
+
+	// Инвентарь
+	GetPlayerInventory(playerID int) ([]models.InventoryItem, error)
+	GetItemQuantityInInventory(playerID int, itemName string) (int, error)
+	GetTool(playerID int, toolName string) (*models.InventoryItem, error)
+	GetToolItems(kind string) ([]models.Item, error)
+	EquipTool(playerID int, instanceID int) (*models.InventoryItem, error)
+	ApplyInventoryChange(playerID int, change models.InventoryChange) error
+	GetInventoryLedger(playerID int, limit int) ([]models.LedgerEntry, error)
+	GetEdibleItems() ([]models.Item, error)
+
+	// Баффы от еды
+	AddPlayerBuff(playerID int, buff models.Buff, now time.Time) error
+	GetPlayerBuffs(playerID int, now time.Time) ([]models.Buff, error)
+
+	// Рецепты
+	GetRecipe(key string) (*models.Recipe, error)
+	GetStationRecipes(station string) ([]models.Recipe, error)
+
+	// Печь и костер
+	GetFuels() ([]models.Item, error)
+	QueueFurnaceJob(playerID int, job models.FurnaceJob, change models.InventoryChange) (*models.FurnaceJob, error)
+	GetFurnaceJobs(playerID int) ([]models.FurnaceJob, error)
+	CollectFurnaceJobs(playerID int, now time.Time) ([]models.FurnaceJob, error)
+
+	// Локации
+	// Локации различаются ключом: "mine", "forest", "gathering", "hunting", "lake", "field"
+	GetOrCreateLocation(playerID int, location string) (*models.Location, error)
+	UpdateLocationExperience(playerID int, location string, expGained int) (bool, int, error)
+	SetLocationExhausted(playerID int, location string, exhausted bool) error
+
+	// Квесты
+	GetPlayerQuest(playerID int, questID int) (*models.Quest, error)
+	CreateQuest(playerID int, questID int, target int) error
+	UpdateQuestStatus(playerID int, questID int, status string) error
+	UpdateQuestProgress(playerID int, questID int, progress int) error
+	CompleteQuest(playerID int, questID int, experience int, reward models.InventoryChange) error
+
+	// Сессии, действия и кулдауны, переживающие перезапуск
+	SavePlayerRuntime(telegramID int64, sessions []models.LocationSession, actions []models.TimedAction, cooldowns []models.Cooldown) error
+	GetLocationSessions() ([]models.LocationSession, error)
+	GetTimedActions() ([]models.TimedAction, error)
+	GetCooldowns(now time.Time) ([]models.Cooldown, error)
+}
+
+// starterInventory - предметы, которые получает новый игрок
+var starterInventory = models.InventoryChange{
+	Reason: "start",
+	Grant: []models.ItemDelta{
+		{ItemName: "Простой лук", Quantity: 1, Durability: 100},
+		{ItemName: "Простой нож", Quantity: 1, Durability: 100},
+		{ItemName: "Простая кирка", Quantity: 1, Durability: 100},
+		{ItemName: "Простой топор", Quantity: 1, Durability: 100},
+		{ItemName: "Стрелы", Quantity: 100},
+		{ItemName: "Лесная ягода", Quantity: 10},
+	},
+}
+
+var (
+	_ Store = (*DB)(nil)
+	_ Store = (*Memory)(nil)
+)
@@ -0,0 +1,76 @@
+package dispatcher
+
+import (
+	"sync"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// queueSize - размер очереди каждого обработчика
+const queueSize = 100
+
+// Dispatcher распределяет обновления по пулу обработчиков.
+// Обновления одного игрока всегда попадают к одному и тому же обработчику
+// и выполняются по порядку, обновления разных игроков - параллельно.
+type Dispatcher struct {
+	handle func(update tgbotapi.Update)
+	queues []chan tgbotapi.Update
+	wg     sync.WaitGroup
+}
+
+// New запускает workers обработчиков, каждый из которых вызывает handle
+// для своей доли обновлений
+func New(workers int, handle func(update tgbotapi.Update)) *Dispatcher {
+	if workers < 1 {
+		workers = 1
+	}
+
+	d := &Dispatcher{
+		handle: handle,
+		queues: make([]chan tgbotapi.Update, workers),
+	}
+
+	for i := range d.queues {
+		d.queues[i] = make(chan tgbotapi.Update, queueSize)
+		d.wg.Add(1)
+		go d.work(d.queues[i])
+	}
+
+	return d
+}
+
+// Dispatch ставит обновление в очередь обработчика, отвечающего за отправителя.
+// Если очередь заполнена, вызов блокируется до освобождения места.
+func (d *Dispatcher) Dispatch(update tgbotapi.Update) {
+	d.queues[d.shard(update)] <- update
+}
+
+// Close прекращает приём обновлений и ждет, пока обработчики выполнят
+// всё, что уже стоит в очередях. После Close вызывать Dispatch нельзя.
+func (d *Dispatcher) Close() {
+	for _, queue := range d.queues {
+		close(queue)
+	}
+	d.wg.Wait()
+}
+
+func (d *Dispatcher) work(queue chan tgbotapi.Update) {
+	defer d.wg.Done()
+
+	for update := range queue {
+		d.handle(update)
+	}
+}
+
+// shard выбирает обработчик по ID отправителя (или чата, если отправителя нет)
+func (d *Dispatcher) shard(update tgbotapi.Update) int {
+	var key int64
+	if from := update.SentFrom(); from != nil {
+		key = from.ID
+	} else if chat := update.FromChat(); chat != nil {
+		key = chat.ID
+	}
+
+	// Отрицательные ID (группы) приводятся к uint64: -key переполняется для math.MinInt64
+	return int(uint64(key) % uint64(len(d.queues)))
+}
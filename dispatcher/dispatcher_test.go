@@ -0,0 +1,108 @@
+package dispatcher
+
+import (
+	"math"
+	"reborn_land/state"
+	"sync"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func update(userID int64, seq int) tgbotapi.Update {
+	return tgbotapi.Update{
+		UpdateID: seq,
+		Message: &tgbotapi.Message{
+			MessageID: seq,
+			From:      &tgbotapi.User{ID: userID},
+			Chat:      &tgbotapi.Chat{ID: userID},
+		},
+	}
+}
+
+// Обновления многих игроков от нескольких отправителей обрабатываются параллельно,
+// но обновления одного отправителя приходят к обработчику по порядку. Фоновые
+// горутины игроков одновременно меняют то же состояние через Manager.Do.
+func TestDispatcherUnderLoad(t *testing.T) {
+	const (
+		players   = 40
+		perPlayer = 200
+		workers   = 8
+	)
+
+	states := state.NewManager()
+	var mu sync.Mutex
+	seen := make(map[int64][]int)
+
+	d := New(workers, func(u tgbotapi.Update) {
+		id := u.SentFrom().ID
+		states.Do(id, func(p *state.Player) {
+			if p.Actions == nil {
+				t.Error("player state is not initialised")
+			}
+			p.Persisted += "."
+		})
+		mu.Lock()
+		seen[id] = append(seen[id], u.UpdateID)
+		mu.Unlock()
+	})
+
+	var wg sync.WaitGroup
+	for id := int64(1); id <= players; id++ {
+		// Обновления одного игрока отправляются по порядку, как их присылает Telegram
+		wg.Add(1)
+		go func(id int64) {
+			defer wg.Done()
+			for seq := 0; seq < perPlayer; seq++ {
+				d.Dispatch(update(id, seq))
+			}
+		}(id)
+
+		// Фоновые действия игрока (таймеры добычи, крафта) захватывают его состояние сами
+		wg.Add(1)
+		go func(id int64) {
+			defer wg.Done()
+			for i := 0; i < perPlayer; i++ {
+				p := states.Lock(id)
+				p.Persisted += "."
+				p.Unlock()
+			}
+		}(id)
+	}
+	wg.Wait()
+	d.Close()
+
+	for id := int64(1); id <= players; id++ {
+		updates := seen[id]
+		if len(updates) != perPlayer {
+			t.Fatalf("player %d: handled %d updates, want %d", id, len(updates), perPlayer)
+		}
+		for i, seq := range updates {
+			if seq != i {
+				t.Fatalf("player %d: update %d handled at position %d", id, seq, i)
+			}
+		}
+		states.Do(id, func(p *state.Player) {
+			if len(p.Persisted) != 2*perPlayer {
+				t.Errorf("player %d: %d state changes, want %d", id, len(p.Persisted), 2*perPlayer)
+			}
+		})
+	}
+}
+
+// Любой ID, включая отрицательные ID групп и крайние значения, попадает в существующую очередь
+// и всегда в одну и ту же
+func TestShardInRange(t *testing.T) {
+	d := New(7, func(tgbotapi.Update) {})
+	defer d.Close()
+
+	for _, id := range []int64{0, 1, -1, 6, -7, -1001234567890, math.MaxInt64, math.MinInt64, math.MinInt64 + 1} {
+		shard := d.shard(update(id, 0))
+		if shard < 0 || shard >= len(d.queues) {
+			t.Errorf("shard(%d) = %d, want within [0, %d)", id, shard, len(d.queues))
+		}
+		if again := d.shard(update(id, 1)); again != shard {
+			t.Errorf("shard(%d) changed from %d to %d", id, shard, again)
+		}
+	}
+}
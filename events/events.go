@@ -0,0 +1,153 @@
+// Package events - шина игровых событий внутри процесса.
+// Игровые действия публикуют события, а подсистемы (квесты, уведомления, статистика)
+// подписываются на нужные им типы событий.
+package events
+
+import (
+	"log"
+	"reflect"
+	"sync"
+)
+
+// Player - игрок, с которым произошло событие
+type Player struct {
+	TelegramID int64
+	PlayerID   int // ID игрока в базе
+	ChatID     int64
+}
+
+// ResourceGathered - ресурс добыт в локации
+type ResourceGathered struct {
+	Player
+	Location string // "mine", "forest", "gathering", "lake", "field"
+	Resource string
+	Quantity int
+}
+
+// AnimalHunted - добыча на охоте
+type AnimalHunted struct {
+	Player
+	Animal string
+}
+
+// ItemCrafted - предметы созданы по рецепту
+type ItemCrafted struct {
+	Player
+	RecipeKey string
+	Item      string
+	Quantity  int // Сколько предметов получено
+}
+
+// BuildingBuilt - постройка возведена
+type BuildingBuilt struct {
+	Player
+	Building string // Ключ рецепта постройки
+}
+
+// FoodEaten - игрок съел еду
+type FoodEaten struct {
+	Player
+	Food     string
+	Quantity int
+}
+
+// PageRead - игрок прочитал страницу лора
+type PageRead struct {
+	Player
+	Page int
+}
+
+// ToolBroken - прочность инструмента закончилась
+type ToolBroken struct {
+	Player
+	Tool string
+}
+
+// LevelUp - повышен уровень локации
+type LevelUp struct {
+	Player
+	Location string // "mine", "forest", "gathering", "hunting", "lake", "field"
+	Level    int
+}
+
+// QuestCompleted - квест выполнен и награда выдана
+type QuestCompleted struct {
+	Player
+	QuestID int
+}
+
+// SatietyChanged - изменилась сытость игрока
+type SatietyChanged struct {
+	Player
+	Delta int
+}
+
+type subscriber struct {
+	handle func(any)
+	async  bool
+}
+
+// Bus доставляет события подписчикам. Безопасна для использования из нескольких горутин.
+type Bus struct {
+	mu          sync.RWMutex
+	subscribers map[reflect.Type][]subscriber
+	pending     sync.WaitGroup
+}
+
+// New создает шину без подписчиков
+func New() *Bus {
+	return &Bus{subscribers: make(map[reflect.Type][]subscriber)}
+}
+
+// Subscribe подписывает fn на события типа E. Обработчик вызывается синхронно
+// в горутине публикации, до возврата из Publish.
+func Subscribe[E any](b *Bus, fn func(E)) {
+	b.add(typeOf[E](), subscriber{handle: func(e any) { fn(e.(E)) }})
+}
+
+// SubscribeAsync подписывает fn на события типа E. Обработчик вызывается в отдельной
+// горутине и не должен рассчитывать на блокировку игрока, удерживаемую публикующим кодом.
+func SubscribeAsync[E any](b *Bus, fn func(E)) {
+	b.add(typeOf[E](), subscriber{handle: func(e any) { fn(e.(E)) }, async: true})
+}
+
+// Publish доставляет событие всем подписчикам его типа в порядке подписки
+func Publish[E any](b *Bus, event E) {
+	b.mu.RLock()
+	subscribers := b.subscribers[typeOf[E]()]
+	b.mu.RUnlock()
+
+	for _, s := range subscribers {
+		if !s.async {
+			s.handle(event)
+			continue
+		}
+
+		b.pending.Add(1)
+		go func(s subscriber) {
+			defer b.pending.Done()
+			defer func() {
+				if r := recover(); r != nil {
+					log.Printf("Async subscriber for %T panicked: %v", event, r)
+				}
+			}()
+			s.handle(event)
+		}(s)
+	}
+}
+
+// Wait ждет завершения запущенных асинхронных обработчиков
+func (b *Bus) Wait() {
+	b.pending.Wait()
+}
+
+func (b *Bus) add(t reflect.Type, s subscriber) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.subscribers[t] = append(b.subscribers[t], s)
+}
+
+func typeOf[E any]() reflect.Type {
+	return reflect.TypeOf((*E)(nil)).Elem()
+}
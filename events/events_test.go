@@ -0,0 +1,131 @@
+package events
+
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// Синхронные подписчики вызываются до возврата из Publish в порядке подписки
+func TestSyncDeliveryOrder(t *testing.T) {
+	bus := New()
+	var got []string
+	for _, name := range []string{"first", "second", "third"} {
+		name := name
+		Subscribe(bus, func(e ItemCrafted) { got = append(got, name+":"+e.Item) })
+	}
+
+	Publish(bus, ItemCrafted{Item: "Березовый брус"})
+	Publish(bus, ItemCrafted{Item: "Простой топор"})
+
+	want := []string{
+		"first:Березовый брус", "second:Березовый брус", "third:Березовый брус",
+		"first:Простой топор", "second:Простой топор", "third:Простой топор",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("delivered %q, want %q", got, want)
+	}
+}
+
+// Асинхронные подписчики получают каждое событие, а Wait дожидается их всех
+func TestAsyncDeliveryAndWait(t *testing.T) {
+	bus := New()
+	release := make(chan struct{})
+	var delivered atomic.Int64
+	var sum atomic.Int64
+	SubscribeAsync(bus, func(e SatietyChanged) {
+		<-release
+		sum.Add(int64(e.Delta))
+		delivered.Add(1)
+	})
+
+	syncCalled := false
+	Subscribe(bus, func(e SatietyChanged) { syncCalled = true })
+
+	for i := 1; i <= 100; i++ {
+		Publish(bus, SatietyChanged{Delta: i})
+	}
+	// Publish не ждет асинхронных подписчиков
+	if !syncCalled {
+		t.Fatalf("sync subscriber was not called before Publish returned")
+	}
+	if n := delivered.Load(); n != 0 {
+		t.Fatalf("async subscribers finished before release: %d", n)
+	}
+
+	close(release)
+	bus.Wait()
+	if n := delivered.Load(); n != 100 {
+		t.Errorf("async deliveries after Wait = %d, want 100", n)
+	}
+	if s := sum.Load(); s != 5050 {
+		t.Errorf("sum of async deltas = %d, want 5050", s)
+	}
+}
+
+// Паника асинхронного подписчика не мешает остальным и не валит процесс
+func TestAsyncPanicIsIsolated(t *testing.T) {
+	bus := New()
+	var delivered atomic.Int64
+	SubscribeAsync(bus, func(e ToolBroken) { panic("broken subscriber") })
+	SubscribeAsync(bus, func(e ToolBroken) { delivered.Add(1) })
+
+	Publish(bus, ToolBroken{Tool: "Простой топор"})
+	Publish(bus, ToolBroken{Tool: "Простая кирка"})
+	bus.Wait()
+
+	if n := delivered.Load(); n != 2 {
+		t.Errorf("healthy subscriber deliveries = %d, want 2", n)
+	}
+}
+
+// События доставляются только подписчикам их типа, даже если поля совпадают
+func TestTypeBasedRouting(t *testing.T) {
+	bus := New()
+	var gathered, hunted, levels int
+	Subscribe(bus, func(e ResourceGathered) { gathered++ })
+	Subscribe(bus, func(e AnimalHunted) { hunted++ })
+	Subscribe(bus, func(e LevelUp) { levels++ })
+
+	player := Player{TelegramID: 1, PlayerID: 1, ChatID: 1}
+	Publish(bus, ResourceGathered{Player: player, Location: "mine", Resource: "Камень", Quantity: 1})
+	Publish(bus, ResourceGathered{Player: player, Location: "forest", Resource: "Береза", Quantity: 1})
+	Publish(bus, AnimalHunted{Player: player, Animal: "Кролик"})
+	Publish(bus, player)                   // у Player нет подписчиков
+	Publish(bus, PageRead{Player: player}) // и у PageRead тоже
+
+	if gathered != 2 || hunted != 1 || levels != 0 {
+		t.Errorf("gathered %d, hunted %d, level ups %d; want 2, 1, 0", gathered, hunted, levels)
+	}
+}
+
+// Подписка и публикация из разных горутин безопасны
+func TestConcurrentPublish(t *testing.T) {
+	bus := New()
+	var delivered atomic.Int64
+	Subscribe(bus, func(e FoodEaten) { delivered.Add(int64(e.Quantity)) })
+	SubscribeAsync(bus, func(e FoodEaten) { delivered.Add(int64(e.Quantity)) })
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				Publish(bus, FoodEaten{Quantity: 1})
+			}
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		Subscribe(bus, func(e QuestCompleted) {})
+	}()
+	wg.Wait()
+	bus.Wait()
+
+	if n := delivered.Load(); n != 2*20*50 {
+		t.Errorf("deliveries = %d, want %d", n, 2*20*50)
+	}
+}
@@ -0,0 +1,103 @@
+// Package fieldgen генерирует поля ресурсов для локаций (шахта, лес, сбор, охота, озеро, поле).
+package fieldgen
+
+import (
+	"math/rand"
+	"sync"
+)
+
+// Spec описывает поле локации
+type Spec struct {
+	Rows      int
+	Cols      int
+	Resources int      // Сколько клеток занято ресурсами
+	Kinds     []string // Возможные ресурсы, выбираются равновероятно; повтор вида повышает его шанс
+}
+
+// Generate заполняет поле, используя переданный генератор случайных чисел.
+// Ресурсы ставятся в случайные различные клетки, остальные клетки пустые.
+func Generate(rng *rand.Rand, spec Spec) [][]string {
+	field := make([][]string, spec.Rows)
+	for i := range field {
+		field[i] = make([]string, spec.Cols)
+	}
+	if len(spec.Kinds) == 0 {
+		return field
+	}
+
+	count := spec.Resources
+	if cells := spec.Rows * spec.Cols; count > cells {
+		count = cells
+	}
+
+	for _, cell := range rng.Perm(spec.Rows * spec.Cols)[:count] {
+		field[cell/spec.Cols][cell%spec.Cols] = spec.Kinds[rng.Intn(len(spec.Kinds))]
+	}
+
+	return field
+}
+
+// Seeded генерирует поле с заданным зерном: одинаковое зерно дает одинаковое поле.
+// Подходит для зерна на сессию.
+func Seeded(seed int64, spec Spec) [][]string {
+	return Generate(rand.New(rand.NewSource(seed)), spec)
+}
+
+// Generator выдает поля из последовательности случайных чисел с заданным зерном.
+// Безопасен для использования из нескольких горутин.
+type Generator struct {
+	mu        sync.Mutex
+	seed      int64
+	perPlayer bool
+	global    *rand.Rand
+	players   map[int64]*rand.Rand
+}
+
+// New создает генератор с общей для всех игроков последовательностью
+func New(seed int64) *Generator {
+	return &Generator{seed: seed, global: rand.New(rand.NewSource(seed))}
+}
+
+// NewPerPlayer создает генератор с отдельной последовательностью для каждого игрока.
+// Поля игрока зависят только от зерна и его ID, но не от действий других игроков.
+func NewPerPlayer(seed int64) *Generator {
+	return &Generator{seed: seed, perPlayer: true, players: make(map[int64]*rand.Rand)}
+}
+
+// Field генерирует следующее поле для игрока
+func (g *Generator) Field(playerID int64, spec Spec) [][]string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return Generate(g.rng(playerID), spec)
+}
+
+// Intn возвращает следующее случайное число из [0, n) для игрока: броски
+// шансов идут из той же последовательности, что и поля, и так же воспроизводимы.
+func (g *Generator) Intn(playerID int64, n int) int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.rng(playerID).Intn(n)
+}
+
+func (g *Generator) rng(playerID int64) *rand.Rand {
+	if !g.perPlayer {
+		return g.global
+	}
+
+	rng, exists := g.players[playerID]
+	if !exists {
+		rng = rand.New(rand.NewSource(g.seed ^ mix(playerID)))
+		g.players[playerID] = rng
+	}
+	return rng
+}
+
+// mix перемешивает биты ID, чтобы у соседних игроков не совпадали зерна по младшим битам
+func mix(x int64) int64 {
+	z := uint64(x) + 0x9e3779b97f4a7c15
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return int64(z ^ (z >> 31))
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"errors"
+	"reborn_land/catalog"
+	"reborn_land/database"
+	"reborn_land/events"
+	"reborn_land/fieldgen"
+	"testing"
+	"time"
+)
+
+// hutFailingStore - хранилище, в котором не удается отметить хижину построенной
+type hutFailingStore struct {
+	*database.Memory
+}
+
+func (hutFailingStore) UpdateSimpleHutBuilt(playerID int, built bool) error {
+	return errors.New("hut update failed")
+}
+
+// Если постройку не удалось отметить, ингредиенты возвращаются, сытость не списывается,
+// а сообщения и события о постройке нет
+func TestBuildingFailureRefundsIngredients(t *testing.T) {
+	s := newScenario(t)
+	s.h = New(s.bot, hutFailingStore{s.db}, s.clk, fieldgen.New(1), catalog.DefaultQuests(s.items, s.recipes), catalog.DefaultLoot(s.items), catalog.DefaultSurvival(s.items))
+	const u = int64(4001)
+	player := s.register(u, "Строитель")
+
+	built := false
+	events.Subscribe(s.h.Events(), func(e events.BuildingBuilt) { built = true })
+
+	recipe, _ := s.db.GetRecipe("simple_hut")
+	before := make(map[string]int)
+	for _, ingredient := range recipe.Ingredients {
+		s.grant(player.ID, ingredient.ItemName, ingredient.Quantity)
+		before[ingredient.ItemName] = s.quantity(player.ID, ingredient.ItemName)
+	}
+	satiety, _ := s.db.GetPlayer(u)
+
+	s.press(u, 1, "craft_simple_hut")
+	if !s.busy(u, "crafting") {
+		last, _ := s.bot.Last(u)
+		t.Fatalf("building did not start: %q", last.Text)
+	}
+	s.waitDone(u, "crafting", time.Second)
+
+	after, _ := s.db.GetPlayer(u)
+	if after.SimpleHutBuilt {
+		t.Errorf("hut is marked built")
+	}
+	if after.Satiety != satiety.Satiety {
+		t.Errorf("satiety = %d, want %d", after.Satiety, satiety.Satiety)
+	}
+	for item, want := range before {
+		if got := s.quantity(player.ID, item); got != want {
+			t.Errorf("%s after refund = %d, want %d", item, got, want)
+		}
+	}
+	if s.sent(u, "Строительство \"Простая хижина\" завершено") {
+		t.Errorf("building completion message was sent")
+	}
+	if !s.sent(u, "Ингредиенты возвращены в инвентарь.") {
+		t.Errorf("no refund message")
+	}
+	if built {
+		t.Errorf("BuildingBuilt was published")
+	}
+}
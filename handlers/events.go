@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"reborn_land/catalog"
+	"reborn_land/events"
+	"reborn_land/quests"
+	"strconv"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// subscribe подключает подсистемы обработчиков к шине событий.
+// Подписчики синхронные: события публикуются под блокировкой игрока,
+// поэтому подписчики могут работать с его данными.
+func (h *BotHandlers) subscribe() {
+	// Квесты
+	events.Subscribe(h.bus, func(e events.ResourceGathered) {
+		h.progressQuests(e.Player, quests.Event{Type: catalog.ObjectiveGather, Target: e.Resource, Quantity: e.Quantity})
+	})
+	events.Subscribe(h.bus, func(e events.ItemCrafted) {
+		h.progressQuests(e.Player, quests.Event{Type: catalog.ObjectiveCraft, Target: e.Item, Quantity: e.Quantity})
+	})
+	events.Subscribe(h.bus, func(e events.BuildingBuilt) {
+		h.progressQuests(e.Player, quests.Event{Type: catalog.ObjectiveBuild, Target: e.Building, Quantity: 1})
+	})
+	events.Subscribe(h.bus, func(e events.FoodEaten) {
+		h.progressQuests(e.Player, quests.Event{Type: catalog.ObjectiveEat, Target: e.Food, Quantity: e.Quantity})
+	})
+	events.Subscribe(h.bus, func(e events.AnimalHunted) {
+		h.progressQuests(e.Player, quests.Event{Type: catalog.ObjectiveHunt, Target: e.Animal, Quantity: 1})
+	})
+	events.Subscribe(h.bus, func(e events.PageRead) {
+		h.progressQuests(e.Player, quests.Event{Type: catalog.ObjectiveReadPage, Target: strconv.Itoa(e.Page), Quantity: 1})
+	})
+
+	// Уведомления
+	events.Subscribe(h.bus, h.notifyQuestCompleted)
+	events.Subscribe(h.bus, h.notifyLevelUp)
+}
+
+// playerRef описывает игрока для событий
+func playerRef(telegramID int64, playerID int, chatID int64) events.Player {
+	return events.Player{TelegramID: telegramID, PlayerID: playerID, ChatID: chatID}
+}
+
+// changeSatiety меняет сытость игрока и публикует SatietyChanged
+func (h *BotHandlers) changeSatiety(p events.Player, delta int) error {
+	if err := h.db.UpdatePlayerSatiety(p.PlayerID, delta); err != nil {
+		return err
+	}
+	events.Publish(h.bus, events.SatietyChanged{Player: p, Delta: delta})
+	return nil
+}
+
+// progressQuests передает событие движку квестов и публикует выполненные квесты
+func (h *BotHandlers) progressQuests(p events.Player, event quests.Event) {
+	completed, err := h.quests.Handle(p.PlayerID, event)
+	if err != nil {
+		log.Printf("Error updating quests for %s event: %v", event.Type, err)
+	}
+
+	for _, quest := range completed {
+		events.Publish(h.bus, events.QuestCompleted{Player: p, QuestID: quest.ID})
+	}
+}
+
+func (h *BotHandlers) notifyQuestCompleted(e events.QuestCompleted) {
+	quest, ok := h.quests.Get(e.QuestID)
+	if !ok {
+		return
+	}
+
+	msg := tgbotapi.NewMessage(e.ChatID, questCompleteText(quest))
+	h.sendMessage(msg)
+}
+
+func (h *BotHandlers) notifyLevelUp(e events.LevelUp) {
+	def, ok := locationByKey(e.Location)
+	if !ok {
+		return
+	}
+	levelUpText := fmt.Sprintf("🎉 Поздравляем! Уровень %s повышен до %d уровня!", def.Genitive, e.Level)
+	levelUpMsg := tgbotapi.NewMessage(e.ChatID, levelUpText)
+	h.sendMessage(levelUpMsg)
+}
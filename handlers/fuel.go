@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"reborn_land/models"
+)
+
+// fuelName - название топлива в сообщениях о нехватке ингредиентов
+const fuelName = "Топливо"
+
+// fuelStock - топливо в инвентаре игрока
+type fuelStock struct {
+	Item     models.Item
+	Quantity int
+}
+
+// playerFuel возвращает топливо игрока от самого жаркого и сумму единиц топлива.
+// reserved - предметы, которые уйдут в рецепт как ингредиенты и не горят.
+func (h *BotHandlers) playerFuel(playerID int, reserved map[string]int) ([]fuelStock, int) {
+	fuels, err := h.db.GetFuels()
+	if err != nil {
+		log.Printf("Error getting fuels: %v", err)
+		return nil, 0
+	}
+
+	var stock []fuelStock
+	units := 0
+	for _, fuel := range fuels {
+		quantity, err := h.db.GetItemQuantityInInventory(playerID, fuel.Name)
+		if err != nil {
+			log.Printf("Error getting inventory quantity: %v", err)
+			continue
+		}
+		quantity -= reserved[fuel.Name]
+		if quantity <= 0 {
+			continue
+		}
+		stock = append(stock, fuelStock{Item: fuel, Quantity: quantity})
+		units += quantity * fuel.BurnValue
+	}
+	return stock, units
+}
+
+// burnPlan выбирает, какое топливо сжечь ради need единиц. Самое жаркое топливо
+// берется первым; если оставшегося мелкого топлива не хватит на остаток,
+// добавляется еще один жаркий предмет. Возвращает nil, если топлива не хватает.
+func burnPlan(stock []fuelStock, need int) map[string]int {
+	// smaller[i] - сколько единиц дает все топливо после i-го
+	smaller := make([]int, len(stock)+1)
+	for i := len(stock) - 1; i >= 0; i-- {
+		smaller[i] = smaller[i+1] + stock[i].Quantity*stock[i].Item.BurnValue
+	}
+
+	burned := make(map[string]int)
+	for i, fuel := range stock {
+		if need <= 0 {
+			break
+		}
+		n := min(fuel.Quantity, need/fuel.Item.BurnValue)
+		if rest := need - n*fuel.Item.BurnValue; rest > 0 && rest > smaller[i+1] && n < fuel.Quantity {
+			n++
+		}
+		if n > 0 {
+			burned[fuel.Item.Name] = n
+			need -= n * fuel.Item.BurnValue
+		}
+	}
+
+	if need > 0 {
+		return nil
+	}
+	return burned
+}
+
+// burnFuel добавляет в change сжигание топлива на need единиц. Предметы, которые
+// change уже списывает, не горят. Возвращает текст о сожженном топливе и false,
+// если топлива не хватает.
+func (h *BotHandlers) burnFuel(playerID int, change *models.InventoryChange, need int) (string, bool) {
+	if need <= 0 {
+		return "", true
+	}
+
+	reserved := make(map[string]int)
+	for _, delta := range change.Consume {
+		reserved[delta.ItemName] += delta.Quantity
+	}
+
+	stock, _ := h.playerFuel(playerID, reserved)
+	burned := burnPlan(stock, need)
+	if burned == nil {
+		return "", false
+	}
+
+	burnedText := "\n\nСожжено топлива:"
+	for _, fuel := range stock {
+		if n := burned[fuel.Item.Name]; n > 0 {
+			change.Consume = append(change.Consume, models.ItemDelta{ItemName: fuel.Item.Name, Quantity: n})
+			burnedText += fmt.Sprintf("\n%s x%d", fuel.Item.Name, n)
+		}
+	}
+	return burnedText, true
+}
+
+// fuelText перечисляет топливо и его жар
+func fuelText(stock []fuelStock, units int) string {
+	if len(stock) == 0 {
+		return "🔥 Топливо: нет. Подойдут уголь или дрова."
+	}
+
+	text := fmt.Sprintf("🔥 Топливо: %d ед.", units)
+	for _, fuel := range stock {
+		text += fmt.Sprintf("\n%s - %d шт. (%d ед. за штуку)", fuel.Item.Name, fuel.Quantity, fuel.Item.BurnValue)
+	}
+	return text
+}
@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"reborn_land/catalog"
+	"reborn_land/database"
+	"reborn_land/events"
+	"reborn_land/models"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// maxFurnaceJobs - сколько партий может стоять в очереди печи
+const maxFurnaceJobs = 5
+
+func (h *BotHandlers) handleFurnace(message *tgbotapi.Message) {
+	player, err := h.db.GetPlayer(message.From.ID)
+	if err != nil {
+		log.Printf("Error getting player: %v", err)
+		msg := tgbotapi.NewMessage(message.Chat.ID, "Сначала зарегистрируйтесь с помощью команды /start")
+		h.sendMessage(msg)
+		return
+	}
+
+	recipes, err := h.db.GetStationRecipes(catalog.StationFurnace)
+	if err != nil {
+		log.Printf("Error getting %s recipes: %v", catalog.StationFurnace, err)
+		msg := tgbotapi.NewMessage(message.Chat.ID, "Произошла ошибка. Попробуйте позже.")
+		h.sendMessage(msg)
+		return
+	}
+
+	furnaceText := "🧱 Доступные предметы для плавки:\n"
+	for _, recipe := range recipes {
+		furnaceText += fmt.Sprintf("\n%s — /create_%s", recipe.ItemName, recipe.Key)
+	}
+
+	stock, units := h.playerFuel(player.ID, nil)
+	furnaceText += "\n\n" + fuelText(stock, units)
+
+	jobs, err := h.db.GetFurnaceJobs(player.ID)
+	if err != nil {
+		log.Printf("Error getting furnace jobs: %v", err)
+	}
+
+	msg := tgbotapi.NewMessage(message.Chat.ID, furnaceText+"\n\n"+h.furnaceQueueText(jobs))
+	if h.anyReady(jobs) {
+		msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("Забрать готовое 📦", "furnace_collect"),
+			),
+		)
+	}
+	h.sendMessage(msg)
+}
+
+// furnaceQueueText описывает очередь печи
+func (h *BotHandlers) furnaceQueueText(jobs []models.FurnaceJob) string {
+	if len(jobs) == 0 {
+		return fmt.Sprintf("📦 Очередь пуста (0/%d)", maxFurnaceJobs)
+	}
+
+	now := h.clock.Now()
+	text := fmt.Sprintf("📦 Очередь (%d/%d):", len(jobs), maxFurnaceJobs)
+	for i, job := range jobs {
+		if job.Ready(now) {
+			text += fmt.Sprintf("\n%d. %s x%d — ✅ готово", i+1, job.ItemName, job.Quantity)
+		} else {
+			text += fmt.Sprintf("\n%d. %s x%d — ⏳ %d сек.", i+1, job.ItemName, job.Quantity, int(job.EndsAt.Sub(now).Seconds()))
+		}
+	}
+	return text
+}
+
+func (h *BotHandlers) anyReady(jobs []models.FurnaceJob) bool {
+	for _, job := range jobs {
+		if job.Ready(h.clock.Now()) {
+			return true
+		}
+	}
+	return false
+}
+
+// queueSmelting списывает ингредиенты и топливо и ставит партию в очередь печи.
+// Партия готовится в фоне, пока игрок занят другими делами.
+func (h *BotHandlers) queueSmelting(userID int64, chatID int64, recipe models.Recipe, quantity int) {
+	player, err := h.db.GetPlayer(userID)
+	if err != nil {
+		log.Printf("Error getting player: %v", err)
+		return
+	}
+
+	jobs, err := h.db.GetFurnaceJobs(player.ID)
+	if err != nil {
+		log.Printf("Error getting furnace jobs: %v", err)
+		msg := tgbotapi.NewMessage(chatID, "Произошла ошибка. Попробуйте позже.")
+		h.sendMessage(msg)
+		return
+	}
+	if len(jobs) >= maxFurnaceJobs {
+		msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("Очередь печи заполнена (%d/%d). Забери готовое или дождись окончания плавки.", len(jobs), maxFurnaceJobs))
+		h.sendMessage(msg)
+		return
+	}
+
+	// Загрузка печи отнимает сытость, голодный игрок на это не способен.
+	// Сама плавка идет без игрока, поэтому голод и баффы ее не ускоряют и не замедляют.
+	if recipe.SatietyCost > 0 && !h.survival.CanWork(player.Satiety) {
+		msg := tgbotapi.NewMessage(chatID, starvingText)
+		h.sendMessage(msg)
+		return
+	}
+
+	change := models.InventoryChange{Reason: "furnace:" + recipe.Key}
+	for _, ingredient := range recipe.Ingredients {
+		change.Consume = append(change.Consume, models.ItemDelta{ItemName: ingredient.ItemName, Quantity: ingredient.Quantity * quantity})
+	}
+	burnedText, ok := h.burnFuel(player.ID, &change, recipe.Fuel*quantity)
+	if !ok {
+		msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("Недостаточно топлива: нужно %d ед.", recipe.Fuel*quantity))
+		h.sendMessage(msg)
+		return
+	}
+
+	// Партии идут одна за другой: новая начинается после последней в очереди
+	startsAt := h.clock.Now()
+	if len(jobs) > 0 && jobs[len(jobs)-1].EndsAt.After(startsAt) {
+		startsAt = jobs[len(jobs)-1].EndsAt
+	}
+	job := models.FurnaceJob{
+		RecipeKey: recipe.Key, ItemName: recipe.ItemName, Quantity: recipe.OutputQuantity * quantity,
+		StartedAt: startsAt, EndsAt: startsAt.Add(time.Duration(recipe.CraftTime*quantity) * time.Second),
+	}
+
+	queued, err := h.db.QueueFurnaceJob(player.ID, job, change)
+	var insufficient *database.InsufficientItemError
+	if errors.As(err, &insufficient) {
+		msg := tgbotapi.NewMessage(chatID, fmt.Sprintf(`Недостаточно предмета "%s".`, insufficient.ItemName))
+		h.sendMessage(msg)
+		return
+	}
+	if err != nil {
+		log.Printf("Error queueing furnace job %s: %v", recipe.Key, err)
+		msg := tgbotapi.NewMessage(chatID, "Произошла ошибка при загрузке печи.")
+		h.sendMessage(msg)
+		return
+	}
+
+	if recipe.SatietyCost > 0 {
+		if err := h.changeSatiety(playerRef(userID, player.ID, chatID), -recipe.SatietyCost*quantity); err != nil {
+			log.Printf("Error updating player satiety: %v", err)
+		}
+	}
+
+	queuedText := fmt.Sprintf(`🧱 Партия "%s" x%d поставлена в печь.
+Будет готова через %d сек. Забрать ее можно в меню печи.`, queued.ItemName, queued.Quantity, int(queued.EndsAt.Sub(h.clock.Now()).Seconds()))
+	queuedText += burnedText
+	msg := tgbotapi.NewMessage(chatID, queuedText)
+	h.sendMessage(msg)
+}
+
+// handleFurnaceCollect выдает игроку все готовые партии печи
+func (h *BotHandlers) handleFurnaceCollect(userID int64, chatID int64, callbackID string) {
+	player, err := h.db.GetPlayer(userID)
+	if err != nil {
+		log.Printf("Error getting player: %v", err)
+		callbackConfig := tgbotapi.NewCallback(callbackID, "Ошибка получения данных игрока")
+		h.requestAPI(callbackConfig)
+		return
+	}
+
+	jobs, err := h.db.CollectFurnaceJobs(player.ID, h.clock.Now())
+	if err != nil {
+		log.Printf("Error collecting furnace jobs: %v", err)
+		callbackConfig := tgbotapi.NewCallback(callbackID, "Произошла ошибка. Попробуйте позже.")
+		h.requestAPI(callbackConfig)
+		return
+	}
+	if len(jobs) == 0 {
+		callbackConfig := tgbotapi.NewCallback(callbackID, "В печи пока ничего не готово")
+		h.requestAPI(callbackConfig)
+		return
+	}
+
+	callbackConfig := tgbotapi.NewCallback(callbackID, "")
+	h.requestAPI(callbackConfig)
+
+	collectText := "✅ Из печи получено:"
+	for _, job := range jobs {
+		collectText += fmt.Sprintf("\n%s x%d", job.ItemName, job.Quantity)
+	}
+	msg := tgbotapi.NewMessage(chatID, collectText)
+	h.sendMessage(msg)
+
+	collector := playerRef(userID, player.ID, chatID)
+	for _, job := range jobs {
+		events.Publish(h.bus, events.ItemCrafted{Player: collector, RecipeKey: job.RecipeKey, Item: job.ItemName, Quantity: job.Quantity})
+	}
+}
@@ -1,10 +1,19 @@
 package handlers
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"reborn_land/catalog"
+	"reborn_land/clock"
 	"reborn_land/database"
+	"reborn_land/events"
+	"reborn_land/fieldgen"
 	"reborn_land/models"
+	"reborn_land/quests"
+	"reborn_land/state"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -13,53 +22,62 @@ import (
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
-type BotHandlers struct {
-	bot                     *tgbotapi.BotAPI
-	db                      *database.DB
-	waitingForName          map[int64]bool
-	waitingForCraftQuantity map[int64]string // Ожидание количества для крафта (значение - название предмета)
-	mineSessions            map[int64]*models.MineSession
-	forestSessions          map[int64]*models.ForestSession
-	gatheringSessions       map[int64]*models.GatheringSession
-	huntingSessions         map[int64]*models.HuntingSession
-	miningTimers            map[int64]*time.Timer
-	choppingTimers          map[int64]*time.Timer
-	gatheringTimers         map[int64]*time.Timer
-	huntingTimers           map[int64]*time.Timer
-	craftingTimers          map[int64]*time.Timer // Таймеры для крафта
-	mineCooldowns           map[int64]time.Time   // Время окончания кулдауна шахты
-	forestCooldowns         map[int64]time.Time   // Время окончания кулдауна леса
-	gatheringCooldowns      map[int64]time.Time   // Время окончания кулдауна сбора
-	huntingCooldowns        map[int64]time.Time   // Время окончания кулдауна охоты
-	playerLocation          map[int64]string      // Текущее местоположение игрока
-	restingTimers           map[int64]*time.Timer // Таймеры для отдыха
+// Messenger - часть Telegram клиента, через которую обработчики отправляют сообщения.
+// Ей удовлетворяет *tgbotapi.BotAPI, в тестах - telegramtest.Fake.
+type Messenger interface {
+	Send(c tgbotapi.Chattable) (tgbotapi.Message, error)
+	Request(c tgbotapi.Chattable) (*tgbotapi.APIResponse, error)
 }
 
-func New(bot *tgbotapi.BotAPI, db *database.DB) *BotHandlers {
-	return &BotHandlers{
-		bot:                     bot,
-		db:                      db,
-		waitingForName:          make(map[int64]bool),
-		waitingForCraftQuantity: make(map[int64]string),
-		mineSessions:            make(map[int64]*models.MineSession),
-		forestSessions:          make(map[int64]*models.ForestSession),
-		gatheringSessions:       make(map[int64]*models.GatheringSession),
-		huntingSessions:         make(map[int64]*models.HuntingSession),
-		miningTimers:            make(map[int64]*time.Timer),
-		choppingTimers:          make(map[int64]*time.Timer),
-		gatheringTimers:         make(map[int64]*time.Timer),
-		huntingTimers:           make(map[int64]*time.Timer),
-		craftingTimers:          make(map[int64]*time.Timer),
-		mineCooldowns:           make(map[int64]time.Time),
-		forestCooldowns:         make(map[int64]time.Time),
-		gatheringCooldowns:      make(map[int64]time.Time),
-		huntingCooldowns:        make(map[int64]time.Time),
-		playerLocation:          make(map[int64]string),
-		restingTimers:           make(map[int64]*time.Timer),
-	}
+type BotHandlers struct {
+	bot      Messenger
+	db       database.Store
+	states   *state.Manager // Сессии, таймеры и кулдауны игроков
+	clock    clock.Clock    // Время для таймеров игровых действий
+	fields   *fieldgen.Generator
+	quests   *quests.Engine    // Продвигает квесты по игровым событиям
+	loot     *catalog.Loot     // Таблицы добычи ресурсов локаций
+	survival *catalog.Survival // Правила сытости, с которыми сверяются все действия
+	bus      *events.Bus       // Игровые события для квестов, уведомлений и других подсистем
+}
+
+func New(bot Messenger, db database.Store, clk clock.Clock, fields *fieldgen.Generator, questBook *catalog.Quests, loot *catalog.Loot, survival *catalog.Survival) *BotHandlers {
+	h := &BotHandlers{
+		bot:      bot,
+		db:       db,
+		states:   state.NewManager(),
+		clock:    clk,
+		fields:   fields,
+		quests:   quests.New(db, questBook),
+		loot:     loot,
+		survival: survival,
+		bus:      events.New(),
+	}
+	checkLoot(loot)
+	checkSurvival(survival)
+	h.subscribe()
+	return h
+}
+
+// Events возвращает шину игровых событий, на которую могут подписаться другие подсистемы
+func (h *BotHandlers) Events() *events.Bus {
+	return h.bus
 }
 
 func (h *BotHandlers) HandleUpdate(update tgbotapi.Update) {
+	// Обработка обновления игрока сериализуется с его фоновыми действиями
+	if from := update.SentFrom(); from != nil {
+		unlock := h.lockPlayer(from.ID)
+		defer unlock()
+
+		// Сытость убывает со временем: учитываем это до того, как игрок что-то сделает
+		chatID := from.ID
+		if chat := update.FromChat(); chat != nil {
+			chatID = chat.ID
+		}
+		h.settleSatiety(from.ID, chatID)
+	}
+
 	if update.Message != nil {
 		h.handleMessage(update.Message)
 	}
@@ -68,30 +86,144 @@ func (h *BotHandlers) HandleUpdate(update tgbotapi.Update) {
 	}
 }
 
+// playerState возвращает состояние игрока. Вызывающий код должен удерживать
+// блокировку игрока: HandleUpdate и фоновые горутины захватывают её сами.
+func (h *BotHandlers) playerState(userID int64) *state.Player {
+	return h.states.Get(userID)
+}
+
+// lockPlayer захватывает состояние игрока и возвращает функцию освобождения.
+// Перед освобождением изменившиеся сессии, действия и кулдауны сохраняются в базу,
+// чтобы после перезапуска бота их можно было восстановить.
+func (h *BotHandlers) lockPlayer(userID int64) func() {
+	player := h.states.Lock(userID)
+	return func() {
+		defer player.Unlock()
+
+		snapshot := player.Snapshot(userID, h.clock.Now())
+		data, err := json.Marshal(snapshot)
+		if err != nil {
+			log.Printf("Error encoding player %d state: %v", userID, err)
+			return
+		}
+		if string(data) == player.Persisted {
+			return
+		}
+
+		err = h.db.SavePlayerRuntime(userID, snapshot.Sessions, snapshot.Actions, snapshot.Cooldowns)
+		if err != nil {
+			log.Printf("Error saving player %d state: %v", userID, err)
+			return
+		}
+		player.Persisted = string(data)
+	}
+}
+
+// Restore загружает из базы сессии, действия и кулдауны игроков, сохраненные до
+// перезапуска бота, и возобновляет действия. Просроченные действия завершаются
+// на первом тике, прогресс-сообщения продолжают редактироваться.
+func (h *BotHandlers) Restore() error {
+	sessions, err := h.db.GetLocationSessions()
+	if err != nil {
+		return err
+	}
+	cooldowns, err := h.db.GetCooldowns(h.clock.Now())
+	if err != nil {
+		return err
+	}
+	actions, err := h.db.GetTimedActions()
+	if err != nil {
+		return err
+	}
+
+	for _, session := range sessions {
+		h.states.Do(session.PlayerID, func(p *state.Player) {
+			p.RestoreSession(session)
+		})
+	}
+	for _, cooldown := range cooldowns {
+		h.states.Do(cooldown.PlayerID, func(p *state.Player) {
+			p.RestoreCooldown(cooldown)
+		})
+	}
+	for _, action := range actions {
+		h.states.Do(action.PlayerID, func(p *state.Player) {
+			p.RestoreAction(action)
+		})
+		h.resumeAction(action)
+	}
+
+	// Запоминаем восстановленное состояние как уже сохраненное
+	restored := make(map[int64]bool)
+	for _, session := range sessions {
+		restored[session.PlayerID] = true
+	}
+	for _, cooldown := range cooldowns {
+		restored[cooldown.PlayerID] = true
+	}
+	for _, action := range actions {
+		restored[action.PlayerID] = true
+	}
+	for playerID := range restored {
+		h.states.Do(playerID, func(p *state.Player) {
+			data, err := json.Marshal(p.Snapshot(playerID, h.clock.Now()))
+			if err == nil {
+				p.Persisted = string(data)
+			}
+		})
+	}
+
+	log.Printf("Restored %d sessions, %d actions, %d cooldowns", len(sessions), len(actions), len(cooldowns))
+	return nil
+}
+
+// resumeAction заново запускает горутину прогресса для восстановленного действия
+func (h *BotHandlers) resumeAction(a models.TimedAction) {
+	if def, ok := locationByAction(a.Kind); ok {
+		go h.updateLocationProgress(def, a)
+		return
+	}
+
+	switch a.Kind {
+	case "crafting":
+		// Для крафта в ItemName хранится ключ рецепта
+		recipe, err := h.db.GetRecipe(a.ItemName)
+		if err != nil || recipe == nil {
+			log.Printf("Recipe %q for player %d crafting not found: %v", a.ItemName, a.PlayerID, err)
+			return
+		}
+		go h.updateCraftingProgress(a.PlayerID, a.ChatID, a.MessageID, *recipe, a.Quantity, a.Duration(), a.StartedAt)
+	case "resting":
+		go h.runRest(a.PlayerID, a.ChatID, a.MessageID, a.StartedAt, a.EndsAt)
+	default:
+		log.Printf("Unknown action kind %q for player %d", a.Kind, a.PlayerID)
+	}
+}
+
 func (h *BotHandlers) handleMessage(message *tgbotapi.Message) {
 	userID := message.From.ID
 
 	// Проверяем, не отдыхает ли игрок
-	if _, exists := h.restingTimers[userID]; exists {
+	if h.playerState(userID).Resting != nil {
 		msg := tgbotapi.NewMessage(message.Chat.ID, "Нельзя совершить действие пока не завершен отдых.")
 		h.sendMessage(msg)
 		return
 	}
 
 	// Проверяем, ждем ли мы от пользователя имя
-	if h.waitingForName[userID] {
+	if h.playerState(userID).WaitingForName {
 		h.handleNameInput(message)
 		return
 	}
 
 	// Проверяем, ждем ли мы количество для крафта
-	if itemName, exists := h.waitingForCraftQuantity[userID]; exists {
-		h.handleCraftQuantityInput(message, itemName)
+	if recipeKey := h.playerState(userID).WaitingForCraftQuantity; recipeKey != "" {
+		h.handleCraftQuantityInput(message, recipeKey)
 		return
 	}
 
 	// Проверяем, идет ли крафт
-	if _, exists := h.craftingTimers[userID]; exists {
+	if h.playerState(userID).Crafting != nil {
 		msg := tgbotapi.NewMessage(message.Chat.ID, "Нельзя совершать действия пока идет создание предметов.")
 		h.sendMessage(msg)
 		return
@@ -118,38 +250,10 @@ func (h *BotHandlers) handleMessage(message *tgbotapi.Message) {
 		h.handleCampfire(message)
 	case "◀️ Назад":
 		h.handleBack(message)
-	case "⛏ Шахта":
-		h.handleMine(message)
-	case "🌾 Поле":
-		h.handleField(message)
-	case "🎣 Озеро":
-		h.handleLake(message)
 	case "🏞 Лес":
 		h.handleForest(message)
-	case "/create_axe":
-		h.handleCreateAxe(message)
-	case "/create_pickaxe":
-		h.handleCreatePickaxe(message)
-	case "/create_bow":
-		h.handleCreateBow(message)
-	case "/create_arrows":
-		h.handleCreateArrows(message)
-	case "/create_knife":
-		h.handleCreateKnife(message)
-	case "/create_fishing_rod":
-		h.handleCreateFishingRod(message)
-	case "/create_birch_plank":
-		h.handleCreateBirchPlank(message)
-	case "/create_simple_hut":
-		h.handleCreateSimpleHut(message)
 	case "/eat":
 		h.handleEat(message)
-	case "🎯 Охота":
-		h.handleHunting(message)
-	case "🌿 Сбор":
-		h.handleForestGathering(message)
-	case "🪓 Рубка":
-		h.handleChopping(message)
 	case "📖 Лор":
 		h.handleLore(message)
 	case "🗓️ Ежедневные":
@@ -183,6 +287,22 @@ func (h *BotHandlers) handleMessage(message *tgbotapi.Message) {
 	case "/rest":
 		h.handleRest(message)
 	default:
+		// Локации с полем ресурсов открываются своими кнопками
+		if def, ok := locationByButton(message.Text); ok {
+			h.handleLocation(message, def)
+			return
+		}
+		// Все рецепты создаются через /create_<ключ рецепта>
+		if strings.HasPrefix(message.Text, "/create_") {
+			h.handleCreate(message, strings.TrimPrefix(message.Text, "/create_"))
+			return
+		}
+		// Экземпляр инструмента выбирается через /equip_<ID записи инвентаря>
+		if strings.HasPrefix(message.Text, "/equip_") {
+			h.handleEquip(message, strings.TrimPrefix(message.Text, "/equip_"))
+			return
+		}
+
 		// Неизвестная команда
 		msg := tgbotapi.NewMessage(message.Chat.ID, "Неизвестная команда. Используйте /start для начала игры.")
 		h.sendMessage(msg)
@@ -238,7 +358,7 @@ func (h *BotHandlers) startRegistration(message *tgbotapi.Message) {
 	// Запускаем последовательность сообщений с задержками
 	go func() {
 		// Ждем 2 секунды и отправляем второе сообщение
-		time.Sleep(2 * time.Second)
+		h.clock.Sleep(2 * time.Second)
 
 		secondText := `🧭 Что тебя ждёт:
 🪵 Добыча ресурсов (дерево, камень, пища)
@@ -252,7 +372,7 @@ func (h *BotHandlers) startRegistration(message *tgbotapi.Message) {
 		h.sendMessage(msg2)
 
 		// Ждем еще 2 секунды и отправляем третье сообщение
-		time.Sleep(2 * time.Second)
+		h.clock.Sleep(2 * time.Second)
 
 		thirdText := `Мир пал — не в огне и не в крови,
 а в молчании. Цивилизации исчезли, города заросли, 
@@ -274,7 +394,9 @@ func (h *BotHandlers) startRegistration(message *tgbotapi.Message) {
 		h.sendMessage(nameMsg)
 
 		// Отмечаем, что ждем имя от этого пользователя
-		h.waitingForName[message.From.ID] = true
+		h.states.Do(message.From.ID, func(p *state.Player) {
+			p.WaitingForName = true
+		})
 	}()
 }
 
@@ -290,7 +412,7 @@ func (h *BotHandlers) handleNameInput(message *tgbotapi.Message) {
 	}
 
 	// Создаем игрока в базе данных
-	player, err := h.db.CreatePlayer(userID, name)
+	player, err := h.db.CreatePlayer(userID, name, h.clock.Now())
 	if err != nil {
 		log.Printf("Error creating player: %v", err)
 		msg := tgbotapi.NewMessage(message.Chat.ID, "Произошла ошибка при регистрации. Попробуйте позже.")
@@ -298,19 +420,8 @@ func (h *BotHandlers) handleNameInput(message *tgbotapi.Message) {
 		return
 	}
 
-	// Добавляем стартовые предметы новому игроку
-	err = h.db.AddItemToInventoryWithDurability(player.ID, "Простой лук", 1, 100)
-	if err != nil {
-		log.Printf("Error adding bow to new player: %v", err)
-	}
-
-	err = h.db.AddItemToInventory(player.ID, "Стрелы", 100)
-	if err != nil {
-		log.Printf("Error adding arrows to new player: %v", err)
-	}
-
 	// Убираем флаг ожидания имени
-	delete(h.waitingForName, userID)
+	h.playerState(userID).WaitingForName = false
 
 	// Отправляем сообщение об успешной регистрации
 	successText := fmt.Sprintf(`✅ Регистрация прошла успешно!
@@ -333,7 +444,7 @@ func (h *BotHandlers) handleNameInput(message *tgbotapi.Message) {
 	h.sendWithKeyboard(msg)
 }
 
-func (h *BotHandlers) handleCraftQuantityInput(message *tgbotapi.Message, itemName string) {
+func (h *BotHandlers) handleCraftQuantityInput(message *tgbotapi.Message, recipeKey string) {
 	userID := message.From.ID
 	quantityStr := strings.TrimSpace(message.Text)
 
@@ -345,6 +456,9 @@ func (h *BotHandlers) handleCraftQuantityInput(message *tgbotapi.Message, itemNa
 		return
 	}
 
+	// Убираем флаг ожидания количества
+	h.playerState(userID).WaitingForCraftQuantity = ""
+
 	// Получаем игрока
 	player, err := h.db.GetPlayer(userID)
 	if err != nil {
@@ -354,30 +468,29 @@ func (h *BotHandlers) handleCraftQuantityInput(message *tgbotapi.Message, itemNa
 		return
 	}
 
-	// Проверяем количество березы в инвентаре для березового бруса
-	if itemName == "Березовый брус" {
-		birchQuantity, err := h.db.GetItemQuantityInInventory(player.ID, "Береза")
-		if err != nil {
-			log.Printf("Error getting birch quantity: %v", err)
-			birchQuantity = 0
-		}
+	recipe, err := h.db.GetRecipe(recipeKey)
+	if err != nil || recipe == nil {
+		log.Printf("Error getting recipe %q: %v", recipeKey, err)
+		msg := tgbotapi.NewMessage(message.Chat.ID, "Ошибка получения рецепта.")
+		h.sendMessage(msg)
+		return
+	}
 
-		// Для березового бруса нужно 2 березы за 1 брус
-		requiredBirch := quantity * 2
-		if birchQuantity < requiredBirch {
-			msg := tgbotapi.NewMessage(message.Chat.ID, `Недостаточно предмета "Береза".`)
-			h.sendMessage(msg)
-			// Убираем флаг ожидания количества
-			delete(h.waitingForCraftQuantity, userID)
-			return
-		}
+	// Проверяем, хватает ли ингредиентов на все количество
+	if crafts, missing := h.availableCrafts(player.ID, recipe); crafts < quantity {
+		msg := tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf(`Недостаточно предмета "%s".`, missing))
+		h.sendMessage(msg)
+		return
+	}
 
-		// Начинаем крафт
-		h.startCrafting(userID, message.Chat.ID, itemName, quantity)
+	// Печь работает в фоне, поэтому партия встает в очередь, а не занимает игрока
+	if recipe.Station == catalog.StationFurnace {
+		h.queueSmelting(userID, message.Chat.ID, *recipe, quantity)
+		return
 	}
 
-	// Убираем флаг ожидания количества
-	delete(h.waitingForCraftQuantity, userID)
+	// Начинаем крафт
+	h.startCrafting(userID, message.Chat.ID, *recipe, quantity)
 }
 
 func (h *BotHandlers) handleProfile(message *tgbotapi.Message) {
@@ -397,6 +510,8 @@ Telegram ID: %d
 Уровень: %d
 Опыт: %d/100
 Сытость: %d/100`, player.Name, player.TelegramID, player.Level, player.Experience, player.Satiety)
+	profileText += h.hungerText(player.Satiety)
+	profileText += h.buffsText(h.activeBuffs(player.ID))
 
 	msg := tgbotapi.NewMessage(message.Chat.ID, profileText)
 	h.sendMessage(msg)
@@ -445,11 +560,18 @@ func (h *BotHandlers) handleInventory(message *tgbotapi.Message) {
 	// Формируем текст инвентаря
 	inventoryText := "🎒 Ваш инвентарь:\n\n"
 
-	// Добавляем обычные предметы
+	// Добавляем обычные предметы. Каждый экземпляр инструмента - отдельной строкой,
+	// выбирать есть из чего, только если экземпляров несколько.
+	instances := make(map[string]int)
 	for _, item := range regularItems {
-		if item.Type == "tool" && item.Durability > 0 {
-			inventoryText += fmt.Sprintf("%s - %d шт. (Прочность: %d/100)\n", item.ItemName, item.Quantity, item.Durability)
-		} else if item.ItemName == "Лесная ягода" {
+		if item.IsTool() {
+			instances[item.ItemName]++
+		}
+	}
+	for _, item := range regularItems {
+		if item.IsTool() {
+			inventoryText += toolLine(item, instances[item.ItemName] > 1)
+		} else if item.Type == "food" {
 			inventoryText += fmt.Sprintf("%s - %d шт. /eat\n", item.ItemName, item.Quantity)
 		} else {
 			inventoryText += fmt.Sprintf("%s - %d шт.\n", item.ItemName, item.Quantity)
@@ -465,117 +587,208 @@ func (h *BotHandlers) handleInventory(message *tgbotapi.Message) {
 	h.sendMessage(msg)
 }
 
-func (h *BotHandlers) handleEat(message *tgbotapi.Message) {
-	// Получаем информацию об игроке
+// toolLine - строка экземпляра инструмента в инвентаре. choosable добавляет команду выбора экземпляра.
+func toolLine(tool models.InventoryItem, choosable bool) string {
+	line := fmt.Sprintf("%s - %d шт. (Прочность: %d/%d)", tool.ItemName, tool.Quantity, tool.Durability, tool.DurabilityMax)
+
+	keys := make([]string, 0, len(tool.Attributes))
+	for key := range tool.Attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		line += fmt.Sprintf(" [%s: %s]", key, tool.Attributes[key])
+	}
+
+	switch {
+	case !choosable:
+	case tool.Equipped:
+		line += " ✅"
+	case tool.Durability > 0:
+		line += fmt.Sprintf(" /equip_%d", tool.ID)
+	}
+	return line + "\n"
+}
+
+// handleEquip выбирает экземпляр инструмента, которым игрок будет работать
+func (h *BotHandlers) handleEquip(message *tgbotapi.Message, idText string) {
 	player, err := h.db.GetPlayer(message.From.ID)
 	if err != nil {
 		log.Printf("Error getting player: %v", err)
 		return
 	}
 
-	// Получаем инвентарь
-	inventory, err := h.db.GetPlayerInventory(player.ID)
+	instanceID, err := strconv.Atoi(idText)
 	if err != nil {
-		log.Printf("Error getting inventory: %v", err)
+		msg := tgbotapi.NewMessage(message.Chat.ID, "Неизвестная команда. Используйте /start для начала игры.")
+		h.sendMessage(msg)
 		return
 	}
 
-	// Ищем ягоды в инвентаре
-	var berryItem *models.InventoryItem
-	for i, item := range inventory {
-		if item.ItemName == "Лесная ягода" && item.Quantity > 0 {
-			berryItem = &inventory[i]
-			break
-		}
+	tool, err := h.db.EquipTool(player.ID, instanceID)
+	if err != nil {
+		log.Printf("Error equipping tool %d: %v", instanceID, err)
+		return
 	}
-
-	if berryItem == nil {
-		msg := tgbotapi.NewMessage(message.Chat.ID, "У тебя нет ягод для еды!")
+	if tool == nil {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "Такого инструмента нет в инвентаре.")
 		h.sendMessage(msg)
 		return
 	}
 
-	// Уменьшаем количество ягод
-	err = h.db.ConsumeItem(player.ID, "Лесная ягода", 1)
+	msg := tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf(`✅ Выбран "%s" (Прочность: %d/%d).`, tool.ItemName, tool.Durability, tool.DurabilityMax))
+	h.sendMessage(msg)
+}
+
+// foodStock - съедобный предмет в инвентаре игрока
+type foodStock struct {
+	Item     models.Item
+	Quantity int
+}
+
+// playerFood возвращает еду игрока, начиная с самой сытной
+func (h *BotHandlers) playerFood(playerID int) []foodStock {
+	foods, err := h.db.GetEdibleItems()
 	if err != nil {
-		log.Printf("Error consuming berries: %v", err)
-		return
+		log.Printf("Error getting edible items: %v", err)
+		return nil
 	}
 
-	// Увеличиваем сытость
-	err = h.db.UpdatePlayerSatiety(player.ID, 5)
+	var stock []foodStock
+	for _, food := range foods {
+		quantity, err := h.db.GetItemQuantityInInventory(playerID, food.Name)
+		if err != nil {
+			log.Printf("Error getting inventory quantity: %v", err)
+			continue
+		}
+		if quantity > 0 {
+			stock = append(stock, foodStock{Item: food, Quantity: quantity})
+		}
+	}
+	return stock
+}
+
+// foodKeyboard - по кнопке eat_<ключ> на каждую еду игрока
+func foodKeyboard(stock []foodStock) tgbotapi.InlineKeyboardMarkup {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, food := range stock {
+		label := fmt.Sprintf("%s x%d (+%d)", food.Item.Name, food.Quantity, food.Item.Satiety)
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(label, "eat_"+food.Item.Key)))
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+func eatPickerText(satiety int) string {
+	return fmt.Sprintf("🍽 Что будешь есть? Сытость: %d/100", satiety)
+}
+
+// handleEat показывает еду игрока кнопками
+func (h *BotHandlers) handleEat(message *tgbotapi.Message) {
+	// Получаем информацию об игроке
+	player, err := h.db.GetPlayer(message.From.ID)
 	if err != nil {
-		log.Printf("Error updating satiety: %v", err)
+		log.Printf("Error getting player: %v", err)
 		return
 	}
 
-	// Получаем обновленное значение сытости
-	updatedPlayer, err := h.db.GetPlayer(message.From.ID)
-	if err != nil {
-		log.Printf("Error getting updated player: %v", err)
+	stock := h.playerFood(player.ID)
+	if len(stock) == 0 {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "У тебя нет еды! Собери ягоды или приготовь добычу на костре.")
+		h.sendMessage(msg)
 		return
 	}
 
-	// Отправляем сообщение о съеденных ягодах
-	msg := tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("Ты съел ягоды! Сытость: %d/100", updatedPlayer.Satiety))
+	msg := tgbotapi.NewMessage(message.Chat.ID, eatPickerText(player.Satiety))
+	msg.ReplyMarkup = foodKeyboard(stock)
 	h.sendMessage(msg)
-
-	// Проверяем прогресс квеста 7
-	h.checkBerryEatingQuestProgress(message.From.ID, message.Chat.ID, player.ID)
 }
 
-func (h *BotHandlers) checkBerryEatingQuestProgress(userID int64, chatID int64, playerID int) {
-	// Проверяем активный квест 7 (съесть 3 ягоды)
-	quest, err := h.db.GetPlayerQuest(playerID, 7)
+// handleEatCallback съедает одну штуку выбранной еды и обновляет кнопки
+func (h *BotHandlers) handleEatCallback(userID int64, chatID int64, foodKey string, callbackID string, messageID int) {
+	player, err := h.db.GetPlayer(userID)
 	if err != nil {
-		log.Printf("Error getting quest 7: %v", err)
+		log.Printf("Error getting player: %v", err)
+		callbackConfig := tgbotapi.NewCallback(callbackID, "Ошибка получения данных игрока")
+		h.requestAPI(callbackConfig)
 		return
 	}
 
-	// Если квест не активен, ничего не делаем
-	if quest == nil || quest.Status != "active" {
+	foods, err := h.db.GetEdibleItems()
+	if err != nil {
+		log.Printf("Error getting edible items: %v", err)
+		callbackConfig := tgbotapi.NewCallback(callbackID, "Произошла ошибка. Попробуйте позже.")
+		h.requestAPI(callbackConfig)
+		return
+	}
+	var food *models.Item
+	for i := range foods {
+		if foods[i].Key == foodKey {
+			food = &foods[i]
+			break
+		}
+	}
+	if food == nil {
+		callbackConfig := tgbotapi.NewCallback(callbackID, "Это нельзя съесть")
+		h.requestAPI(callbackConfig)
 		return
 	}
 
-	// Увеличиваем прогресс квеста
-	newProgress := quest.Progress + 1
-	err = h.db.UpdateQuestProgress(playerID, 7, newProgress)
+	err = h.db.ApplyInventoryChange(player.ID, models.InventoryChange{
+		Reason:  "eat",
+		Consume: []models.ItemDelta{{ItemName: food.Name, Quantity: 1}},
+	})
+	var insufficient *database.InsufficientItemError
+	if errors.As(err, &insufficient) {
+		callbackConfig := tgbotapi.NewCallback(callbackID, fmt.Sprintf(`У тебя больше нет "%s"`, food.Name))
+		h.requestAPI(callbackConfig)
+		h.updateEatPicker(chatID, messageID, player.ID, player.Satiety)
+		return
+	}
 	if err != nil {
-		log.Printf("Error updating quest 7 progress: %v", err)
+		log.Printf("Error consuming %s: %v", food.Name, err)
+		callbackConfig := tgbotapi.NewCallback(callbackID, "Произошла ошибка. Попробуйте позже.")
+		h.requestAPI(callbackConfig)
 		return
 	}
 
-	// Проверяем, выполнен ли квест
-	if newProgress >= quest.Target {
-		// Квест выполнен!
-		err = h.db.UpdateQuestStatus(playerID, 7, "completed")
-		if err != nil {
-			log.Printf("Error completing quest 7: %v", err)
-			return
-		}
+	// Увеличиваем сытость
+	eater := playerRef(userID, player.ID, chatID)
+	if err := h.changeSatiety(eater, food.Satiety); err != nil {
+		log.Printf("Error updating satiety: %v", err)
+	}
 
-		// Добавляем награды
-		// 10 опыта игроку
-		err = h.db.UpdatePlayerExperience(playerID, 10)
-		if err != nil {
-			log.Printf("Error updating player experience: %v", err)
-		}
+	// Получаем обновленное значение сытости
+	updatedPlayer, err := h.db.GetPlayer(userID)
+	if err != nil {
+		log.Printf("Error getting updated player: %v", err)
+		updatedPlayer = player
+	}
 
-		// Добавляем страницу в инвентарь
-		err = h.db.AddItemToInventory(playerID, "📖 Страница 7 «Шёпот ветра»", 1)
-		if err != nil {
-			log.Printf("Error adding quest item to inventory: %v", err)
-		}
+	// Некоторая еда дает временный бафф
+	buffText := h.applyFoodBuff(player.ID, food.Name)
 
-		// Отправляем сообщение о выполнении квеста
-		questCompleteText := `🍇 Квест 7: Перекус ВЫПОЛНЕН!
-Получена награда:
-🎖 10 опыта
-📖 Страница 7 «Шёпот ветра»`
+	callbackConfig := tgbotapi.NewCallback(callbackID, "")
+	h.requestAPI(callbackConfig)
 
-		msg := tgbotapi.NewMessage(chatID, questCompleteText)
-		h.sendMessage(msg)
+	msg := tgbotapi.NewMessage(chatID, fmt.Sprintf(`Ты съел "%s"! Сытость: %d/100`, food.Name, updatedPlayer.Satiety)+buffText+h.hungerText(updatedPlayer.Satiety))
+	h.sendMessage(msg)
+
+	h.updateEatPicker(chatID, messageID, player.ID, updatedPlayer.Satiety)
+
+	events.Publish(h.bus, events.FoodEaten{Player: eater, Food: food.Name, Quantity: 1})
+}
+
+// updateEatPicker обновляет кнопки еды, а когда еда кончилась - убирает их
+func (h *BotHandlers) updateEatPicker(chatID int64, messageID int, playerID int, satiety int) {
+	stock := h.playerFood(playerID)
+	if len(stock) == 0 {
+		editMsg := tgbotapi.NewEditMessageText(chatID, messageID, "🍽 Еда закончилась.")
+		h.requestAPI(editMsg)
+		return
 	}
+
+	editMsg := tgbotapi.NewEditMessageTextAndMarkup(chatID, messageID, eatPickerText(satiety), foodKeyboard(stock))
+	h.requestAPI(editMsg)
 }
 
 func (h *BotHandlers) handleGathering(message *tgbotapi.Message) {
@@ -602,150 +815,90 @@ func (h *BotHandlers) handleWorkplace(message *tgbotapi.Message) {
 }
 
 func (h *BotHandlers) handleWorkbench(message *tgbotapi.Message) {
-	workbenchText := `🛠 Доступные предметы для создания:
-
-Березовый брус — /create_birch_plank
-Простой топор — /create_axe
-Простая кирка — /create_pickaxe
-Простой лук — /create_bow
-Стрелы — /create_arrows
-Простой нож — /create_knife
-Простая удочка — /create_fishing_rod`
-
-	msg := tgbotapi.NewMessage(message.Chat.ID, workbenchText)
-	h.sendMessage(msg)
-}
-
-func (h *BotHandlers) handleFurnace(message *tgbotapi.Message) {
-	msg := tgbotapi.NewMessage(message.Chat.ID, "🧱 Функция печи пока в разработке...")
-	h.sendMessage(msg)
+	h.showStation(message, catalog.StationWorkbench, "🛠 Доступные предметы для создания:", "🛠 Функция верстака пока в разработке...")
 }
 
 func (h *BotHandlers) handleCampfire(message *tgbotapi.Message) {
-	msg := tgbotapi.NewMessage(message.Chat.ID, "🔥 Функция костра пока в разработке...")
-	h.sendMessage(msg)
+	h.showStation(message, catalog.StationCampfire, "🔥 Доступные блюда для приготовления:", "🔥 Функция костра пока в разработке...")
 }
 
-func (h *BotHandlers) handleBack(message *tgbotapi.Message) {
-	userID := message.From.ID
-	chatID := message.Chat.ID
-
-	// Проверяем, идет ли добыча ресурса или рубка
-	if _, isMining := h.miningTimers[userID]; isMining {
-		// Если идет добыча, не позволяем выйти
-		msg := tgbotapi.NewMessage(chatID, "Идет добыча ресурса.")
-		h.sendMessage(msg)
-		return
-	}
-
-	if _, isChopping := h.choppingTimers[userID]; isChopping {
-		// Если идет рубка, не позволяем выйти
-		msg := tgbotapi.NewMessage(chatID, "Идет рубка дерева.")
-		h.sendMessage(msg)
-		return
-	}
-
-	if _, isGathering := h.gatheringTimers[userID]; isGathering {
-		// Если идет сбор, не позволяем выйти
-		msg := tgbotapi.NewMessage(chatID, "Идет сбор ягод.")
+// showStation выводит рецепты станции со ссылками /create_<ключ>
+func (h *BotHandlers) showStation(message *tgbotapi.Message, station string, title string, emptyText string) {
+	recipes, err := h.db.GetStationRecipes(station)
+	if err != nil {
+		log.Printf("Error getting %s recipes: %v", station, err)
+		msg := tgbotapi.NewMessage(message.Chat.ID, "Произошла ошибка. Попробуйте позже.")
 		h.sendMessage(msg)
 		return
 	}
 
-	if _, isHunting := h.huntingTimers[userID]; isHunting {
-		// Если идет охота, не позволяем выйти
-		msg := tgbotapi.NewMessage(chatID, "Идет охота.")
+	if len(recipes) == 0 {
+		msg := tgbotapi.NewMessage(message.Chat.ID, emptyText)
 		h.sendMessage(msg)
 		return
 	}
 
-	// Проверяем, есть ли активная сессия шахты
-	if session, exists := h.mineSessions[userID]; exists {
-		// Удаляем сообщение с полем шахты
-		deleteFieldMsg := tgbotapi.NewDeleteMessage(chatID, session.FieldMessageID)
-		h.requestAPI(deleteFieldMsg)
-
-		// Удаляем сообщение с информацией о шахте
-		deleteInfoMsg := tgbotapi.NewDeleteMessage(chatID, session.InfoMessageID)
-		h.requestAPI(deleteInfoMsg)
-
-		// Удаляем сессию шахты
-		delete(h.mineSessions, userID)
-
-		// Возвращаемся в меню добычи
-		msg := tgbotapi.NewMessage(chatID, "🌿 Выберите место для добычи ресурсов:")
-		h.sendGatheringKeyboard(msg)
-		return
+	stationText := title + "\n"
+	for _, recipe := range recipes {
+		stationText += fmt.Sprintf("\n%s — /create_%s", recipe.ItemName, recipe.Key)
 	}
 
-	// Проверяем, есть ли активная сессия леса
-	if session, exists := h.forestSessions[userID]; exists {
-		// Удаляем сообщение с полем леса
-		deleteFieldMsg := tgbotapi.NewDeleteMessage(chatID, session.FieldMessageID)
-		h.requestAPI(deleteFieldMsg)
-
-		// Удаляем сообщение с информацией о лесе
-		deleteInfoMsg := tgbotapi.NewDeleteMessage(chatID, session.InfoMessageID)
-		h.requestAPI(deleteInfoMsg)
-
-		// Удаляем сессию леса
-		delete(h.forestSessions, userID)
-
-		// Возвращаемся в меню леса
-		msg := tgbotapi.NewMessage(chatID, "🌲 Ты входишь в густой лес. Под ногами хрустит трава, в кронах поют птицы, а где-то вдалеке слышен треск ветки — ты здесь не один...\n\nЗдесь ты можешь:\n🪓 Рубить деревья\n🎯 Охотиться на дичь\n🌿 Собирать травы и ягоды")
-		h.sendForestKeyboard(msg)
-		return
+	// Станциям с топливом показываем, чем их можно растопить
+	if catalog.FuelStations[station] {
+		player, err := h.db.GetPlayer(message.From.ID)
+		if err != nil {
+			log.Printf("Error getting player: %v", err)
+		} else {
+			stock, units := h.playerFuel(player.ID, nil)
+			stationText += "\n\n" + fuelText(stock, units)
+		}
 	}
 
-	// Проверяем, есть ли активная сессия сбора
-	if session, exists := h.gatheringSessions[userID]; exists {
-		// Удаляем сообщение с полем сбора
-		deleteFieldMsg := tgbotapi.NewDeleteMessage(chatID, session.FieldMessageID)
-		h.requestAPI(deleteFieldMsg)
-
-		// Удаляем сообщение с информацией о сборе
-		deleteInfoMsg := tgbotapi.NewDeleteMessage(chatID, session.InfoMessageID)
-		h.requestAPI(deleteInfoMsg)
+	msg := tgbotapi.NewMessage(message.Chat.ID, stationText)
+	h.sendMessage(msg)
+}
 
-		// Удаляем сессию сбора
-		delete(h.gatheringSessions, userID)
+func (h *BotHandlers) handleBack(message *tgbotapi.Message) {
+	userID := message.From.ID
+	chatID := message.Chat.ID
 
-		// Возвращаемся в меню леса
-		msg := tgbotapi.NewMessage(chatID, "🌲 Ты входишь в густой лес. Под ногами хрустит трава, в кронах поют птицы, а где-то вдалеке слышен треск ветки — ты здесь не один...\n\nЗдесь ты можешь:\n🪓 Рубить деревья\n🎯 Охотиться на дичь\n🌿 Собирать травы и ягоды")
-		h.sendForestKeyboard(msg)
-		return
+	// Во время добычи выйти из локации нельзя
+	for _, def := range locations {
+		if h.playerState(userID).Actions[def.Action] != nil {
+			msg := tgbotapi.NewMessage(chatID, def.BusyText)
+			h.sendMessage(msg)
+			return
+		}
 	}
 
-	// Проверяем, есть ли активная сессия охоты
-	if session, exists := h.huntingSessions[userID]; exists {
-		// Удаляем сообщение с полем охоты
-		deleteFieldMsg := tgbotapi.NewDeleteMessage(chatID, session.FieldMessageID)
-		h.requestAPI(deleteFieldMsg)
-
-		// Удаляем сообщение с информацией об охоте
-		deleteInfoMsg := tgbotapi.NewDeleteMessage(chatID, session.InfoMessageID)
-		h.requestAPI(deleteInfoMsg)
-
-		// Удаляем сессию охоты
-		delete(h.huntingSessions, userID)
+	// Закрываем открытое поле локации и возвращаемся в меню, из которого она открыта
+	for _, def := range locations {
+		if h.playerState(userID).Sessions[def.Key] == nil {
+			continue
+		}
+		h.closeLocationSession(chatID, userID, def)
 
-		// Возвращаемся в меню леса
-		msg := tgbotapi.NewMessage(chatID, "🌲 Ты входишь в густой лес. Под ногами хрустит трава, в кронах поют птицы, а где-то вдалеке слышен треск ветки — ты здесь не один...\n\nЗдесь ты можешь:\n🪓 Рубить деревья\n🎯 Охотиться на дичь\n🌿 Собирать травы и ягоды")
-		h.sendForestKeyboard(msg)
+		if def.InForest {
+			msg := tgbotapi.NewMessage(chatID, forestText)
+			h.sendForestKeyboard(msg)
+		} else {
+			msg := tgbotapi.NewMessage(chatID, "🌿 Выберите место для добычи ресурсов:")
+			h.sendGatheringKeyboard(msg)
+		}
 		return
 	}
 
 	// Проверяем текущее местоположение игрока
-	if location, exists := h.playerLocation[userID]; exists {
+	if location := h.playerState(userID).Location; location != "" {
 		switch location {
 		case "forest":
 			// Игрок в лесу - возвращаемся в меню добычи
-			delete(h.playerLocation, userID) // Убираем местоположение
+			h.playerState(userID).Location = "" // Убираем местоположение
 			msg := tgbotapi.NewMessage(chatID, "🌿 Выберите место для добычи ресурсов:")
 			h.sendGatheringKeyboard(msg)
 		case "quest":
 			// Игрок в меню квестов - возвращаемся в главное меню
-			delete(h.playerLocation, userID) // Убираем местоположение
+			h.playerState(userID).Location = "" // Убираем местоположение
 			msg := tgbotapi.NewMessage(chatID, "🏠 Возвращаемся к главному меню.")
 			h.sendWithKeyboard(msg)
 		default:
@@ -760,1169 +913,46 @@ func (h *BotHandlers) handleBack(message *tgbotapi.Message) {
 	}
 }
 
-func (h *BotHandlers) handleMine(message *tgbotapi.Message) {
-	userID := message.From.ID
+func (h *BotHandlers) createProgressBar(current, total int) string {
+	// Создаем прогресс бар из 10 блоков
+	barLength := 10
+	filled := (current * barLength) / total
+	if filled > barLength {
+		filled = barLength
+	}
 
-	// Проверяем, активен ли кулдаун шахты
-	if cooldownEnd, exists := h.mineCooldowns[userID]; exists {
-		if time.Now().Before(cooldownEnd) {
-			// Кулдаун еще активен
-			remainingTime := time.Until(cooldownEnd)
-			msg := tgbotapi.NewMessage(message.Chat.ID,
-				fmt.Sprintf("До обновления шахты осталось %d сек.", int(remainingTime.Seconds())))
-			h.sendMessage(msg)
-			return
+	progressBar := ""
+	for i := 0; i < barLength; i++ {
+		if i < filled {
+			progressBar += "🟩" // Заполненный блок
 		} else {
-			// Кулдаун истек, удаляем его
-			delete(h.mineCooldowns, userID)
+			progressBar += "⬜" // Пустой блок
 		}
 	}
 
-	// Получаем игрока
-	player, err := h.db.GetPlayer(userID)
-	if err != nil {
-		log.Printf("Error getting player: %v", err)
-		msg := tgbotapi.NewMessage(message.Chat.ID, "Сначала зарегистрируйтесь с помощью команды /start")
-		h.sendMessage(msg)
-		return
-	}
+	return progressBar
+}
 
-	// Проверяем сытость игрока
-	if player.Satiety <= 0 {
-		msg := tgbotapi.NewMessage(message.Chat.ID, "Сытость 0. Необходимо поесть.")
-		h.sendMessage(msg)
-		return
-	}
-
-	// Получаем или создаем шахту
-	mine, err := h.db.GetOrCreateMine(player.ID)
-	if err != nil {
-		log.Printf("Error getting mine: %v", err)
-		msg := tgbotapi.NewMessage(message.Chat.ID, "Произошла ошибка при работе с шахтой.")
-		h.sendMessage(msg)
-		return
-	}
-
-	// Если шахта была истощена в базе данных, восстанавливаем её
-	if mine.IsExhausted {
-		if err := h.db.SetMineExhausted(player.ID, false); err != nil {
-			log.Printf("Error setting mine exhausted: %v", err)
-		}
-		mine.IsExhausted = false
-	}
-
-	// Создаем новую сессию шахты
-	h.createNewMineSession(userID, message.Chat.ID, mine)
-}
-
-func (h *BotHandlers) createNewMineSession(userID int64, chatID int64, mine *models.Mine) {
-	// Генерируем случайное поле
-	field := h.generateRandomMineField()
-
-	// Показываем поле и получаем MessageID
-	fieldMessageID, infoMessageID := h.showMineField(chatID, mine, field)
-
-	// Создаем сессию
-	session := &models.MineSession{
-		PlayerID:       userID,
-		Resources:      field,
-		IsActive:       true,
-		IsMining:       false,
-		StartedAt:      time.Now(),
-		FieldMessageID: fieldMessageID,
-		InfoMessageID:  infoMessageID,
-	}
-
-	h.mineSessions[userID] = session
-}
-
-func (h *BotHandlers) showMineField(chatID int64, mine *models.Mine, field [][]string) (int, int) {
-	// Создаем инлайн клавиатуру на основе переданного поля
-	var keyboard [][]tgbotapi.InlineKeyboardButton
-	for i := 0; i < 3; i++ {
-		var row []tgbotapi.InlineKeyboardButton
-		for j := 0; j < 3; j++ {
-			cell := field[i][j]
-			var callbackData string
-
-			switch cell {
-			case "🪨":
-				callbackData = fmt.Sprintf("mine_stone_%d_%d", i, j)
-			case "⚫":
-				callbackData = fmt.Sprintf("mine_coal_%d_%d", i, j)
-			default:
-				callbackData = fmt.Sprintf("mine_empty_%d_%d", i, j)
-				cell = " "
-			}
-
-			button := tgbotapi.NewInlineKeyboardButtonData(cell, callbackData)
-			row = append(row, button)
-		}
-		keyboard = append(keyboard, row)
-	}
-
-	// Сначала отправляем поле шахты с инлайн кнопками
-	fieldMsg := tgbotapi.NewMessage(chatID, "Выберите ресурс для добычи:")
-	fieldMsg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(keyboard...)
-	fieldResponse, _ := h.sendChattableWithResponse(fieldMsg)
-
-	// Затем отправляем информационное сообщение с клавиатурой
-	// Вычисляем опыт до следующего уровня
-	expToNext := (mine.Level * 100) - mine.Experience
-
-	infoText := fmt.Sprintf(`⛏ Шахта (Уровень %d)
-До следующего уровня: %d опыта
-
-Доступные ресурсы:
-🪨 Камень
-⚫ Уголь`, mine.Level, expToNext)
-
-	mineKeyboard := tgbotapi.NewReplyKeyboard(
-		tgbotapi.NewKeyboardButtonRow(
-			tgbotapi.NewKeyboardButton("◀️ Назад"),
-		),
-	)
-	mineKeyboard.ResizeKeyboard = true
-
-	infoMsg := tgbotapi.NewMessage(chatID, infoText)
-	infoMsg.ReplyMarkup = mineKeyboard
-	infoResponse, _ := h.sendChattableWithResponse(infoMsg)
-
-	// Возвращаем ID поля и ID информационного сообщения
-	return fieldResponse.MessageID, infoResponse.MessageID
-}
-
-func (h *BotHandlers) generateRandomMineField() [][]string {
-	// Создаем пустое поле 3x3
-	field := make([][]string, 3)
-	for i := range field {
-		field[i] = make([]string, 3)
-	}
-
-	// Доступные ресурсы
-	availableResources := []string{"🪨", "⚫"}
-
-	// Используем время для псевдослучайности
-	now := time.Now()
-	seed := now.UnixNano()
-
-	// Создаем список всех позиций
-	positions := [][2]int{
-		{0, 0}, {0, 1}, {0, 2},
-		{1, 0}, {1, 1}, {1, 2},
-		{2, 0}, {2, 1}, {2, 2},
-	}
-
-	// Перемешиваем позиции с использованием времени
-	for i := len(positions) - 1; i > 0; i-- {
-		j := int((seed + int64(i*13)) % int64(i+1))
-		positions[i], positions[j] = positions[j], positions[i]
-	}
-
-	// Размещаем 3 ресурса в первых 3 позициях
-	for i := 0; i < 3; i++ {
-		pos := positions[i]
-		// Выбираем ресурс псевдослучайно
-		resourceIndex := int((seed + int64(i*17) + int64(pos[0]*3) + int64(pos[1])) % int64(len(availableResources)))
-		resourceType := availableResources[resourceIndex]
-		field[pos[0]][pos[1]] = resourceType
-	}
-
-	return field
-}
-
-func (h *BotHandlers) createProgressBar(current, total int) string {
-	// Создаем прогресс бар из 10 блоков
-	barLength := 10
-	filled := (current * barLength) / total
-	if filled > barLength {
-		filled = barLength
-	}
-
-	progressBar := ""
-	for i := 0; i < barLength; i++ {
-		if i < filled {
-			progressBar += "🟩" // Заполненный блок
-		} else {
-			progressBar += "⬜" // Пустой блок
-		}
-	}
-
-	return progressBar
-}
-
-func (h *BotHandlers) updateMiningProgress(userID int64, chatID int64, messageID int, resourceName string, totalDuration int, durability int, row, col int) {
-	startTime := time.Now()
-	ticker := time.NewTicker(1 * time.Second)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ticker.C:
-			elapsed := time.Since(startTime).Seconds()
-			progress := int(elapsed)
-
-			if progress >= totalDuration {
-				// Добыча завершена
-				h.completeMining(userID, chatID, resourceName, durability, messageID, row, col)
-				return
-			}
-
-			// Обновляем прогресс бар
-			percentage := int((elapsed / float64(totalDuration)) * 100)
-			progressBar := h.createProgressBar(progress, totalDuration)
-
-			newText := fmt.Sprintf(`Началась добыча ресурса "%s". Время добычи %d сек.
-			
-%s %d%%`, resourceName, totalDuration, progressBar, percentage)
-
-			// Редактируем сообщение
-			editMsg := tgbotapi.NewEditMessageText(chatID, messageID, newText)
-			h.editMessage(editMsg)
-
-		case <-time.After(time.Duration(totalDuration+1) * time.Second):
-			// Таймаут на случай, если что-то пошло не так
-			return
-		}
-	}
-}
-
-func (h *BotHandlers) handleField(message *tgbotapi.Message) {
-	msg := tgbotapi.NewMessage(message.Chat.ID, "🌾 Функция поля пока в разработке...")
-	h.sendMessage(msg)
-}
-
-func (h *BotHandlers) handleLake(message *tgbotapi.Message) {
-	msg := tgbotapi.NewMessage(message.Chat.ID, "🎣 Функция озера пока в разработке...")
-	h.sendMessage(msg)
-}
-
-func (h *BotHandlers) handleForest(message *tgbotapi.Message) {
-	userID := message.From.ID
-
-	// Устанавливаем местоположение игрока
-	h.playerLocation[userID] = "forest"
-
-	forestText := `🌲 Ты входишь в густой лес. Под ногами хрустит трава, в кронах поют птицы, а где-то вдалеке слышен треск ветки — ты здесь не один...
-
-Здесь ты можешь:
-🪓 Рубить деревья  
-🎯 Охотиться на дичь  
-🌿 Собирать травы и ягоды`
-
-	msg := tgbotapi.NewMessage(message.Chat.ID, forestText)
-	h.sendForestKeyboard(msg)
-}
-
-func (h *BotHandlers) handleHunting(message *tgbotapi.Message) {
-	userID := message.From.ID
-
-	// Проверяем, активен ли кулдаун охоты
-	if cooldownEnd, exists := h.huntingCooldowns[userID]; exists {
-		if time.Now().Before(cooldownEnd) {
-			// Кулдаун еще активен
-			remainingTime := time.Until(cooldownEnd)
-			msg := tgbotapi.NewMessage(message.Chat.ID,
-				fmt.Sprintf("До обновления охотничьих угодий осталось %d сек.", int(remainingTime.Seconds())))
-			h.sendMessage(msg)
-			return
-		} else {
-			// Кулдаун истек, удаляем его
-			delete(h.huntingCooldowns, userID)
-		}
-	}
-
-	// Получаем игрока
-	player, err := h.db.GetPlayer(userID)
-	if err != nil {
-		log.Printf("Error getting player: %v", err)
-		msg := tgbotapi.NewMessage(message.Chat.ID, "Сначала зарегистрируйтесь с помощью команды /start")
-		h.sendMessage(msg)
-		return
-	}
-
-	// Проверяем сытость игрока
-	if player.Satiety <= 0 {
-		msg := tgbotapi.NewMessage(message.Chat.ID, "Сытость 0. Необходимо поесть.")
-		h.sendMessage(msg)
-		return
-	}
-
-	// Получаем или создаем охоту
-	hunting, err := h.db.GetOrCreateHunting(player.ID)
-	if err != nil {
-		log.Printf("Error getting hunting: %v", err)
-		msg := tgbotapi.NewMessage(message.Chat.ID, "Произошла ошибка при работе с охотой.")
-		h.sendMessage(msg)
-		return
-	}
-
-	// Если охота была истощена в базе данных, восстанавливаем её
-	if hunting.IsExhausted {
-		if err := h.db.SetHuntingExhausted(player.ID, false); err != nil {
-			log.Printf("Error setting hunting exhausted: %v", err)
-		}
-		hunting.IsExhausted = false
-	}
-
-	// Создаем новую сессию охоты
-	h.createNewHuntingSession(userID, message.Chat.ID, hunting)
-}
-
-func (h *BotHandlers) createNewHuntingSession(userID int64, chatID int64, hunting *models.Hunting) {
-	// Генерируем случайное поле
-	field := h.generateRandomHuntingField()
-
-	// Показываем поле и получаем MessageID
-	fieldMessageID, infoMessageID := h.showHuntingField(chatID, hunting, field)
-
-	// Создаем сессию
-	session := &models.HuntingSession{
-		PlayerID:       userID,
-		Resources:      field,
-		IsActive:       true,
-		IsHunting:      false,
-		StartedAt:      time.Now(),
-		FieldMessageID: fieldMessageID,
-		InfoMessageID:  infoMessageID,
-	}
-
-	h.huntingSessions[userID] = session
-}
-
-func (h *BotHandlers) generateRandomHuntingField() [][]string {
-	// Создаем пустое поле 3x3
-	field := make([][]string, 3)
-	for i := range field {
-		field[i] = make([]string, 3)
-	}
-
-	// Доступные ресурсы для охоты
-	availableResources := []string{"🐰", "🐦"}
-
-	// Используем время для псевдослучайности
-	now := time.Now()
-	seed := now.UnixNano()
-
-	// Создаем список всех позиций
-	positions := [][2]int{
-		{0, 0}, {0, 1}, {0, 2},
-		{1, 0}, {1, 1}, {1, 2},
-		{2, 0}, {2, 1}, {2, 2},
-	}
-
-	// Перемешиваем позиции с использованием времени
-	for i := len(positions) - 1; i > 0; i-- {
-		j := int((seed + int64(i*13)) % int64(i+1))
-		positions[i], positions[j] = positions[j], positions[i]
-	}
-
-	// Размещаем 3 ресурса в первых 3 позициях
-	for i := 0; i < 3; i++ {
-		pos := positions[i]
-		// Выбираем ресурс псевдослучайно
-		resourceIndex := int((seed + int64(i*17) + int64(pos[0]*3) + int64(pos[1])) % int64(len(availableResources)))
-		resourceType := availableResources[resourceIndex]
-		field[pos[0]][pos[1]] = resourceType
-	}
-
-	return field
-}
-
-func (h *BotHandlers) showHuntingField(chatID int64, hunting *models.Hunting, field [][]string) (int, int) {
-	// Создаем инлайн клавиатуру на основе переданного поля
-	var keyboard [][]tgbotapi.InlineKeyboardButton
-	for i := 0; i < 3; i++ {
-		var row []tgbotapi.InlineKeyboardButton
-		for j := 0; j < 3; j++ {
-			cell := field[i][j]
-			var callbackData string
-
-			switch cell {
-			case "🐰":
-				callbackData = fmt.Sprintf("hunt_rabbit_%d_%d", i, j)
-			case "🐦":
-				callbackData = fmt.Sprintf("hunt_bird_%d_%d", i, j)
-			default:
-				callbackData = fmt.Sprintf("hunt_empty_%d_%d", i, j)
-				cell = " "
-			}
-
-			button := tgbotapi.NewInlineKeyboardButtonData(cell, callbackData)
-			row = append(row, button)
-		}
-		keyboard = append(keyboard, row)
-	}
-
-	// Сначала отправляем поле охоты с инлайн кнопками
-	fieldMsg := tgbotapi.NewMessage(chatID, "Выберите цель для охоты:")
-	fieldMsg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(keyboard...)
-	fieldResponse, _ := h.sendChattableWithResponse(fieldMsg)
-
-	// Затем отправляем информационное сообщение с клавиатурой
-	// Вычисляем опыт до следующего уровня
-	expToNext := (hunting.Level * 100) - hunting.Experience
-
-	infoText := fmt.Sprintf(`🎯 Охота (Уровень %d)
-До следующего уровня: %d опыта
-
-Доступные ресурсы:
-🐰 Кролик
-🐦 Куропатка`, hunting.Level, expToNext)
-
-	huntingKeyboard := tgbotapi.NewReplyKeyboard(
-		tgbotapi.NewKeyboardButtonRow(
-			tgbotapi.NewKeyboardButton("◀️ Назад"),
-		),
-	)
-	huntingKeyboard.ResizeKeyboard = true
-
-	infoMsg := tgbotapi.NewMessage(chatID, infoText)
-	infoMsg.ReplyMarkup = huntingKeyboard
-	infoResponse, _ := h.sendChattableWithResponse(infoMsg)
-
-	// Возвращаем ID поля и ID информационного сообщения
-	return fieldResponse.MessageID, infoResponse.MessageID
-}
-
-func (h *BotHandlers) startHuntingAtPosition(userID int64, chatID int64, resourceName string, duration int, callbackID string, rowStr, colStr string) {
-	row, _ := strconv.Atoi(rowStr)
-	col, _ := strconv.Atoi(colStr)
-
-	h.startHunting(userID, chatID, resourceName, duration, callbackID, row, col)
-}
-
-func (h *BotHandlers) startHunting(userID int64, chatID int64, resourceName string, duration int, callbackID string, row, col int) {
-	// Проверяем, идет ли уже охота, добыча в шахте, рубка в лесу или крафт
-	if _, exists := h.huntingTimers[userID]; exists {
-		msg := tgbotapi.NewMessage(chatID, "Нельзя начинать новую охоту, пока не закончена текущая.")
-		h.sendMessage(msg)
-		callbackConfig := tgbotapi.NewCallback(callbackID, "")
-		h.requestAPI(callbackConfig)
-		return
-	}
-	if _, exists := h.miningTimers[userID]; exists {
-		msg := tgbotapi.NewMessage(chatID, "Нельзя начинать новую добычу, пока не закончена текущая.")
-		h.sendMessage(msg)
-		callbackConfig := tgbotapi.NewCallback(callbackID, "")
-		h.requestAPI(callbackConfig)
-		return
-	}
-	if _, exists := h.choppingTimers[userID]; exists {
-		msg := tgbotapi.NewMessage(chatID, "Нельзя начинать новую добычу, пока не закончена текущая.")
-		h.sendMessage(msg)
-		callbackConfig := tgbotapi.NewCallback(callbackID, "")
-		h.requestAPI(callbackConfig)
-		return
-	}
-	if _, exists := h.gatheringTimers[userID]; exists {
-		msg := tgbotapi.NewMessage(chatID, "Нельзя начинать новую добычу, пока не закончена текущая.")
-		h.sendMessage(msg)
-		callbackConfig := tgbotapi.NewCallback(callbackID, "")
-		h.requestAPI(callbackConfig)
-		return
-	}
-	if _, exists := h.craftingTimers[userID]; exists {
-		msg := tgbotapi.NewMessage(chatID, "Нельзя совершать действия пока идет создание предметов.")
-		h.sendMessage(msg)
-		callbackConfig := tgbotapi.NewCallback(callbackID, "")
-		h.requestAPI(callbackConfig)
-		return
-	}
-
-	// Получаем игрока
-	player, err := h.db.GetPlayer(userID)
-	if err != nil {
-		log.Printf("Error getting player: %v", err)
-		return
-	}
-
-	// Проверяем наличие лука
-	hasBow, bowDurability, err := h.db.HasToolInInventory(player.ID, "Простой лук")
-	if err != nil {
-		log.Printf("Error checking bow: %v", err)
-		return
-	}
-
-	if !hasBow {
-		msg := tgbotapi.NewMessage(chatID, `В инвентаре нет предмета "Простой лук".`)
-		h.sendMessage(msg)
-		callbackConfig := tgbotapi.NewCallback(callbackID, "")
-		h.requestAPI(callbackConfig)
-		return
-	}
-
-	// Проверяем наличие стрел
-	arrowQuantity, err := h.db.GetItemQuantityInInventory(player.ID, "Стрелы")
-	if err != nil {
-		log.Printf("Error getting arrow quantity: %v", err)
-		arrowQuantity = 0
-	}
-
-	if arrowQuantity < 1 {
-		msg := tgbotapi.NewMessage(chatID, `В инвентаре нет предмета "Стрелы".`)
-		h.sendMessage(msg)
-		callbackConfig := tgbotapi.NewCallback(callbackID, "")
-		h.requestAPI(callbackConfig)
-		return
-	}
-
-	// Отвечаем на callback
-	callbackConfig := tgbotapi.NewCallback(callbackID, "")
-	h.requestAPI(callbackConfig)
-
-	// Удаляем предыдущее сообщение о результате охоты, если оно существует
-	if session, exists := h.huntingSessions[userID]; exists && session.ResultMessageID != 0 {
-		deleteResultMsg := tgbotapi.NewDeleteMessage(chatID, session.ResultMessageID)
-		h.requestAPI(deleteResultMsg)
-		session.ResultMessageID = 0 // Сбрасываем ID
-	}
-
-	// Отправляем сообщение о начале охоты
-	initialText := fmt.Sprintf(`Идет охота на "%s". Время охоты %d сек.
-		
-%s 0%%`, resourceName, duration, h.createProgressBar(0, 10))
-
-	huntingMsg := tgbotapi.NewMessage(chatID, initialText)
-	sentMsg, _ := h.sendMessageWithResponse(huntingMsg)
-
-	// Запускаем горутину для обновления прогресс бара
-	go h.updateHuntingProgress(userID, chatID, sentMsg.MessageID, resourceName, duration, bowDurability, row, col)
-
-	// Создаем заглушку таймера
-	timer := time.NewTimer(time.Duration(duration) * time.Second)
-	h.huntingTimers[userID] = timer
-}
-
-func (h *BotHandlers) updateHuntingProgress(userID int64, chatID int64, messageID int, resourceName string, totalDuration int, durability int, row, col int) {
-	startTime := time.Now()
-	ticker := time.NewTicker(1 * time.Second)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ticker.C:
-			elapsed := time.Since(startTime).Seconds()
-			progress := int(elapsed)
-
-			if progress >= totalDuration {
-				// Охота завершена
-				h.completeHunting(userID, chatID, resourceName, durability, messageID, row, col)
-				return
-			}
-
-			// Обновляем прогресс бар
-			percentage := int((elapsed / float64(totalDuration)) * 100)
-			progressBar := h.createProgressBar(progress, totalDuration)
-
-			newText := fmt.Sprintf(`Началась охота на "%s". Время охоты %d сек.
-			
-%s %d%%`, resourceName, totalDuration, progressBar, percentage)
-
-			// Редактируем сообщение
-			editMsg := tgbotapi.NewEditMessageText(chatID, messageID, newText)
-			h.editMessage(editMsg)
-
-		case <-time.After(time.Duration(totalDuration+1) * time.Second):
-			// Таймаут на случай, если что-то пошло не так
-			return
-		}
-	}
-}
-
-func (h *BotHandlers) completeHunting(userID int64, chatID int64, resourceName string, oldDurability int, messageID int, row, col int) {
-	// Получаем игрока
-	player, err := h.db.GetPlayer(userID)
-	if err != nil {
-		log.Printf("Error getting player: %v", err)
-		return
-	}
-
-	// Уменьшаем прочность лука на 1
-	newDurability := oldDurability - 1
-	if newDurability <= 0 {
-		// Лук сломался, удаляем его
-		err = h.db.RemoveItemFromInventory(player.ID, "Простой лук", 1)
-		if err != nil {
-			log.Printf("Error removing broken bow: %v", err)
-		}
-	} else {
-		// Обновляем прочность лука
-		err = h.db.UpdateToolDurability(player.ID, "Простой лук", newDurability)
-		if err != nil {
-			log.Printf("Error updating bow durability: %v", err)
-		}
-	}
-
-	// Уменьшаем количество стрел на 1
-	err = h.db.RemoveItemFromInventory(player.ID, "Стрелы", 1)
-	if err != nil {
-		log.Printf("Error removing arrow: %v", err)
-	}
-
-	// Добавляем добытый ресурс в инвентарь
-	err = h.db.AddItemToInventory(player.ID, resourceName, 1)
-	if err != nil {
-		log.Printf("Error adding hunted resource: %v", err)
-	}
-
-	// Добавляем опыт охоты
-	expGained := 2
-	levelUp, newLevel, err := h.db.UpdateHuntingExperience(player.ID, expGained)
-	if err != nil {
-		log.Printf("Error updating hunting experience: %v", err)
-	}
-
-	// Получаем количество стрел после охоты
-	arrowsLeft, err := h.db.GetItemQuantityInInventory(player.ID, "Стрелы")
-	if err != nil {
-		log.Printf("Error getting arrows quantity: %v", err)
-		arrowsLeft = 0
-	}
-
-	// Получаем обновленные данные
-	updatedPlayer, _ := h.db.GetPlayer(userID)
-	updatedHunting, _ := h.db.GetOrCreateHunting(player.ID)
-
-	// Удаляем таймер
-	if timer, exists := h.huntingTimers[userID]; exists {
-		timer.Stop()
-		delete(h.huntingTimers, userID)
-	}
-
-	// Обновляем поле охоты (убираем добытый ресурс)
-	if session, exists := h.huntingSessions[userID]; exists {
-		session.Resources[row][col] = ""
-		h.updateHuntingField(chatID, session.Resources, session.FieldMessageID)
-
-		// Проверяем, остались ли ресурсы
-		hasResources := false
-		for i := 0; i < 3; i++ {
-			for j := 0; j < 3; j++ {
-				if session.Resources[i][j] != "" {
-					hasResources = true
-					break
-				}
-			}
-			if hasResources {
-				break
-			}
-		}
-
-		if !hasResources {
-			// Все ресурсы добыты, истощаем охоту
-			err = h.db.ExhaustHunting(userID)
-			if err != nil {
-				log.Printf("Error exhausting hunting: %v", err)
-			}
-
-			// Устанавливаем кулдаун на 1 минуту
-			h.huntingCooldowns[userID] = time.Now().Add(1 * time.Minute)
-
-			// Удаляем сообщение с полем охоты
-			deleteFieldMsg := tgbotapi.NewDeleteMessage(chatID, session.FieldMessageID)
-			h.requestAPI(deleteFieldMsg)
-
-			// Удаляем сообщение с информацией об охоте
-			deleteInfoMsg := tgbotapi.NewDeleteMessage(chatID, session.InfoMessageID)
-			h.requestAPI(deleteInfoMsg)
-
-			// Отправляем сообщение об истощении с клавиатурой леса
-			exhaustMsg := tgbotapi.NewMessage(chatID, `⚠️ Охотничьи угодья истощены! Необходимо подождать 1 минуту до восстановления ресурсов.
-Нажми кнопку "🎯 Охота" чтобы проверить готовность.`)
-			h.sendForestKeyboard(exhaustMsg)
-
-			// Удаляем сессию
-			delete(h.huntingSessions, userID)
-		} else {
-			// Обновляем информационное сообщение с актуальными данными охоты
-			h.updateHuntingInfoMessage(userID, chatID, updatedHunting, session.InfoMessageID)
-		}
-	}
-
-	// Формируем сообщение о результате
-	satietyText := ""
-	if updatedPlayer != nil {
-		satietyText = fmt.Sprintf(`
-🍖 Сытость: %d`, updatedPlayer.Satiety)
-	}
-
-	// Вычисляем опыт до следующего уровня
-	expToNext := 0
-	if updatedHunting != nil {
-		expToNext = (updatedHunting.Level * 100) - updatedHunting.Experience
-	}
-
-	resultText := fmt.Sprintf(`✅ Охота завершена!
-
-Добыто: %s x1
-Опыт охоты: +%d
-До следующего уровня: %d опыта%s`, resourceName, expGained, expToNext, satietyText)
-
-	if levelUp {
-		resultText += fmt.Sprintf(`
-🎉 Уровень охоты повышен до %d!`, newLevel)
-	}
-
-	if newDurability <= 0 {
-		resultText += fmt.Sprintf(`
-💔 Простой лук сломался!
-🏹 Стрел осталось: %d`, arrowsLeft)
-	} else {
-		resultText += fmt.Sprintf(`
-🏹 Прочность лука: %d
-🏹 Стрел осталось: %d`, newDurability, arrowsLeft)
-	}
-
-	// Редактируем сообщение с прогрессом на результат
-	editMsg := tgbotapi.NewEditMessageText(chatID, messageID, resultText)
-	h.editMessage(editMsg)
-
-	// Сохраняем ID сообщения с результатом в сессии
-	if session, exists := h.huntingSessions[userID]; exists {
-		session.ResultMessageID = messageID
-	}
-
-	// Проверяем прогресс квеста 5 (первая охота)
-	h.checkHuntingQuestProgress(userID, chatID, player.ID)
-}
-
-func (h *BotHandlers) updateHuntingField(chatID int64, field [][]string, messageID int) {
-	// Создаем инлайн клавиатуру на основе обновленного поля
-	var keyboard [][]tgbotapi.InlineKeyboardButton
-	for i := 0; i < 3; i++ {
-		var row []tgbotapi.InlineKeyboardButton
-		for j := 0; j < 3; j++ {
-			cell := field[i][j]
-			var callbackData string
-
-			switch cell {
-			case "🐰":
-				callbackData = fmt.Sprintf("hunt_rabbit_%d_%d", i, j)
-			case "🐦":
-				callbackData = fmt.Sprintf("hunt_bird_%d_%d", i, j)
-			default:
-				callbackData = fmt.Sprintf("hunt_empty_%d_%d", i, j)
-				cell = " "
-			}
-
-			button := tgbotapi.NewInlineKeyboardButtonData(cell, callbackData)
-			row = append(row, button)
-		}
-		keyboard = append(keyboard, row)
-	}
-
-	// Редактируем сообщение с полем
-	editMsg := tgbotapi.NewEditMessageReplyMarkup(chatID, messageID, tgbotapi.NewInlineKeyboardMarkup(keyboard...))
-	h.editMessage(editMsg)
-}
-
-func (h *BotHandlers) updateHuntingInfoMessage(userID int64, chatID int64, hunting *models.Hunting, messageID int) {
-	// Вычисляем опыт до следующего уровня
-	expToNext := (hunting.Level * 100) - hunting.Experience
-
-	infoText := fmt.Sprintf(`🎯 Охота (Уровень %d)
-До следующего уровня: %d опыта
-
-Доступные ресурсы:
-🐰 Кролик
-🐦 Куропатка`, hunting.Level, expToNext)
-
-	// Удаляем старое сообщение
-	deleteMsg := tgbotapi.NewDeleteMessage(chatID, messageID)
-	h.requestAPI(deleteMsg)
-
-	// Создаем новое сообщение с клавиатурой
-	huntingKeyboard := tgbotapi.NewReplyKeyboard(
-		tgbotapi.NewKeyboardButtonRow(
-			tgbotapi.NewKeyboardButton("◀️ Назад"),
-		),
-	)
-	huntingKeyboard.ResizeKeyboard = true
-
-	newMsg := tgbotapi.NewMessage(chatID, infoText)
-	newMsg.ReplyMarkup = huntingKeyboard
-	newResponse, _ := h.sendMessageWithResponse(newMsg)
-
-	// Обновляем ID сообщения в сессии
-	if session, exists := h.huntingSessions[userID]; exists {
-		session.InfoMessageID = newResponse.MessageID
-	}
-}
-
-func (h *BotHandlers) handleChopping(message *tgbotapi.Message) {
-	userID := message.From.ID
-
-	// Проверяем, активен ли кулдаун леса
-	if cooldownEnd, exists := h.forestCooldowns[userID]; exists {
-		if time.Now().Before(cooldownEnd) {
-			// Кулдаун еще активен
-			remainingTime := time.Until(cooldownEnd)
-			msg := tgbotapi.NewMessage(message.Chat.ID,
-				fmt.Sprintf("До обновления леса осталось %d сек.", int(remainingTime.Seconds())))
-			h.sendMessage(msg)
-			return
-		} else {
-			// Кулдаун истек, удаляем его
-			delete(h.forestCooldowns, userID)
-		}
-	}
-
-	// Получаем игрока
-	player, err := h.db.GetPlayer(userID)
-	if err != nil {
-		log.Printf("Error getting player: %v", err)
-		msg := tgbotapi.NewMessage(message.Chat.ID, "Сначала зарегистрируйтесь с помощью команды /start")
-		h.sendMessage(msg)
-		return
-	}
-
-	// Проверяем сытость игрока
-	if player.Satiety <= 0 {
-		msg := tgbotapi.NewMessage(message.Chat.ID, "Сытость 0. Необходимо поесть.")
-		h.sendMessage(msg)
-		return
-	}
-
-	// Получаем или создаем лес
-	forest, err := h.db.GetOrCreateForest(player.ID)
-	if err != nil {
-		log.Printf("Error getting forest: %v", err)
-		msg := tgbotapi.NewMessage(message.Chat.ID, "Произошла ошибка при работе с лесом.")
-		h.sendMessage(msg)
-		return
-	}
-
-	// Если лес был истощен в базе данных, восстанавливаем его
-	if forest.IsExhausted {
-		if err := h.db.SetForestExhausted(player.ID, false); err != nil {
-			log.Printf("Error setting forest exhausted: %v", err)
-		}
-		forest.IsExhausted = false
-	}
-
-	// Создаем новую сессию леса
-	h.createNewForestSession(userID, message.Chat.ID, forest)
-}
-
-func (h *BotHandlers) createNewForestSession(userID int64, chatID int64, forest *models.Forest) {
-	// Генерируем случайное поле
-	field := h.generateRandomForestField()
-
-	// Показываем поле и получаем MessageID
-	fieldMessageID, infoMessageID := h.showForestField(chatID, forest, field)
-
-	// Создаем сессию
-	session := &models.ForestSession{
-		PlayerID:       userID,
-		Resources:      field,
-		IsActive:       true,
-		IsChopping:     false,
-		StartedAt:      time.Now(),
-		FieldMessageID: fieldMessageID,
-		InfoMessageID:  infoMessageID,
-	}
-
-	h.forestSessions[userID] = session
-}
-
-func (h *BotHandlers) generateRandomForestField() [][]string {
-	// Создаем пустое поле 3x3
-	field := make([][]string, 3)
-	for i := range field {
-		field[i] = make([]string, 3)
-	}
-
-	// Доступные ресурсы для леса
-	availableResources := []string{"🌳"}
-
-	// Используем время для псевдослучайности
-	now := time.Now()
-	seed := now.UnixNano()
-
-	// Создаем список всех позиций
-	positions := [][2]int{
-		{0, 0}, {0, 1}, {0, 2},
-		{1, 0}, {1, 1}, {1, 2},
-		{2, 0}, {2, 1}, {2, 2},
-	}
-
-	// Перемешиваем позиции с использованием времени
-	for i := len(positions) - 1; i > 0; i-- {
-		j := int((seed + int64(i*13)) % int64(i+1))
-		positions[i], positions[j] = positions[j], positions[i]
-	}
-
-	// Размещаем 3 ресурса в первых 3 позициях
-	for i := 0; i < 3; i++ {
-		pos := positions[i]
-		// Выбираем ресурс псевдослучайно
-		resourceIndex := int((seed + int64(i*17) + int64(pos[0]*3) + int64(pos[1])) % int64(len(availableResources)))
-		resourceType := availableResources[resourceIndex]
-		field[pos[0]][pos[1]] = resourceType
-	}
-
-	return field
-}
-
-func (h *BotHandlers) showForestField(chatID int64, forest *models.Forest, field [][]string) (int, int) {
-	// Создаем инлайн клавиатуру на основе переданного поля
-	var keyboard [][]tgbotapi.InlineKeyboardButton
-	for i := 0; i < 3; i++ {
-		var row []tgbotapi.InlineKeyboardButton
-		for j := 0; j < 3; j++ {
-			cell := field[i][j]
-			var callbackData string
-
-			switch cell {
-			case "🌳":
-				callbackData = fmt.Sprintf("forest_birch_%d_%d", i, j)
-			default:
-				callbackData = fmt.Sprintf("forest_empty_%d_%d", i, j)
-				cell = " "
-			}
-
-			button := tgbotapi.NewInlineKeyboardButtonData(cell, callbackData)
-			row = append(row, button)
-		}
-		keyboard = append(keyboard, row)
-	}
-
-	// Сначала отправляем поле леса с инлайн кнопками
-	fieldMsg := tgbotapi.NewMessage(chatID, "Выберите дерево для рубки:")
-	fieldMsg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(keyboard...)
-	fieldResponse, _ := h.sendChattableWithResponse(fieldMsg)
-
-	// Затем отправляем информационное сообщение с клавиатурой
-	// Вычисляем опыт до следующего уровня
-	expToNext := (forest.Level * 100) - forest.Experience
-
-	infoText := fmt.Sprintf(`🪓 Рубка (Уровень %d)
-До следующего уровня: %d опыта
-
-Доступные ресурсы:
-🌳 Береза`, forest.Level, expToNext)
-
-	forestKeyboard := tgbotapi.NewReplyKeyboard(
-		tgbotapi.NewKeyboardButtonRow(
-			tgbotapi.NewKeyboardButton("◀️ Назад"),
-		),
-	)
-	forestKeyboard.ResizeKeyboard = true
-
-	infoMsg := tgbotapi.NewMessage(chatID, infoText)
-	infoMsg.ReplyMarkup = forestKeyboard
-	infoResponse, _ := h.sendChattableWithResponse(infoMsg)
-
-	// Возвращаем ID поля и ID информационного сообщения
-	return fieldResponse.MessageID, infoResponse.MessageID
-}
-
-func (h *BotHandlers) handleForestGathering(message *tgbotapi.Message) {
-	userID := message.From.ID
-
-	// Проверяем, активен ли кулдаун сбора
-	if cooldownEnd, exists := h.gatheringCooldowns[userID]; exists {
-		if time.Now().Before(cooldownEnd) {
-			// Кулдаун еще активен
-			remainingTime := time.Until(cooldownEnd)
-			msg := tgbotapi.NewMessage(message.Chat.ID,
-				fmt.Sprintf("До обновления ягодных кустов осталось %d сек.", int(remainingTime.Seconds())))
-			h.sendMessage(msg)
-			return
-		} else {
-			// Кулдаун истек, удаляем его
-			delete(h.gatheringCooldowns, userID)
-		}
-	}
-
-	// Получаем игрока
-	player, err := h.db.GetPlayer(userID)
-	if err != nil {
-		log.Printf("Error getting player: %v", err)
-		msg := tgbotapi.NewMessage(message.Chat.ID, "Сначала зарегистрируйтесь с помощью команды /start")
-		h.sendMessage(msg)
-		return
-	}
-
-	// Проверяем сытость игрока
-	if player.Satiety <= 0 {
-		msg := tgbotapi.NewMessage(message.Chat.ID, "Сытость 0. Необходимо поесть.")
-		h.sendMessage(msg)
-		return
-	}
-
-	// Получаем или создаем данные о сборе
-	gathering, err := h.db.GetOrCreateGathering(player.ID)
-	if err != nil {
-		log.Printf("Error getting gathering: %v", err)
-		msg := tgbotapi.NewMessage(message.Chat.ID, "Произошла ошибка при работе со сбором.")
-		h.sendMessage(msg)
-		return
-	}
-
-	// Если сбор был истощен в базе данных, восстанавливаем его
-	if gathering.IsExhausted {
-		if err := h.db.SetGatheringExhausted(player.ID, false); err != nil {
-			log.Printf("Error setting gathering exhausted: %v", err)
-		}
-	}
-
-	// Создаем новую сессию сбора
-	h.createNewGatheringSession(userID, message.Chat.ID)
-}
-
-func (h *BotHandlers) createNewGatheringSession(userID int64, chatID int64) {
-	// Получаем игрока
-	player, err := h.db.GetPlayer(userID)
-	if err != nil {
-		log.Printf("Error getting player in createNewGatheringSession: %v", err)
-		return
-	}
-
-	// Получаем или создаем данные о сборе
-	gathering, err := h.db.GetOrCreateGathering(player.ID)
-	if err != nil {
-		log.Printf("Error getting gathering in createNewGatheringSession: %v", err)
-		return
-	}
-
-	// Генерируем случайное поле
-	field := h.generateRandomGatheringField()
-
-	// Показываем поле и получаем MessageID
-	fieldMessageID, infoMessageID := h.showGatheringField(chatID, field, gathering)
-
-	// Создаем сессию
-	session := &models.GatheringSession{
-		PlayerID:       userID,
-		Resources:      field,
-		IsActive:       true,
-		StartedAt:      time.Now(),
-		FieldMessageID: fieldMessageID,
-		InfoMessageID:  infoMessageID,
-	}
-
-	h.gatheringSessions[userID] = session
-}
-
-func (h *BotHandlers) generateRandomGatheringField() [][]string {
-	// Создаем пустое поле 3x3
-	field := make([][]string, 3)
-	for i := range field {
-		field[i] = make([]string, 3)
-	}
-
-	// Доступные ресурсы для сбора
-	availableResources := []string{"🍇"}
-
-	// Используем время для псевдослучайности
-	now := time.Now()
-	seed := now.UnixNano()
-
-	// Создаем список всех позиций
-	positions := [][2]int{
-		{0, 0}, {0, 1}, {0, 2},
-		{1, 0}, {1, 1}, {1, 2},
-		{2, 0}, {2, 1}, {2, 2},
-	}
-
-	// Перемешиваем позиции с использованием времени
-	for i := len(positions) - 1; i > 0; i-- {
-		j := int((seed + int64(i*13)) % int64(i+1))
-		positions[i], positions[j] = positions[j], positions[i]
-	}
-
-	// Размещаем 3 ресурса в первых 3 позициях
-	for i := 0; i < 3; i++ {
-		pos := positions[i]
-		// Выбираем ресурс псевдослучайно
-		resourceIndex := int((seed + int64(i*17) + int64(pos[0]*3) + int64(pos[1])) % int64(len(availableResources)))
-		resourceType := availableResources[resourceIndex]
-		field[pos[0]][pos[1]] = resourceType
-	}
-
-	return field
-}
-
-func (h *BotHandlers) showGatheringField(chatID int64, field [][]string, gathering *models.Gathering) (int, int) {
-	// Создаем инлайн клавиатуру на основе переданного поля
-	var keyboard [][]tgbotapi.InlineKeyboardButton
-	for i := 0; i < 3; i++ {
-		var row []tgbotapi.InlineKeyboardButton
-		for j := 0; j < 3; j++ {
-			cell := field[i][j]
-			var callbackData string
-
-			switch cell {
-			case "🍇":
-				callbackData = fmt.Sprintf("gathering_berry_%d_%d", i, j)
-			default:
-				callbackData = fmt.Sprintf("gathering_empty_%d_%d", i, j)
-				cell = " "
-			}
-
-			button := tgbotapi.NewInlineKeyboardButtonData(cell, callbackData)
-			row = append(row, button)
-		}
-		keyboard = append(keyboard, row)
-	}
-
-	// Сначала отправляем поле сбора с инлайн кнопками
-	fieldMsg := tgbotapi.NewMessage(chatID, "Выберите ресурс для сбора:")
-	fieldMsg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(keyboard...)
-	fieldResponse, _ := h.sendChattableWithResponse(fieldMsg)
-
-	// Затем отправляем информационное сообщение с клавиатурой
-	// Вычисляем опыт до следующего уровня
-	expToNext := (gathering.Level * 100) - gathering.Experience
-
-	infoText := fmt.Sprintf(`🌿 Сбор (Уровень %d)
-До следующего уровня: %d опыта
-
-Доступные ресурсы:
-🍇 Ягоды`, gathering.Level, expToNext)
-
-	gatheringKeyboard := tgbotapi.NewReplyKeyboard(
-		tgbotapi.NewKeyboardButtonRow(
-			tgbotapi.NewKeyboardButton("◀️ Назад"),
-		),
-	)
-	gatheringKeyboard.ResizeKeyboard = true
-
-	infoMsg := tgbotapi.NewMessage(chatID, infoText)
-	infoMsg.ReplyMarkup = gatheringKeyboard
-	infoResponse, _ := h.sendChattableWithResponse(infoMsg)
-
-	// Возвращаем ID поля и ID информационного сообщения
-	return fieldResponse.MessageID, infoResponse.MessageID
-}
-
-func (h *BotHandlers) handleCreateAxe(message *tgbotapi.Message) {
-	h.showRecipe(message, "Простой топор")
-}
+// forestText - описание леса в его меню
+const forestText = `🌲 Ты входишь в густой лес. Под ногами хрустит трава, в кронах поют птицы, а где-то вдалеке слышен треск ветки — ты здесь не один...
 
-func (h *BotHandlers) handleCreatePickaxe(message *tgbotapi.Message) {
-	h.showRecipe(message, "Простая кирка")
-}
-
-func (h *BotHandlers) handleCreateBow(message *tgbotapi.Message) {
-	h.showRecipe(message, "Простой лук")
-}
+Здесь ты можешь:
+🪓 Рубить деревья  
+🎯 Охотиться на дичь  
+🌿 Собирать травы и ягоды`
 
-func (h *BotHandlers) handleCreateArrows(message *tgbotapi.Message) {
-	h.showRecipe(message, "Стрелы")
-}
+func (h *BotHandlers) handleForest(message *tgbotapi.Message) {
+	userID := message.From.ID
 
-func (h *BotHandlers) handleCreateKnife(message *tgbotapi.Message) {
-	h.showRecipe(message, "Простой нож")
-}
+	// Устанавливаем местоположение игрока
+	h.playerState(userID).Location = "forest"
 
-func (h *BotHandlers) handleCreateFishingRod(message *tgbotapi.Message) {
-	h.showRecipe(message, "Простая удочка")
+	msg := tgbotapi.NewMessage(message.Chat.ID, forestText)
+	h.sendForestKeyboard(msg)
 }
 
-func (h *BotHandlers) handleCreateBirchPlank(message *tgbotapi.Message) {
+// handleCreate показывает рецепт по ключу из команды /create_<ключ>
+func (h *BotHandlers) handleCreate(message *tgbotapi.Message, recipeKey string) {
 	userID := message.From.ID
 
 	// Получаем игрока
@@ -1934,87 +964,48 @@ func (h *BotHandlers) handleCreateBirchPlank(message *tgbotapi.Message) {
 		return
 	}
 
-	// Проверяем количество березы в инвентаре
-	birchQuantity, err := h.db.GetItemQuantityInInventory(player.ID, "Береза")
-	if err != nil {
-		log.Printf("Error getting birch quantity: %v", err)
-		birchQuantity = 0
-	}
-
-	// Показываем рецепт с кнопкой
-	recipeText := fmt.Sprintf(`Для изготовления предмета "Березовый брус" необходимо следующее:
-Береза - %d/%d шт.`, birchQuantity, 2)
-
-	// Проверяем, можно ли создать хотя бы один предмет
-	canCraft := birchQuantity >= 2
-	var buttonText string
-	if canCraft {
-		buttonText = "Создать ✅"
-	} else {
-		buttonText = "Создать ❌"
-	}
-
-	msg := tgbotapi.NewMessage(message.Chat.ID, recipeText)
-
-	// Создаем инлайн клавиатуру с кнопкой создать
-	keyboard := tgbotapi.NewInlineKeyboardMarkup(
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData(buttonText, "craft_Березовый брус"),
-		),
-	)
-	msg.ReplyMarkup = keyboard
-	h.sendMessage(msg)
-}
-
-func (h *BotHandlers) handleCreateSimpleHut(message *tgbotapi.Message) {
-	userID := message.From.ID
-
-	// Получаем игрока
-	player, err := h.db.GetPlayer(userID)
+	// Получаем рецепт
+	recipe, err := h.db.GetRecipe(recipeKey)
 	if err != nil {
-		log.Printf("Error getting player: %v", err)
-		msg := tgbotapi.NewMessage(message.Chat.ID, "Сначала зарегистрируйтесь с помощью команды /start")
+		log.Printf("Error getting recipe: %v", err)
+		msg := tgbotapi.NewMessage(message.Chat.ID, "Ошибка получения рецепта.")
 		h.sendMessage(msg)
 		return
 	}
-
-	// Определяем требования для строительства простой хижины
-	requirements := []struct {
-		ItemName string
-		Quantity int
-	}{
-		{"Береза", 20},
-		{"Березовый брус", 10},
-		{"Камень", 15},
-		{"Лесная ягода", 10},
+	if recipe == nil {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "Неизвестная команда. Используйте /start для начала игры.")
+		h.sendMessage(msg)
+		return
 	}
 
 	// Формируем текст рецепта
-	recipeText := "Для строительства необходимо следующее:"
-	canBuild := true
+	var recipeText string
+	if recipe.Station == catalog.StationConstruction {
+		recipeText = "Для строительства необходимо следующее:"
+	} else {
+		recipeText = fmt.Sprintf(`Для изготовления предмета "%s" необходимо следующее:`, recipe.ItemName)
+	}
 
-	for _, req := range requirements {
-		playerQuantity, err := h.db.GetItemQuantityInInventory(player.ID, req.ItemName)
+	for _, ingredient := range recipe.Ingredients {
+		playerQuantity, err := h.db.GetItemQuantityInInventory(player.ID, ingredient.ItemName)
 		if err != nil {
 			log.Printf("Error getting inventory quantity: %v", err)
 			playerQuantity = 0
 		}
 
-		if playerQuantity < req.Quantity {
-			canBuild = false
-		}
-
-		recipeText += fmt.Sprintf("\n%s - %d/%d шт.", req.ItemName, playerQuantity, req.Quantity)
+		recipeText += fmt.Sprintf("\n%s - %d/%d шт.", ingredient.ItemName, playerQuantity, ingredient.Quantity)
+	}
+	if recipe.Fuel > 0 {
+		_, units := h.playerFuel(player.ID, nil)
+		recipeText += fmt.Sprintf("\n%s - %d/%d ед.", fuelName, units, recipe.Fuel)
 	}
 
 	// Добавляем кнопку "Создать"
-	var buttonText, callbackData string
-	if canBuild {
+	var buttonText string
+	if crafts, _ := h.availableCrafts(player.ID, recipe); crafts > 0 {
 		buttonText = "Создать ✅"
-		callbackData = "craft_Простая хижина"
 	} else {
 		buttonText = "Создать ❌"
-		callbackData = "no_craft_Простая хижина"
 	}
 
 	msg := tgbotapi.NewMessage(message.Chat.ID, recipeText)
@@ -2022,70 +1013,34 @@ func (h *BotHandlers) handleCreateSimpleHut(message *tgbotapi.Message) {
 	// Создаем инлайн клавиатуру с кнопкой создать
 	keyboard := tgbotapi.NewInlineKeyboardMarkup(
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData(buttonText, callbackData),
+			tgbotapi.NewInlineKeyboardButtonData(buttonText, "craft_"+recipe.Key),
 		),
 	)
 	msg.ReplyMarkup = keyboard
 	h.sendMessage(msg)
 }
 
-func (h *BotHandlers) showRecipe(message *tgbotapi.Message, itemName string) {
-	userID := message.From.ID
-
-	// Получаем игрока
-	player, err := h.db.GetPlayer(userID)
-	if err != nil {
-		log.Printf("Error getting player: %v", err)
-		msg := tgbotapi.NewMessage(message.Chat.ID, "Сначала зарегистрируйтесь с помощью команды /start")
-		h.sendMessage(msg)
-		return
-	}
-
-	// Получаем рецепт
-	recipe, err := h.db.GetRecipeRequirements(itemName)
-	if err != nil {
-		log.Printf("Error getting recipe: %v", err)
-		msg := tgbotapi.NewMessage(message.Chat.ID, "Ошибка получения рецепта.")
-		h.sendMessage(msg)
-		return
-	}
-
-	// Формируем текст рецепта
-	recipeText := fmt.Sprintf(`Для изготовления предмета "%s" необходимо следующее:`, itemName)
-	canCraft := true
-
-	for _, ingredient := range recipe {
-		playerQuantity, err := h.db.GetItemQuantityInInventory(player.ID, ingredient.ItemName)
+// availableCrafts возвращает, сколько раз игрок может выполнить рецепт,
+// и ингредиент, которого не хватает на следующее создание
+func (h *BotHandlers) availableCrafts(playerID int, recipe *models.Recipe) (int, string) {
+	crafts, missing := -1, ""
+	for _, ingredient := range recipe.Ingredients {
+		playerQuantity, err := h.db.GetItemQuantityInInventory(playerID, ingredient.ItemName)
 		if err != nil {
 			log.Printf("Error getting inventory quantity: %v", err)
 			playerQuantity = 0
 		}
 
-		if playerQuantity < ingredient.Quantity {
-			canCraft = false
+		if n := playerQuantity / ingredient.Quantity; crafts < 0 || n < crafts {
+			crafts, missing = n, ingredient.ItemName
 		}
-
-		recipeText += fmt.Sprintf("\n%s - %d/%d шт.", ingredient.ItemName, playerQuantity, ingredient.Quantity)
 	}
-
-	// Добавляем кнопку "Создать"
-	var buttonText string
-	if canCraft {
-		buttonText = "Создать ✅"
-	} else {
-		buttonText = "Создать ❌"
+	if recipe.Fuel > 0 {
+		if _, units := h.playerFuel(playerID, nil); units/recipe.Fuel < crafts {
+			crafts, missing = units/recipe.Fuel, fuelName
+		}
 	}
-
-	msg := tgbotapi.NewMessage(message.Chat.ID, recipeText)
-
-	// Создаем инлайн клавиатуру с кнопкой создать
-	keyboard := tgbotapi.NewInlineKeyboardMarkup(
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData(buttonText, fmt.Sprintf("craft_%s", itemName)),
-		),
-	)
-	msg.ReplyMarkup = keyboard
-	h.sendMessage(msg)
+	return max(crafts, 0), missing
 }
 
 func (h *BotHandlers) handleCallbackQuery(callback *tgbotapi.CallbackQuery) {
@@ -2254,455 +1209,129 @@ func (h *BotHandlers) handleCallbackQuery(callback *tgbotapi.CallbackQuery) {
 		callbackConfig := tgbotapi.NewCallback(callback.ID, "")
 		h.requestAPI(callbackConfig)
 
-		// Проверяем прогресс квеста 6 после успешного чтения страницы
-		h.checkLorePagesQuestProgressSequential(userID, callback.Message.Chat.ID, player.ID, targetPage)
+		events.Publish(h.bus, events.PageRead{Player: playerRef(userID, player.ID, callback.Message.Chat.ID), Page: targetPage})
 
 		return
 	}
 
 	// Обрабатываем остальные callback'и
-	if strings.HasPrefix(data, "mine_") {
-		parts := strings.Split(data, "_")
-		if len(parts) == 4 {
-			row, col := parts[2], parts[3]
-			h.startMiningAtPosition(userID, callback.Message.Chat.ID, "Камень", 10, callback.ID, row, col)
-		}
-	} else if strings.HasPrefix(data, "mine_coal_") {
-		parts := strings.Split(data, "_")
-		if len(parts) == 4 {
-			row, col := parts[2], parts[3]
-			h.startMiningAtPosition(userID, callback.Message.Chat.ID, "Уголь", 20, callback.ID, row, col)
-		}
-	} else if strings.HasPrefix(data, "mine_empty_") {
-		// Пустая ячейка
-		callbackConfig := tgbotapi.NewCallback(callback.ID, "Здесь нет ресурсов!")
-		h.requestAPI(callbackConfig)
-	} else if strings.HasPrefix(data, "forest_birch_") {
-		// Обрабатываем callback'и от леса
-		parts := strings.Split(data, "_")
-		if len(parts) == 4 {
-			row, col := parts[2], parts[3]
-			h.startChoppingAtPosition(userID, callback.Message.Chat.ID, "Береза", 10, callback.ID, row, col)
-		}
-	} else if strings.HasPrefix(data, "forest_empty_") {
-		// Пустая ячейка
-		callbackConfig := tgbotapi.NewCallback(callback.ID, "Здесь нет деревьев!")
-		h.requestAPI(callbackConfig)
-	} else if strings.HasPrefix(data, "gathering_berry_") {
-		// Обрабатываем callback'и от сбора
-		parts := strings.Split(data, "_")
-		if len(parts) == 4 {
-			row, col := parts[2], parts[3]
-			h.startGatheringAtPosition(userID, callback.Message.Chat.ID, "Лесная ягода", 10, callback.ID, row, col)
-		}
-	} else if strings.HasPrefix(data, "gathering_empty_") {
-		// Пустая ячейка
-		callbackConfig := tgbotapi.NewCallback(callback.ID, "Здесь нет ягод!")
-		h.requestAPI(callbackConfig)
-	} else if strings.HasPrefix(data, "hunt_rabbit_") {
-		// Обрабатываем callback'и от охоты на кролика
-		parts := strings.Split(data, "_")
-		if len(parts) == 4 {
-			row, col := parts[2], parts[3]
-			h.startHuntingAtPosition(userID, callback.Message.Chat.ID, "Кролик", 20, callback.ID, row, col)
-		}
-	} else if strings.HasPrefix(data, "hunt_bird_") {
-		// Обрабатываем callback'и от охоты на куропатку
-		parts := strings.Split(data, "_")
-		if len(parts) == 4 {
-			row, col := parts[2], parts[3]
-			h.startHuntingAtPosition(userID, callback.Message.Chat.ID, "Куропатка", 20, callback.ID, row, col)
-		}
-	} else if strings.HasPrefix(data, "hunt_empty_") {
-		// Пустая ячейка
-		callbackConfig := tgbotapi.NewCallback(callback.ID, "Здесь нет добычи!")
-		h.requestAPI(callbackConfig)
+	if def, ok := locationByCallback(data); ok {
+		// Клетка поля локации: <префикс>_<ресурс>_<строка>_<столбец>
+		h.handleLocationCallback(userID, callback.Message.Chat.ID, def, data, callback.ID)
+	} else if strings.HasPrefix(data, "eat_") {
+		// Съедаем выбранную еду
+		foodKey := strings.TrimPrefix(data, "eat_")
+		h.handleEatCallback(userID, callback.Message.Chat.ID, foodKey, callback.ID, callback.Message.MessageID)
+	} else if data == "furnace_collect" {
+		// Забираем готовые партии из печи
+		h.handleFurnaceCollect(userID, callback.Message.Chat.ID, callback.ID)
 	} else if strings.HasPrefix(data, "craft_") {
-		// Обрабатываем крафт
-		itemName := strings.TrimPrefix(data, "craft_")
-		h.handleCraftCallback(userID, callback.Message.Chat.ID, itemName, callback.ID)
+		// Обрабатываем крафт по ключу рецепта
+		recipeKey := strings.TrimPrefix(data, "craft_")
+		h.handleCraftCallback(userID, callback.Message.Chat.ID, recipeKey, callback.ID)
 	} else if strings.HasPrefix(data, "quest_accept_") {
 		// Принятие квеста
 		questIDStr := strings.TrimPrefix(data, "quest_accept_")
 		questID, _ := strconv.Atoi(questIDStr)
-		h.handleQuestAccept(userID, callback.Message.Chat.ID, questID, callback.ID, callback.Message.MessageID)
-	} else if strings.HasPrefix(data, "quest_decline_") {
-		// Отказ от квеста
-		h.handleQuestDecline(callback.Message.Chat.ID, callback.ID, callback.Message.MessageID)
-	} else if strings.HasPrefix(data, "quest_accept_6") {
-		// Принятие квеста 6
-		questIDStr := strings.TrimPrefix(data, "quest_accept_")
-		questID, _ := strconv.Atoi(questIDStr)
-		h.handleQuestAccept(userID, callback.Message.Chat.ID, questID, callback.ID, callback.Message.MessageID)
-	} else if strings.HasPrefix(data, "quest_decline_6") {
-		// Отказ от квеста 6
-		h.handleQuestDecline(callback.Message.Chat.ID, callback.ID, callback.Message.MessageID)
-	} else if strings.HasPrefix(data, "quest_accept_7") {
-		// Принятие квеста 7
-		questIDStr := strings.TrimPrefix(data, "quest_accept_")
-		questID, _ := strconv.Atoi(questIDStr)
-		h.handleQuestAccept(userID, callback.Message.Chat.ID, questID, callback.ID, callback.Message.MessageID)
-	} else if strings.HasPrefix(data, "quest_decline_7") {
-		// Отказ от квеста 7
-		h.handleQuestDecline(callback.Message.Chat.ID, callback.ID, callback.Message.MessageID)
-	} else if strings.HasPrefix(data, "quest_accept_8") {
-		// Принятие квеста 8
-		questIDStr := strings.TrimPrefix(data, "quest_accept_")
-		questID, _ := strconv.Atoi(questIDStr)
-		h.handleQuestAccept(userID, callback.Message.Chat.ID, questID, callback.ID, callback.Message.MessageID)
-	} else if strings.HasPrefix(data, "quest_decline_8") {
-		// Отказ от квеста 8
-		h.handleQuestDecline(callback.Message.Chat.ID, callback.ID, callback.Message.MessageID)
-	} else {
-		// Остальные callback
-		// msg := tgbotapi.NewMessage(callback.Message.Chat.ID, "🔨 Функция пока в разработке...")
-		// h.sendMessage(msg)
-		// callbackConfig := tgbotapi.NewCallback(callback.ID, "")
-		// h.requestAPI(callbackConfig)
-	}
-}
-
-func (h *BotHandlers) handleQuestAccept(userID int64, chatID int64, questID int, callbackID string, messageID int) {
-	// Получаем игрока
-	player, err := h.db.GetPlayer(userID)
-	if err != nil {
-		log.Printf("Error getting player: %v", err)
-		callbackConfig := tgbotapi.NewCallback(callbackID, "Ошибка получения данных игрока")
-		h.requestAPI(callbackConfig)
-		return
-	}
-
-	// Активируем квест
-	err = h.db.UpdateQuestStatus(player.ID, questID, "active")
-	if err != nil {
-		log.Printf("Error updating quest status: %v", err)
-		callbackConfig := tgbotapi.NewCallback(callbackID, "Ошибка активации квеста")
-		h.requestAPI(callbackConfig)
-		return
-	}
-
-	// Отвечаем на callback
-	callbackConfig := tgbotapi.NewCallback(callbackID, "Квест принят!")
-	h.requestAPI(callbackConfig)
-
-	// Удаляем сообщение с предложением квеста
-	deleteMsg := tgbotapi.NewDeleteMessage(chatID, messageID)
-	h.requestAPI(deleteMsg)
-}
-
-func (h *BotHandlers) handleQuestDecline(chatID int64, callbackID string, messageID int) {
-	// Отвечаем на callback
-	callbackConfig := tgbotapi.NewCallback(callbackID, "Квест отклонен")
-	h.requestAPI(callbackConfig)
-
-	// Удаляем сообщение с предложением квеста
-	deleteMsg := tgbotapi.NewDeleteMessage(chatID, messageID)
-	h.requestAPI(deleteMsg)
-}
-
-func (h *BotHandlers) handleCraftCallback(userID int64, chatID int64, itemName string, callbackID string) {
-	// Получаем игрока
-	player, err := h.db.GetPlayer(userID)
-	if err != nil {
-		log.Printf("Error getting player: %v", err)
-		callbackConfig := tgbotapi.NewCallback(callbackID, "Ошибка получения данных игрока")
-		h.requestAPI(callbackConfig)
-		return
-	}
-
-	// Обрабатываем крафт березового бруса
-	if itemName == "Березовый брус" {
-		// Проверяем количество березы
-		birchQuantity, err := h.db.GetItemQuantityInInventory(player.ID, "Береза")
-		if err != nil {
-			log.Printf("Error getting birch quantity: %v", err)
-			birchQuantity = 0
-		}
-
-		if birchQuantity < 2 {
-			callbackConfig := tgbotapi.NewCallback(callbackID, `Недостаточно предмета "Береза"`)
-			h.requestAPI(callbackConfig)
-			return
-		}
-
-		// Отвечаем на callback
-		callbackConfig := tgbotapi.NewCallback(callbackID, "")
-		h.requestAPI(callbackConfig)
-
-		// Спрашиваем количество
-		msg := tgbotapi.NewMessage(chatID, "Введи сколько предметов хочешь создать:")
-		h.sendMessage(msg)
-
-		// Отмечаем, что ждем количество для крафта
-		h.waitingForCraftQuantity[userID] = itemName
-	} else {
-		// Для других предметов пока заглушка
-		callbackConfig := tgbotapi.NewCallback(callbackID, "Функция пока в разработке")
-		h.requestAPI(callbackConfig)
-	}
-}
-
-func (h *BotHandlers) startMiningAtPosition(userID int64, chatID int64, resourceName string, duration int, callbackID string, rowStr, colStr string) {
-	row, _ := strconv.Atoi(rowStr)
-	col, _ := strconv.Atoi(colStr)
-
-	h.startMining(userID, chatID, resourceName, duration, callbackID, row, col)
-}
-
-func (h *BotHandlers) startMining(userID int64, chatID int64, resourceName string, duration int, callbackID string, row, col int) {
-	// Проверяем, идет ли уже добыча в шахте, рубка в лесу или крафт
-	if _, exists := h.miningTimers[userID]; exists {
-		msg := tgbotapi.NewMessage(chatID, "Нельзя начинать новую добычу, пока не закончена текущая.")
-		h.sendMessage(msg)
-		callbackConfig := tgbotapi.NewCallback(callbackID, "")
-		h.requestAPI(callbackConfig)
-		return
-	}
-	if _, exists := h.choppingTimers[userID]; exists {
-		msg := tgbotapi.NewMessage(chatID, "Нельзя начинать новую добычу, пока не закончена текущая.")
-		h.sendMessage(msg)
-		callbackConfig := tgbotapi.NewCallback(callbackID, "")
-		h.requestAPI(callbackConfig)
-		return
-	}
-	if _, exists := h.craftingTimers[userID]; exists {
-		msg := tgbotapi.NewMessage(chatID, "Нельзя совершать действия пока идет создание предметов.")
-		h.sendMessage(msg)
-		callbackConfig := tgbotapi.NewCallback(callbackID, "")
-		h.requestAPI(callbackConfig)
-		return
+		h.handleQuestAccept(userID, callback.Message.Chat.ID, questID, callback.ID, callback.Message.MessageID)
+	} else if strings.HasPrefix(data, "quest_decline_") {
+		// Отказ от квеста
+		h.handleQuestDecline(callback.Message.Chat.ID, callback.ID, callback.Message.MessageID)
+	} else {
+		// Остальные callback
+		// msg := tgbotapi.NewMessage(callback.Message.Chat.ID, "🔨 Функция пока в разработке...")
+		// h.sendMessage(msg)
+		// callbackConfig := tgbotapi.NewCallback(callback.ID, "")
+		// h.requestAPI(callbackConfig)
 	}
+}
 
+func (h *BotHandlers) handleQuestAccept(userID int64, chatID int64, questID int, callbackID string, messageID int) {
 	// Получаем игрока
 	player, err := h.db.GetPlayer(userID)
 	if err != nil {
 		log.Printf("Error getting player: %v", err)
+		callbackConfig := tgbotapi.NewCallback(callbackID, "Ошибка получения данных игрока")
+		h.requestAPI(callbackConfig)
 		return
 	}
 
-	// Проверяем наличие кирки
-	hasTool, durability, err := h.db.HasToolInInventory(player.ID, "Простая кирка")
+	// Активируем квест
+	err = h.db.UpdateQuestStatus(player.ID, questID, "active")
 	if err != nil {
-		log.Printf("Error checking tool: %v", err)
-		return
-	}
-
-	if !hasTool {
-		msg := tgbotapi.NewMessage(chatID, `В инвентаре нет предмета "Простая кирка".`)
-		h.sendMessage(msg)
-		callbackConfig := tgbotapi.NewCallback(callbackID, "")
+		log.Printf("Error updating quest status: %v", err)
+		callbackConfig := tgbotapi.NewCallback(callbackID, "Ошибка активации квеста")
 		h.requestAPI(callbackConfig)
 		return
 	}
 
 	// Отвечаем на callback
-	callbackConfig := tgbotapi.NewCallback(callbackID, "")
+	callbackConfig := tgbotapi.NewCallback(callbackID, "Квест принят!")
 	h.requestAPI(callbackConfig)
 
-	// Удаляем предыдущее сообщение о результате добычи, если оно существует
-	if session, exists := h.mineSessions[userID]; exists && session.ResultMessageID != 0 {
-		deleteResultMsg := tgbotapi.NewDeleteMessage(chatID, session.ResultMessageID)
-		h.requestAPI(deleteResultMsg)
-		session.ResultMessageID = 0 // Сбрасываем ID
-	}
-
-	// Отправляем сообщение о начале добычи (не удаляем информационное сообщение)
-	initialText := fmt.Sprintf(`Началась добыча ресурса "%s". Время добычи %d сек.
-		
-%s 0%%`, resourceName, duration, h.createProgressBar(0, 10))
-
-	miningMsg := tgbotapi.NewMessage(chatID, initialText)
-	sentMsg, _ := h.sendMessageWithResponse(miningMsg)
+	// Удаляем сообщение с предложением квеста
+	deleteMsg := tgbotapi.NewDeleteMessage(chatID, messageID)
+	h.requestAPI(deleteMsg)
+}
 
-	// Запускаем горутину для обновления прогресс бара
-	go h.updateMiningProgress(userID, chatID, sentMsg.MessageID, resourceName, duration, durability, row, col)
+func (h *BotHandlers) handleQuestDecline(chatID int64, callbackID string, messageID int) {
+	// Отвечаем на callback
+	callbackConfig := tgbotapi.NewCallback(callbackID, "Квест отклонен")
+	h.requestAPI(callbackConfig)
 
-	// Создаем заглушку таймера (основная логика теперь в updateMiningProgress)
-	timer := time.NewTimer(time.Duration(duration) * time.Second)
-	h.miningTimers[userID] = timer
+	// Удаляем сообщение с предложением квеста
+	deleteMsg := tgbotapi.NewDeleteMessage(chatID, messageID)
+	h.requestAPI(deleteMsg)
 }
 
-func (h *BotHandlers) completeMining(userID int64, chatID int64, resourceName string, oldDurability int, messageID int, row, col int) {
+func (h *BotHandlers) handleCraftCallback(userID int64, chatID int64, recipeKey string, callbackID string) {
 	// Получаем игрока
 	player, err := h.db.GetPlayer(userID)
 	if err != nil {
 		log.Printf("Error getting player: %v", err)
+		callbackConfig := tgbotapi.NewCallback(callbackID, "Ошибка получения данных игрока")
+		h.requestAPI(callbackConfig)
 		return
 	}
 
-	// Добавляем ресурс в инвентарь
-	if err := h.db.AddItemToInventory(player.ID, resourceName, 1); err != nil {
-		log.Printf("Error adding item to inventory: %v", err)
-	}
-
-	// Проверяем квест на добычу камня
-	if resourceName == "Камень" {
-		h.checkStoneQuestProgress(userID, chatID, player.ID)
-	}
-
-	// Обновляем прочность кирки и сытость (при добыче игрок тратит энергию)
-	if err := h.db.UpdateItemDurability(player.ID, "Простая кирка", 1); err != nil {
-		log.Printf("Error updating item durability: %v", err)
-	}
-	if err := h.db.UpdatePlayerSatiety(player.ID, -1); err != nil {
-		log.Printf("Error updating player satiety: %v", err)
-	}
-
-	// Добавляем опыт шахте и проверяем повышение уровня
-	levelUp, newLevel, err := h.db.UpdateMineExperience(player.ID, 2)
-	if err != nil {
-		log.Printf("Error updating mine experience: %v", err)
+	recipe, err := h.db.GetRecipe(recipeKey)
+	if err != nil || recipe == nil {
+		log.Printf("Error getting recipe %q: %v", recipeKey, err)
+		callbackConfig := tgbotapi.NewCallback(callbackID, "Рецепт не найден")
+		h.requestAPI(callbackConfig)
 		return
 	}
 
-	// Получаем обновленные данные
-	updatedPlayer, _ := h.db.GetPlayer(userID)
-	mine, _ := h.db.GetOrCreateMine(player.ID)
-
-	// Удаляем сообщение о добыче
-	deleteMsg := tgbotapi.NewDeleteMessage(chatID, messageID)
-	h.requestAPI(deleteMsg)
-
-	// Показываем результат
-	resultText := fmt.Sprintf(`✅ Ты добыл %s!
-Получено опыта: 2
-Сытость: %d/100
-Прочность кирки: %d/100
-До следующего уровня: %d опыта`,
-		resourceName,
-		updatedPlayer.Satiety,
-		oldDurability-1,
-		(mine.Level*100)-mine.Experience)
-
-	msg := tgbotapi.NewMessage(chatID, resultText)
-	resultResponse, _ := h.sendMessageWithResponse(msg)
-
-	// Если уровень повысился, показываем сообщение о повышении
-	if levelUp {
-		levelUpText := fmt.Sprintf("🎉 Поздравляем! Уровень шахты повышен до %d уровня!", newLevel)
-		levelUpMsg := tgbotapi.NewMessage(chatID, levelUpText)
-		h.sendMessage(levelUpMsg)
-	}
-
-	// Сохраняем ID сообщения с результатом в сессии
-	if session, exists := h.mineSessions[userID]; exists {
-		session.ResultMessageID = resultResponse.MessageID
+	// Постройку можно возвести только один раз
+	if recipe.Station == catalog.StationConstruction && h.isBuilt(player, recipe.Key) {
+		callbackConfig := tgbotapi.NewCallback(callbackID, fmt.Sprintf(`Объект "%s" уже построен`, recipe.ItemName))
+		h.requestAPI(callbackConfig)
+		return
 	}
 
-	// Кнопка "Назад" остается активной, не нужно восстанавливать
-
-	// Убираем таймер
-	delete(h.miningTimers, userID)
-
-	// Обновляем поле - убираем добытый ресурс
-	if session, exists := h.mineSessions[userID]; exists {
-		session.Resources[row][col] = ""
-
-		// Проверяем, остались ли ресурсы в поле
-		totalResources := 0
-		for i := 0; i < 3; i++ {
-			for j := 0; j < 3; j++ {
-				if session.Resources[i][j] != "" {
-					totalResources++
-				}
-			}
-		}
-
-		if totalResources > 0 {
-			// Обновляем инлайн клавиатуру с новым состоянием поля
-			h.updateMineField(chatID, session.Resources, session.FieldMessageID)
-			// Обновляем информационное сообщение с актуальными данными
-			h.updateMineInfoMessage(userID, chatID, mine, session.InfoMessageID)
-		} else {
-			// Поле истощено, устанавливаем кулдаун
-			if err := h.db.ExhaustMine(userID); err != nil {
-				log.Printf("Error exhausting mine: %v", err)
-			}
-
-			// Устанавливаем таймер кулдауна на 60 секунд
-			h.mineCooldowns[userID] = time.Now().Add(60 * time.Second)
-
-			// Удаляем сообщение с полем шахты
-			deleteFieldMsg := tgbotapi.NewDeleteMessage(chatID, session.FieldMessageID)
-			h.requestAPI(deleteFieldMsg)
-
-			// Удаляем сообщение с информацией о шахте
-			deleteInfoMsg := tgbotapi.NewDeleteMessage(chatID, session.InfoMessageID)
-			h.requestAPI(deleteInfoMsg)
-
-			exhaustMsg := tgbotapi.NewMessage(chatID, `⚠️ Шахта истощена! Необходимо подождать 1 минуту до восстановления ресурсов.
-Нажми кнопку "⛏ Шахта" чтобы проверить готовность.`)
-			h.sendGatheringKeyboard(exhaustMsg)
-
-			// Удаляем сессию
-			delete(h.mineSessions, userID)
-		}
+	// Проверяем, хватает ли ингредиентов хотя бы на одно создание
+	if crafts, missing := h.availableCrafts(player.ID, recipe); crafts == 0 {
+		callbackConfig := tgbotapi.NewCallback(callbackID, fmt.Sprintf(`Недостаточно предмета "%s"`, missing))
+		h.requestAPI(callbackConfig)
+		return
 	}
-}
 
-func (h *BotHandlers) updateMineField(chatID int64, field [][]string, messageID int) {
-	text := "Выберите ресурс для добычи:"
-
-	// Создаем инлайн клавиатуру на основе переданного поля
-	var keyboard [][]tgbotapi.InlineKeyboardButton
-	for i := 0; i < 3; i++ {
-		var row []tgbotapi.InlineKeyboardButton
-		for j := 0; j < 3; j++ {
-			cell := field[i][j]
-			var callbackData string
-
-			switch cell {
-			case "🪨":
-				callbackData = fmt.Sprintf("mine_stone_%d_%d", i, j)
-			case "⚫":
-				callbackData = fmt.Sprintf("mine_coal_%d_%d", i, j)
-			default:
-				callbackData = fmt.Sprintf("mine_empty_%d_%d", i, j)
-				cell = " "
-			}
+	// Отвечаем на callback
+	callbackConfig := tgbotapi.NewCallback(callbackID, "")
+	h.requestAPI(callbackConfig)
 
-			button := tgbotapi.NewInlineKeyboardButtonData(cell, callbackData)
-			row = append(row, button)
-		}
-		keyboard = append(keyboard, row)
+	// Постройки возводятся сразу в одном экземпляре
+	if recipe.Station == catalog.StationConstruction {
+		h.startCrafting(userID, chatID, *recipe, 1)
+		return
 	}
 
-	// Редактируем существующее сообщение вместо отправки нового
-	editMsg := tgbotapi.NewEditMessageTextAndMarkup(chatID, messageID, text, tgbotapi.NewInlineKeyboardMarkup(keyboard...))
-	h.editMessage(editMsg)
-}
-
-func (h *BotHandlers) updateMineInfoMessage(userID int64, chatID int64, mine *models.Mine, messageID int) {
-	// Вычисляем опыт до следующего уровня
-	expToNext := (mine.Level * 100) - mine.Experience
-
-	infoText := fmt.Sprintf(`⛏ Шахта (Уровень %d)
-До следующего уровня: %d опыта
-
-Доступные ресурсы:
-🪨 Камень
-⚫ Уголь`, mine.Level, expToNext)
-
-	// Удаляем старое сообщение
-	deleteMsg := tgbotapi.NewDeleteMessage(chatID, messageID)
-	h.requestAPI(deleteMsg)
-
-	// Создаем новое сообщение с клавиатурой
-	mineKeyboard := tgbotapi.NewReplyKeyboard(
-		tgbotapi.NewKeyboardButtonRow(
-			tgbotapi.NewKeyboardButton("◀️ Назад"),
-		),
-	)
-	mineKeyboard.ResizeKeyboard = true
-
-	newMsg := tgbotapi.NewMessage(chatID, infoText)
-	newMsg.ReplyMarkup = mineKeyboard
-	newResponse, _ := h.sendMessageWithResponse(newMsg)
+	// Спрашиваем количество
+	msg := tgbotapi.NewMessage(chatID, "Введи сколько предметов хочешь создать:")
+	h.sendMessage(msg)
 
-	// Обновляем ID сообщения в сессии
-	if session, exists := h.mineSessions[userID]; exists {
-		session.InfoMessageID = newResponse.MessageID
-	}
+	// Отмечаем, что ждем количество для крафта
+	h.playerState(userID).WaitingForCraftQuantity = recipe.Key
 }
 
 func (h *BotHandlers) sendWithKeyboard(msg tgbotapi.MessageConfig) {
@@ -2815,318 +1444,22 @@ func (h *BotHandlers) sendMessageWithResponse(msg tgbotapi.MessageConfig) (tgbot
 func (h *BotHandlers) editMessage(editMsg tgbotapi.Chattable) {
 	if _, err := h.bot.Send(editMsg); err != nil {
 		log.Printf("Failed to edit message: %v", err)
-	}
-}
-
-// Вспомогательная функция для отправки любого Chattable с возвратом результата
-func (h *BotHandlers) sendChattableWithResponse(c tgbotapi.Chattable) (tgbotapi.Message, error) {
-	response, err := h.bot.Send(c)
-	if err != nil {
-		log.Printf("Failed to send chattable: %v", err)
-	}
-	return response, err
-}
-
-// Вспомогательная функция для отправки запросов к Telegram API с обработкой ошибок
-func (h *BotHandlers) requestAPI(c tgbotapi.Chattable) {
-	if _, err := h.bot.Request(c); err != nil {
-		log.Printf("Failed to send API request: %v", err)
-	}
-}
-
-// Функции для рубки леса
-func (h *BotHandlers) startChoppingAtPosition(userID int64, chatID int64, resourceName string, duration int, callbackID string, rowStr, colStr string) {
-	row, _ := strconv.Atoi(rowStr)
-	col, _ := strconv.Atoi(colStr)
-
-	h.startChopping(userID, chatID, resourceName, duration, callbackID, row, col)
-}
-
-func (h *BotHandlers) startChopping(userID int64, chatID int64, resourceName string, duration int, callbackID string, row, col int) {
-	// Проверяем, идет ли уже рубка в лесу, добыча в шахте или крафт
-	if _, exists := h.choppingTimers[userID]; exists {
-		msg := tgbotapi.NewMessage(chatID, "Нельзя начинать новую добычу, пока не закончена текущая.")
-		h.sendMessage(msg)
-		callbackConfig := tgbotapi.NewCallback(callbackID, "")
-		h.requestAPI(callbackConfig)
-		return
-	}
-	if _, exists := h.miningTimers[userID]; exists {
-		msg := tgbotapi.NewMessage(chatID, "Нельзя начинать новую добычу, пока не закончена текущая.")
-		h.sendMessage(msg)
-		callbackConfig := tgbotapi.NewCallback(callbackID, "")
-		h.requestAPI(callbackConfig)
-		return
-	}
-	if _, exists := h.craftingTimers[userID]; exists {
-		msg := tgbotapi.NewMessage(chatID, "Нельзя совершать действия пока идет создание предметов.")
-		h.sendMessage(msg)
-		callbackConfig := tgbotapi.NewCallback(callbackID, "")
-		h.requestAPI(callbackConfig)
-		return
-	}
-
-	// Получаем игрока
-	player, err := h.db.GetPlayer(userID)
-	if err != nil {
-		log.Printf("Error getting player: %v", err)
-		return
-	}
-
-	// Проверяем наличие топора
-	hasTool, durability, err := h.db.HasToolInInventory(player.ID, "Простой топор")
-	if err != nil {
-		log.Printf("Error checking tool: %v", err)
-		return
-	}
-
-	if !hasTool {
-		msg := tgbotapi.NewMessage(chatID, `В инвентаре нет предмета "Простой топор".`)
-		h.sendMessage(msg)
-		callbackConfig := tgbotapi.NewCallback(callbackID, "")
-		h.requestAPI(callbackConfig)
-		return
-	}
-
-	// Отвечаем на callback
-	callbackConfig := tgbotapi.NewCallback(callbackID, "")
-	h.requestAPI(callbackConfig)
-
-	// Удаляем предыдущее сообщение о результате рубки, если оно существует
-	if session, exists := h.forestSessions[userID]; exists && session.ResultMessageID != 0 {
-		deleteResultMsg := tgbotapi.NewDeleteMessage(chatID, session.ResultMessageID)
-		h.requestAPI(deleteResultMsg)
-		session.ResultMessageID = 0 // Сбрасываем ID
-	}
-
-	// Отправляем сообщение о начале рубки
-	initialText := fmt.Sprintf(`Идет рубка дерева "%s". Время рубки %d сек.
-		
-%s 0%%`, resourceName, duration, h.createProgressBar(0, 10))
-
-	choppingMsg := tgbotapi.NewMessage(chatID, initialText)
-	sentMsg, _ := h.sendMessageWithResponse(choppingMsg)
-
-	// Запускаем горутину для обновления прогресс бара
-	go h.updateChoppingProgress(userID, chatID, sentMsg.MessageID, resourceName, duration, durability, row, col)
-
-	// Создаем заглушку таймера
-	timer := time.NewTimer(time.Duration(duration) * time.Second)
-	h.choppingTimers[userID] = timer
-}
-
-func (h *BotHandlers) updateChoppingProgress(userID int64, chatID int64, messageID int, resourceName string, totalDuration int, durability int, row, col int) {
-	startTime := time.Now()
-	ticker := time.NewTicker(1 * time.Second)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ticker.C:
-			elapsed := time.Since(startTime).Seconds()
-			progress := int(elapsed)
-
-			if progress >= totalDuration {
-				// Рубка завершена
-				h.completeChopping(userID, chatID, resourceName, durability, messageID, row, col)
-				return
-			}
-
-			// Обновляем прогресс бар
-			percentage := int((elapsed / float64(totalDuration)) * 100)
-
-			progressBar := h.createProgressBar(progress, totalDuration)
-
-			newText := fmt.Sprintf(`Началась рубка дерева "%s". Время рубки %d сек.
-			
-%s %d%%`, resourceName, totalDuration, progressBar, percentage)
-
-			// Редактируем сообщение
-			editMsg := tgbotapi.NewEditMessageText(chatID, messageID, newText)
-			h.editMessage(editMsg)
-
-		case <-time.After(time.Duration(totalDuration+1) * time.Second):
-			// Таймаут на случай, если что-то пошло не так
-			return
-		}
-	}
-}
-
-func (h *BotHandlers) completeChopping(userID int64, chatID int64, resourceName string, oldDurability int, messageID int, row, col int) {
-	// Получаем игрока
-	player, err := h.db.GetPlayer(userID)
-	if err != nil {
-		log.Printf("Error getting player: %v", err)
-		return
-	}
-
-	// Добавляем ресурс в инвентарь
-	if err := h.db.AddItemToInventory(player.ID, resourceName, 1); err != nil {
-		log.Printf("Error adding item to inventory: %v", err)
-	}
-
-	// Проверяем квест на рубку березы
-	if resourceName == "Береза" {
-		h.checkBirchQuestProgress(userID, chatID, player.ID)
-	}
-
-	// Обновляем прочность топора и сытость
-	if err := h.db.UpdateItemDurability(player.ID, "Простой топор", 1); err != nil {
-		log.Printf("Error updating item durability: %v", err)
-	}
-	if err := h.db.UpdatePlayerSatiety(player.ID, -1); err != nil {
-		log.Printf("Error updating player satiety: %v", err)
-	}
-
-	// Добавляем опыт лесу и проверяем повышение уровня
-	levelUp, newLevel, err := h.db.UpdateForestExperience(player.ID, 2)
-	if err != nil {
-		log.Printf("Error updating forest experience: %v", err)
-		return
-	}
-
-	// Получаем обновленные данные
-	updatedPlayer, _ := h.db.GetPlayer(userID)
-	forest, _ := h.db.GetOrCreateForest(player.ID)
-
-	// Удаляем сообщение о рубке
-	deleteMsg := tgbotapi.NewDeleteMessage(chatID, messageID)
-	h.requestAPI(deleteMsg)
-
-	// Показываем результат
-	resultText := fmt.Sprintf(`✅ Ты срубил дерево "%s"!
-Получено опыта: 2
-Сытость: %d/100
-Прочность топора: %d/100
-До следующего уровня: %d опыта`,
-		resourceName,
-		updatedPlayer.Satiety,
-		oldDurability-1,
-		(forest.Level*100)-forest.Experience)
-
-	msg := tgbotapi.NewMessage(chatID, resultText)
-	resultResponse, _ := h.sendMessageWithResponse(msg)
-
-	// Если уровень повысился, показываем сообщение о повышении
-	if levelUp {
-		levelUpText := fmt.Sprintf("🎉 Поздравляем! Уровень леса повышен до %d уровня!", newLevel)
-		levelUpMsg := tgbotapi.NewMessage(chatID, levelUpText)
-		h.sendMessage(levelUpMsg)
-	}
-
-	// Сохраняем ID сообщения с результатом в сессии
-	if session, exists := h.forestSessions[userID]; exists {
-		session.ResultMessageID = resultResponse.MessageID
-	}
-
-	// Убираем таймер
-	delete(h.choppingTimers, userID)
-
-	// Обновляем поле - убираем срубленное дерево
-	if session, exists := h.forestSessions[userID]; exists {
-		session.Resources[row][col] = ""
-
-		// Проверяем, остались ли ресурсы в поле
-		totalResources := 0
-		for i := 0; i < 3; i++ {
-			for j := 0; j < 3; j++ {
-				if session.Resources[i][j] != "" {
-					totalResources++
-				}
-			}
-		}
-
-		if totalResources > 0 {
-			// Обновляем инлайн клавиатуру с новым состоянием поля
-			h.updateForestField(chatID, session.Resources, session.FieldMessageID)
-			// Обновляем информационное сообщение с актуальными данными
-			h.updateForestInfoMessage(userID, chatID, forest, session.InfoMessageID)
-		} else {
-			// Поле истощено, устанавливаем кулдаун
-			if err := h.db.ExhaustForest(userID); err != nil {
-				log.Printf("Error exhausting forest: %v", err)
-			}
-
-			// Устанавливаем таймер кулдауна на 60 секунд
-			h.forestCooldowns[userID] = time.Now().Add(60 * time.Second)
-
-			// Удаляем сообщение с полем леса
-			deleteFieldMsg := tgbotapi.NewDeleteMessage(chatID, session.FieldMessageID)
-			h.requestAPI(deleteFieldMsg)
-
-			// Удаляем сообщение с информацией о лесе
-			deleteInfoMsg := tgbotapi.NewDeleteMessage(chatID, session.InfoMessageID)
-			h.requestAPI(deleteInfoMsg)
-
-			exhaustMsg := tgbotapi.NewMessage(chatID, `⚠️ Лес истощен! Необходимо подождать 1 минуту до восстановления деревьев.
-Нажми кнопку "🪓 Рубка" чтобы проверить готовность.`)
-			h.sendForestKeyboard(exhaustMsg)
-
-			// Удаляем сессию
-			delete(h.forestSessions, userID)
-		}
-	}
-}
-
-func (h *BotHandlers) updateForestField(chatID int64, field [][]string, messageID int) {
-	text := "Выберите дерево для рубки:"
-
-	// Создаем инлайн клавиатуру на основе переданного поля
-	var keyboard [][]tgbotapi.InlineKeyboardButton
-	for i := 0; i < 3; i++ {
-		var row []tgbotapi.InlineKeyboardButton
-		for j := 0; j < 3; j++ {
-			cell := field[i][j]
-			var callbackData string
-
-			switch cell {
-			case "🌳":
-				callbackData = fmt.Sprintf("forest_birch_%d_%d", i, j)
-			default:
-				callbackData = fmt.Sprintf("forest_empty_%d_%d", i, j)
-				cell = " "
-			}
-
-			button := tgbotapi.NewInlineKeyboardButtonData(cell, callbackData)
-			row = append(row, button)
-		}
-		keyboard = append(keyboard, row)
-	}
-
-	// Редактируем существующее сообщение вместо отправки нового
-	editMsg := tgbotapi.NewEditMessageTextAndMarkup(chatID, messageID, text, tgbotapi.NewInlineKeyboardMarkup(keyboard...))
-	h.editMessage(editMsg)
-}
-
-func (h *BotHandlers) updateForestInfoMessage(userID int64, chatID int64, forest *models.Forest, messageID int) {
-	// Вычисляем опыт до следующего уровня
-	expToNext := (forest.Level * 100) - forest.Experience
-
-	infoText := fmt.Sprintf(`🪓 Рубка (Уровень %d)
-До следующего уровня: %d опыта
-
-Доступные ресурсы:
-🌳 Береза`, forest.Level, expToNext)
-
-	// Удаляем старое сообщение
-	deleteMsg := tgbotapi.NewDeleteMessage(chatID, messageID)
-	h.requestAPI(deleteMsg)
-
-	// Создаем новое сообщение с клавиатурой
-	forestKeyboard := tgbotapi.NewReplyKeyboard(
-		tgbotapi.NewKeyboardButtonRow(
-			tgbotapi.NewKeyboardButton("◀️ Назад"),
-		),
-	)
-	forestKeyboard.ResizeKeyboard = true
+	}
+}
 
-	newMsg := tgbotapi.NewMessage(chatID, infoText)
-	newMsg.ReplyMarkup = forestKeyboard
-	newResponse, _ := h.sendMessageWithResponse(newMsg)
+// Вспомогательная функция для отправки любого Chattable с возвратом результата
+func (h *BotHandlers) sendChattableWithResponse(c tgbotapi.Chattable) (tgbotapi.Message, error) {
+	response, err := h.bot.Send(c)
+	if err != nil {
+		log.Printf("Failed to send chattable: %v", err)
+	}
+	return response, err
+}
 
-	// Обновляем ID сообщения в сессии
-	if session, exists := h.forestSessions[userID]; exists {
-		session.InfoMessageID = newResponse.MessageID
+// Вспомогательная функция для отправки запросов к Telegram API с обработкой ошибок
+func (h *BotHandlers) requestAPI(c tgbotapi.Chattable) {
+	if _, err := h.bot.Request(c); err != nil {
+		log.Printf("Failed to send API request: %v", err)
 	}
 }
 
@@ -3134,7 +1467,7 @@ func (h *BotHandlers) handleQuest(message *tgbotapi.Message) {
 	userID := message.From.ID
 
 	// Устанавливаем местоположение игрока
-	h.playerLocation[userID] = "quest"
+	h.playerState(userID).Location = "quest"
 
 	questText := `📜 Квесты
 
@@ -3175,608 +1508,52 @@ func (h *BotHandlers) handleLore(message *tgbotapi.Message) {
 		return
 	}
 
-	// Проверяем квест 1
-	quest, err := h.db.GetPlayerQuest(player.ID, 1)
+	// Ищем следующий квест цепочки
+	quest, progress, ok, err := h.quests.Current(player.ID)
 	if err != nil {
-		log.Printf("Error getting quest: %v", err)
+		log.Printf("Error getting current quest: %v", err)
 		msg := tgbotapi.NewMessage(message.Chat.ID, "Произошла ошибка при получении квеста.")
 		h.sendMessage(msg)
 		return
 	}
 
-	if quest == nil || quest.Status == "available" {
-		// Квест еще не создан или доступен для принятия
-		if quest == nil {
-			// Создаем квест, если его нет
-			err := h.db.CreateQuest(player.ID, 1, 5) // Квест 1: нарубить 5 березы
-			if err != nil {
-				log.Printf("Error creating quest: %v", err)
-				msg := tgbotapi.NewMessage(message.Chat.ID, "Произошла ошибка при создании квеста.")
-				h.sendMessage(msg)
-				return
-			}
-		}
-
-		// Показываем предложение квеста
-		questText := `🪓 Квест 1: Дерево под топор
-Задание: Наруби 5 берёзы.
-Для выполнения задания необходимо проследовать в Добыча/Лес/Рубка.
-Награда: 🎖 10 опыта + 📖 Страница 1 «Забытая тишина»`
-
-		msg := tgbotapi.NewMessage(message.Chat.ID, questText)
-
-		// Создаем инлайн кнопки
-		acceptBtn := tgbotapi.NewInlineKeyboardButtonData("Принять", "quest_accept_1")
-		declineBtn := tgbotapi.NewInlineKeyboardButtonData("Отказ", "quest_decline_1")
-		keyboard := tgbotapi.NewInlineKeyboardMarkup(
-			tgbotapi.NewInlineKeyboardRow(acceptBtn, declineBtn),
-		)
-		msg.ReplyMarkup = keyboard
+	if !ok {
+		msg := tgbotapi.NewMessage(message.Chat.ID, "🎉 Ты завершил всю цепочку ЛОР-квестов! Продолжение следует...")
 		h.sendMessage(msg)
 		return
 	}
 
-	if quest.Status == "active" {
+	if progress.Status == "active" {
 		// Квест активен, показываем прогресс
-		activeText := fmt.Sprintf(`Активный квест: 🪓 Квест 1: Дерево под топор
-Задание: Наруби 5 берёзы. (%d/5)
-Для выполнения задания необходимо проследовать в Добыча/Лес/Рубка.
-Награда: 🎖 10 опыта + 📖 Страница 1 «Забытая тишина»`, quest.Progress)
-
-		msg := tgbotapi.NewMessage(message.Chat.ID, activeText)
+		msg := tgbotapi.NewMessage(message.Chat.ID, questActiveText(quest, progress))
 		h.sendMessage(msg)
 		return
 	}
 
-	if quest.Status == "completed" {
-		// Квест 1 выполнен, проверяем квест 2
-		quest2, err := h.db.GetPlayerQuest(player.ID, 2)
-		if err != nil {
-			log.Printf("Error getting quest 2: %v", err)
-			msg := tgbotapi.NewMessage(message.Chat.ID, "Произошла ошибка при получении квеста.")
-			h.sendMessage(msg)
-			return
-		}
-
-		if quest2 == nil || quest2.Status == "available" {
-			// Квест 2 еще не создан или доступен для принятия
-			if quest2 == nil {
-				// Создаем квест 2, если его нет
-				err := h.db.CreateQuest(player.ID, 2, 3) // Квест 2: добыть 3 камня
-				if err != nil {
-					log.Printf("Error creating quest 2: %v", err)
-					msg := tgbotapi.NewMessage(message.Chat.ID, "Произошла ошибка при создании квеста.")
-					h.sendMessage(msg)
-					return
-				}
-			}
-
-			// Показываем предложение квеста 2
-			questText := `⛏ Квест 2: Вглубь
-Задание: Добудь 3 камня.
-Для выполнения задания необходимо проследовать в Добыча/Шахта.
-Награда: 🎖 10 опыта + 📖 Страница 2 «Пыль веков»`
-
-			msg := tgbotapi.NewMessage(message.Chat.ID, questText)
-
-			// Создаем инлайн кнопки
-			acceptBtn := tgbotapi.NewInlineKeyboardButtonData("Принять", "quest_accept_2")
-			declineBtn := tgbotapi.NewInlineKeyboardButtonData("Отказ", "quest_decline_2")
-			keyboard := tgbotapi.NewInlineKeyboardMarkup(
-				tgbotapi.NewInlineKeyboardRow(acceptBtn, declineBtn),
-			)
-			msg.ReplyMarkup = keyboard
-			h.sendMessage(msg)
-			return
-		}
-
-		if quest2.Status == "active" {
-			// Квест 2 активен, показываем прогресс
-			activeText := fmt.Sprintf(`Активный квест: ⛏ Квест 2: Вглубь
-Задание: Добудь 3 камня (%d/3)
-Для выполнения задания необходимо проследовать в Добыча/Шахта.
-Награда: 🎖 10 опыта + 📖 Страница 2 «Пыль веков»`, quest2.Progress)
-
-			msg := tgbotapi.NewMessage(message.Chat.ID, activeText)
+	// Если постройка уже возведена, квест на нее засчитывается сразу
+	if quest.Objective.Type == catalog.ObjectiveBuild && h.isBuilt(player, quest.Objective.Target) {
+		if err := h.quests.Complete(player.ID, quest); err != nil {
+			log.Printf("Error completing quest %d: %v", quest.ID, err)
+			msg := tgbotapi.NewMessage(message.Chat.ID, "Произошла ошибка при выполнении квеста.")
 			h.sendMessage(msg)
 			return
 		}
 
-		if quest2.Status == "completed" {
-			// Квест 2 выполнен, проверяем квест 3
-			quest3, err := h.db.GetPlayerQuest(player.ID, 3)
-			if err != nil {
-				log.Printf("Error getting quest 3: %v", err)
-				msg := tgbotapi.NewMessage(message.Chat.ID, "Произошла ошибка при получении квеста.")
-				h.sendMessage(msg)
-				return
-			}
-
-			if quest3 == nil || quest3.Status == "available" {
-				// Квест 3 еще не создан или доступен для принятия
-				if quest3 == nil {
-					// Создаем квест 3, если его нет
-					err := h.db.CreateQuest(player.ID, 3, 3) // Квест 3: создать 3 березовых бруса
-					if err != nil {
-						log.Printf("Error creating quest 3: %v", err)
-						msg := tgbotapi.NewMessage(message.Chat.ID, "Произошла ошибка при создании квеста.")
-						h.sendMessage(msg)
-						return
-					}
-				}
-
-				// Показываем предложение квеста 3
-				questText := `🪚 Квест 3: Руки мастера
-Задание: Создай 3 берёзовых бруса.
-Для выполнения задания необходимо проследовать в Рабочее место/Верстак и выполнить команду создания бруса.
-Награда: 🎖 10 опыта + 📖 Страница 3 «Первый шаг»`
-
-				msg := tgbotapi.NewMessage(message.Chat.ID, questText)
-
-				// Создаем инлайн кнопки
-				acceptBtn := tgbotapi.NewInlineKeyboardButtonData("Принять", "quest_accept_3")
-				declineBtn := tgbotapi.NewInlineKeyboardButtonData("Отказ", "quest_decline_3")
-				keyboard := tgbotapi.NewInlineKeyboardMarkup(
-					tgbotapi.NewInlineKeyboardRow(acceptBtn, declineBtn),
-				)
-				msg.ReplyMarkup = keyboard
-				h.sendMessage(msg)
-				return
-			}
-
-			if quest3.Status == "active" {
-				// Квест 3 активен, показываем прогресс
-				activeText := fmt.Sprintf(`Активный квест: 🪚 Квест 3: Руки мастера
-Задание: Создай 3 берёзовых бруса (%d/3)
-Для выполнения задания необходимо проследовать в Рабочее место/Верстак и выполнить команду создания бруса.
-Награда: 🎖 10 опыта + 📖 Страница 3 «Первый шаг»`, quest3.Progress)
-
-				msg := tgbotapi.NewMessage(message.Chat.ID, activeText)
-				h.sendMessage(msg)
-				return
-			}
-
-			if quest3.Status == "completed" {
-				// Квест 3 выполнен, проверяем квест 4
-				quest4, err := h.db.GetPlayerQuest(player.ID, 4)
-				if err != nil {
-					log.Printf("Error getting quest 4: %v", err)
-					msg := tgbotapi.NewMessage(message.Chat.ID, "Произошла ошибка при получении квеста.")
-					h.sendMessage(msg)
-					return
-				}
-
-				if quest4 == nil || quest4.Status == "available" {
-					// Квест 4 еще не создан или доступен для принятия
-					if quest4 == nil {
-						// Создаем квест 4, если его нет
-						err := h.db.CreateQuest(player.ID, 4, 5) // Квест 4: собрать 5 лесных ягод
-						if err != nil {
-							log.Printf("Error creating quest 4: %v", err)
-							msg := tgbotapi.NewMessage(message.Chat.ID, "Произошла ошибка при создании квеста.")
-							h.sendMessage(msg)
-							return
-						}
-					}
-
-					// Показываем предложение квеста 4
-					questText := `🍇 Квест 4: Дар леса
-Задание: Собери 5 лесных ягод.
-Для выполнения задания необходимо проследовать в Добыча/Лес/Сбор.
-Награда: 🎖 10 опыта + 📖 Страница 4 «Голос земли»`
-
-					msg := tgbotapi.NewMessage(message.Chat.ID, questText)
-
-					// Создаем инлайн кнопки
-					acceptBtn := tgbotapi.NewInlineKeyboardButtonData("Принять", "quest_accept_4")
-					declineBtn := tgbotapi.NewInlineKeyboardButtonData("Отказ", "quest_decline_4")
-					keyboard := tgbotapi.NewInlineKeyboardMarkup(
-						tgbotapi.NewInlineKeyboardRow(acceptBtn, declineBtn),
-					)
-					msg.ReplyMarkup = keyboard
-					h.sendMessage(msg)
-					return
-				}
-
-				if quest4.Status == "active" {
-					// Квест 4 активен, показываем прогресс
-					activeText := fmt.Sprintf(`Активный квест: 🍇 Квест 4: Дар леса
-Задание: Собери 5 лесных ягод (%d/5)
-Для выполнения задания необходимо проследовать в Добыча/Лес/Сбор.
-Награда: 🎖 10 опыта + 📖 Страница 4 «Голос земли»`, quest4.Progress)
-
-					msg := tgbotapi.NewMessage(message.Chat.ID, activeText)
-					h.sendMessage(msg)
-					return
-				}
-
-				if quest4.Status == "completed" {
-					// Квест 4 выполнен, проверяем квест 5
-					quest5, err := h.db.GetPlayerQuest(player.ID, 5)
-					if err != nil {
-						log.Printf("Error getting quest 5: %v", err)
-						msg := tgbotapi.NewMessage(message.Chat.ID, "Произошла ошибка при получении квеста.")
-						h.sendMessage(msg)
-						return
-					}
-					if quest5 == nil || quest5.Status == "available" {
-						// Квест 5 еще не создан или доступен для принятия
-						if quest5 == nil {
-							// Создаем квест 5, если его нет
-							err := h.db.CreateQuest(player.ID, 5, 1) // Квест 5: совершить первую охоту
-							if err != nil {
-								log.Printf("Error creating quest 5: %v", err)
-								msg := tgbotapi.NewMessage(message.Chat.ID, "Произошла ошибка при создании квеста.")
-								h.sendMessage(msg)
-								return
-							}
-						}
-						// Показываем предложение квеста 5
-						questText := `🎯 Квест 5: Звериный взгляд
-Задание: Соверши первую охоту.
-Для выполнения задания необходимо проследовать в Добыча/Лес/Охота.
-Награда: 🎖 10 опыта + 📖 Страница 5 «След древних»`
-						msg := tgbotapi.NewMessage(message.Chat.ID, questText)
-						acceptBtn := tgbotapi.NewInlineKeyboardButtonData("Принять", "quest_accept_5")
-						declineBtn := tgbotapi.NewInlineKeyboardButtonData("Отказ", "quest_decline_5")
-						keyboard := tgbotapi.NewInlineKeyboardMarkup(
-							tgbotapi.NewInlineKeyboardRow(acceptBtn, declineBtn),
-						)
-						msg.ReplyMarkup = keyboard
-						h.sendMessage(msg)
-						return
-					}
-					if quest5.Status == "active" {
-						activeText := fmt.Sprintf(`Активный квест: 🎯 Квест 5: Звериный взгляд
-Задание: Соверши первую охоту (%d/1)
-Для выполнения задания необходимо проследовать в Добыча/Лес/Охота.
-Награда: 🎖 10 опыта + 📖 Страница 5 «След древних»`, quest5.Progress)
-						msg := tgbotapi.NewMessage(message.Chat.ID, activeText)
-						h.sendMessage(msg)
-						return
-					}
-					if quest5.Status == "completed" {
-						// Квест 5 выполнен, проверяем квест 6
-						quest6, err := h.db.GetPlayerQuest(player.ID, 6)
-						if err != nil {
-							log.Printf("Error getting quest 6: %v", err)
-							msg := tgbotapi.NewMessage(message.Chat.ID, "Произошла ошибка при получении квеста.")
-							h.sendMessage(msg)
-							return
-						}
-						if quest6 == nil || quest6.Status == "available" {
-							// Квест 6 еще не создан или доступен для принятия
-							if quest6 == nil {
-								// Создаем квест 6, если его нет
-								err := h.db.CreateQuest(player.ID, 6, 5) // Квест 6: прочитать 5 страниц
-								if err != nil {
-									log.Printf("Error creating quest 6: %v", err)
-									msg := tgbotapi.NewMessage(message.Chat.ID, "Произошла ошибка при создании квеста.")
-									h.sendMessage(msg)
-									return
-								}
-							}
-							// Показываем предложение квеста 6
-							questText := `📘 Квест 6: Живое Хранилище
-Задание: Открой 5 страниц лора
-Для выполнения задания необходимо в инвентаре в разделе "Страницы"
-вызвать команду look и затем прочитать 5 страниц ЛОРа от 1-й до 5-й.
-Награда: 🎖 10 опыта + 📖 Страница 6 «Сон о башне»`
-							msg := tgbotapi.NewMessage(message.Chat.ID, questText)
-							acceptBtn := tgbotapi.NewInlineKeyboardButtonData("Принять", "quest_accept_6")
-							declineBtn := tgbotapi.NewInlineKeyboardButtonData("Отказ", "quest_decline_6")
-							keyboard := tgbotapi.NewInlineKeyboardMarkup(
-								tgbotapi.NewInlineKeyboardRow(acceptBtn, declineBtn),
-							)
-							msg.ReplyMarkup = keyboard
-							h.sendMessage(msg)
-							return
-						}
-						if quest6.Status == "active" {
-							activeText := fmt.Sprintf(`Активный квест: 📘 Квест 6: Живое Хранилище
-Задание: Открой 5 страниц лора (%d/5)
-Награда: 🎖 10 опыта + 📖 Страница 6 «Сон о башне»`, quest6.Progress)
-							msg := tgbotapi.NewMessage(message.Chat.ID, activeText)
-							h.sendMessage(msg)
-							return
-						}
-						if quest6.Status == "completed" {
-							// Квест 6 выполнен, проверяем квест 7
-							quest7, err := h.db.GetPlayerQuest(player.ID, 7)
-							if err != nil {
-								log.Printf("Error getting quest 7: %v", err)
-								msg := tgbotapi.NewMessage(message.Chat.ID, "Произошла ошибка при получении квеста.")
-								h.sendMessage(msg)
-								return
-							}
-
-							if quest7 == nil || quest7.Status == "available" {
-								// Квест 7 еще не создан или доступен для принятия
-								if quest7 == nil {
-									// Создаем квест 7, если его нет
-									err := h.db.CreateQuest(player.ID, 7, 3) // Квест 7: съесть 3 ягоды
-									if err != nil {
-										log.Printf("Error creating quest 7: %v", err)
-										msg := tgbotapi.NewMessage(message.Chat.ID, "Произошла ошибка при создании квеста.")
-										h.sendMessage(msg)
-										return
-									}
-								}
-
-								// Показываем предложение квеста 7
-								questText := `🍇 Квест 7: Перекус
-Задание: Съешь 3 лесные ягоды.
-Для выполнения квеста необходимо в инвентаре напротив предмета "Лесная ягода" выполнить команду eat.
-Награда: 🎖 10 опыта + 📖 Страница 7 «Шёпот ветра»`
-
-								msg := tgbotapi.NewMessage(message.Chat.ID, questText)
-
-								// Создаем инлайн кнопки
-								acceptBtn := tgbotapi.NewInlineKeyboardButtonData("Принять", "quest_accept_7")
-								declineBtn := tgbotapi.NewInlineKeyboardButtonData("Отказ", "quest_decline_7")
-								keyboard := tgbotapi.NewInlineKeyboardMarkup(
-									tgbotapi.NewInlineKeyboardRow(acceptBtn, declineBtn),
-								)
-								msg.ReplyMarkup = keyboard
-								h.sendMessage(msg)
-								return
-							}
-
-							if quest7.Status == "active" {
-								// Квест 7 активен, показываем прогресс
-								activeText := fmt.Sprintf(`Активный квест: 🍇 Квест 7: Перекус
-Задание: Съешь 3 лесные ягоды (%d/3)
-Для выполнения квеста необходимо в инвентаре напротив предмета "Лесная ягода" выполнить команду eat.
-Награда: 🎖 10 опыта + 📖 Страница 7 «Шёпот ветра»`, quest7.Progress)
-
-								msg := tgbotapi.NewMessage(message.Chat.ID, activeText)
-								h.sendMessage(msg)
-								return
-							}
-
-							if quest7.Status == "completed" {
-								// Квест 7 выполнен, проверяем квест 8
-								quest8, err := h.db.GetPlayerQuest(player.ID, 8)
-								if err != nil {
-									log.Printf("Error getting quest 8: %v", err)
-									msg := tgbotapi.NewMessage(message.Chat.ID, "Произошла ошибка при получении квеста.")
-									h.sendMessage(msg)
-									return
-								}
-
-								if quest8 == nil || quest8.Status == "available" {
-									// Квест 8 еще не создан или доступен для принятия
-									if quest8 == nil {
-										// Создаем квест 8, если его нет
-										err := h.db.CreateQuest(player.ID, 8, 1) // Квест 8: построить простую хижину
-										if err != nil {
-											log.Printf("Error creating quest 8: %v", err)
-											msg := tgbotapi.NewMessage(message.Chat.ID, "Произошла ошибка при создании квеста.")
-											h.sendMessage(msg)
-											return
-										}
-									}
-
-									// Проверяем, построена ли уже хижина
-									if player.SimpleHutBuilt {
-										// Если хижина уже построена, сразу завершаем квест
-										err = h.db.UpdateQuestStatus(player.ID, 8, "completed")
-										if err != nil {
-											log.Printf("Error completing quest 8: %v", err)
-											msg := tgbotapi.NewMessage(message.Chat.ID, "Произошла ошибка при выполнении квеста.")
-											h.sendMessage(msg)
-											return
-										}
-
-										// Добавляем награды
-										err = h.db.UpdatePlayerExperience(player.ID, 10)
-										if err != nil {
-											log.Printf("Error updating player experience: %v", err)
-										}
-
-										err = h.db.AddItemToInventory(player.ID, "📖 Страница 8 «След древних»", 1)
-										if err != nil {
-											log.Printf("Error adding quest item to inventory: %v", err)
-										}
-
-										// Отправляем сообщение о выполнении квеста
-										questCompleteText := `🛖 Квест 8: Под крышей ВЫПОЛНЕН!
-Получена награда:
-🎖 10 опыта
-📖 Страница 8 «След древних»`
-
-										msg := tgbotapi.NewMessage(message.Chat.ID, questCompleteText)
-										h.sendMessage(msg)
-										return
-									}
-
-									// Показываем предложение квеста 8
-									questText := `🛖 Квест 8: Под крышей
-Задание: Построй простую хижину.
-Для выполнения задания необходимо проследовать в Постройки и выполнить команду создания простой хижины.
-Награда: 🎖 10 опыта + 📖 Страница 8 «След древних»`
-
-									msg := tgbotapi.NewMessage(message.Chat.ID, questText)
-									acceptBtn := tgbotapi.NewInlineKeyboardButtonData("Принять", "quest_accept_8")
-									declineBtn := tgbotapi.NewInlineKeyboardButtonData("Отказ", "quest_decline_8")
-									keyboard := tgbotapi.NewInlineKeyboardMarkup(
-										tgbotapi.NewInlineKeyboardRow(acceptBtn, declineBtn),
-									)
-									msg.ReplyMarkup = keyboard
-									h.sendMessage(msg)
-									return
-								}
-
-								if quest8.Status == "active" {
-									activeText := fmt.Sprintf(`Активный квест: 🛖 Квест 8: Под крышей
-Задание: Построй простую хижину (%d/1)
-Для выполнения задания необходимо проследовать в Постройки и выполнить команду создания простой хижины.
-Награда: 🎖 10 опыта + 📖 Страница 8 «След древних»`, quest8.Progress)
-									msg := tgbotapi.NewMessage(message.Chat.ID, activeText)
-									h.sendMessage(msg)
-									return
-								}
-
-								if quest8.Status == "completed" {
-									msg := tgbotapi.NewMessage(message.Chat.ID, "🎉 Ты завершил всю цепочку ЛОР-квестов! Продолжение следует...")
-									h.sendMessage(msg)
-									return
-								}
-							}
-						}
-					}
-				}
-			}
-		}
-	}
-
-	// Квест 4 выполнен, проверяем квест 5
-	quest5, err := h.db.GetPlayerQuest(player.ID, 5)
-	if err != nil {
-		log.Printf("Error getting quest 5: %v", err)
-		msg := tgbotapi.NewMessage(message.Chat.ID, "Произошла ошибка при получении квеста.")
-		h.sendMessage(msg)
+		events.Publish(h.bus, events.QuestCompleted{Player: playerRef(userID, player.ID, message.Chat.ID), QuestID: quest.ID})
 		return
 	}
-	if quest5 == nil || quest5.Status == "available" {
-		// Квест 5 еще не создан или доступен для принятия
-		if quest5 == nil {
-			// Создаем квест 5, если его нет
-			err := h.db.CreateQuest(player.ID, 5, 1) // Квест 5: совершить первую охоту
-			if err != nil {
-				log.Printf("Error creating quest 5: %v", err)
-				msg := tgbotapi.NewMessage(message.Chat.ID, "Произошла ошибка при создании квеста.")
-				h.sendMessage(msg)
-				return
-			}
-		}
-		// Показываем предложение квеста 5
-		questText := `🎯 Квест 5: Звериный взгляд
-Задание: Соверши первую охоту.
-Для выполнения задания необходимо проследовать в Добыча/Лес/Охота.
-Награда: 🎖 10 опыта + 📖 Страница 5 «След древних»`
-		msg := tgbotapi.NewMessage(message.Chat.ID, questText)
-		acceptBtn := tgbotapi.NewInlineKeyboardButtonData("Принять", "quest_accept_5")
-		declineBtn := tgbotapi.NewInlineKeyboardButtonData("Отказ", "quest_decline_5")
-		keyboard := tgbotapi.NewInlineKeyboardMarkup(
-			tgbotapi.NewInlineKeyboardRow(acceptBtn, declineBtn),
-		)
-		msg.ReplyMarkup = keyboard
-		h.sendMessage(msg)
-		return
-	}
-	if quest5.Status == "active" {
-		activeText := fmt.Sprintf(`Активный квест: 🎯 Квест 5: Звериный взгляд
-Задание: Соверши первую охоту (%d/1)
-Для выполнения задания необходимо проследовать в Добыча/Лес/Охота.
-Награда: 🎖 10 опыта + 📖 Страница 5 «След древних»`, quest5.Progress)
-		msg := tgbotapi.NewMessage(message.Chat.ID, activeText)
-		h.sendMessage(msg)
-		return
-	}
-	if quest5.Status == "completed" {
-		// Квест 5 выполнен, проверяем квест 6
-		quest6, err := h.db.GetPlayerQuest(player.ID, 6)
-		if err != nil {
-			log.Printf("Error getting quest 6: %v", err)
-			msg := tgbotapi.NewMessage(message.Chat.ID, "Произошла ошибка при получении квеста.")
-			h.sendMessage(msg)
-			return
-		}
-		if quest6 == nil || quest6.Status == "available" {
-			// Квест 6 еще не создан или доступен для принятия
-			if quest6 == nil {
-				// Создаем квест 6, если его нет
-				err := h.db.CreateQuest(player.ID, 6, 5) // Квест 6: прочитать 5 страниц
-				if err != nil {
-					log.Printf("Error creating quest 6: %v", err)
-					msg := tgbotapi.NewMessage(message.Chat.ID, "Произошла ошибка при создании квеста.")
-					h.sendMessage(msg)
-					return
-				}
-			}
-			// Показываем предложение квеста 6
-			questText := `📘 Квест 6: Живое Хранилище
-Задание: Открой 5 страниц лора.
-Для выполнения задания необходимо в инвентаре в разделе "Страницы"
-вызвать команду look и затем прочитать 5 страниц ЛОРа от 1-й до 5-й.
-Награда: 🎖 10 опыта + 📖 Страница 6 «Сон о башне»`
-			msg := tgbotapi.NewMessage(message.Chat.ID, questText)
-			acceptBtn := tgbotapi.NewInlineKeyboardButtonData("Принять", "quest_accept_6")
-			declineBtn := tgbotapi.NewInlineKeyboardButtonData("Отказ", "quest_decline_6")
-			keyboard := tgbotapi.NewInlineKeyboardMarkup(
-				tgbotapi.NewInlineKeyboardRow(acceptBtn, declineBtn),
-			)
-			msg.ReplyMarkup = keyboard
-			h.sendMessage(msg)
-			return
-		}
-		if quest6.Status == "active" {
-			activeText := fmt.Sprintf(`Активный квест: 📘 Квест 6: Живое Хранилище
-Задание: Открой 5 страниц лора (%d/5)
-Для выполнения задания необходимо в инвентаре в разделе "Страницы"
-вызвать команду look и затем прочитать 5 страниц ЛОРа от 1-й до 5-й.
-Награда: 🎖 10 опыта + 📖 Страница 6 «Сон о башне»`, quest6.Progress)
-			msg := tgbotapi.NewMessage(message.Chat.ID, activeText)
-			h.sendMessage(msg)
-			return
-		}
-		if quest6.Status == "completed" {
-			msg := tgbotapi.NewMessage(message.Chat.ID, "🎉 Ты завершил всю цепочку ЛОР-квестов! Продолжение следует...")
-			h.sendMessage(msg)
-			return
-		}
-	}
 
-	// Квест 6 выполнен, проверяем квест 7
-	quest7, err := h.db.GetPlayerQuest(player.ID, 7)
-	if err != nil {
-		log.Printf("Error getting quest 7: %v", err)
-		msg := tgbotapi.NewMessage(message.Chat.ID, "Произошла ошибка при получении квеста.")
-		h.sendMessage(msg)
-		return
-	}
-	if quest7 == nil || quest7.Status == "available" {
-		// Квест 7 еще не создан или доступен для принятия
-		if quest7 == nil {
-			// Создаем квест 7, если его нет
-			err := h.db.CreateQuest(player.ID, 7, 3) // Квест 7: съесть 3 ягоды
-			if err != nil {
-				log.Printf("Error creating quest 7: %v", err)
-				msg := tgbotapi.NewMessage(message.Chat.ID, "Произошла ошибка при создании квеста.")
-				h.sendMessage(msg)
-				return
-			}
-		}
-		// Показываем предложение квеста 7
-		questText := `🍔 Квест 7: Перекус
-Задание: Съешь 3 ягоды
-Для выполнения квеста в инвентаре напротив Лесной ягоды выполнить команду eat.
-Награда: 🎖 10 опыта + 📖 Страница 7 «Шёпот ветра»`
-		msg := tgbotapi.NewMessage(message.Chat.ID, questText)
-		acceptBtn := tgbotapi.NewInlineKeyboardButtonData("Принять", "quest_accept_7")
-		declineBtn := tgbotapi.NewInlineKeyboardButtonData("Отказ", "quest_decline_7")
-		keyboard := tgbotapi.NewInlineKeyboardMarkup(
-			tgbotapi.NewInlineKeyboardRow(acceptBtn, declineBtn),
-		)
-		msg.ReplyMarkup = keyboard
-		h.sendMessage(msg)
-		return
-	}
-	if quest7.Status == "active" {
-		activeText := fmt.Sprintf(`Активный квест: 🍔 Квест 7: Перекус
-Задание: Съешь 3 ягоды (%d/3)
-Для выполнения квеста необходимо в инвентаре напротив предмета "Лесная ягода" выполнить команду eat.
-Награда: 🎖 10 опыта + 📖 Страница 7 «Шёпот ветра»`, quest7.Progress)
-		msg := tgbotapi.NewMessage(message.Chat.ID, activeText)
-		h.sendMessage(msg)
-		return
-	}
-	if quest7.Status == "completed" {
-		msg := tgbotapi.NewMessage(message.Chat.ID, "🎉 Ты завершил всю цепочку ЛОР-квестов! Продолжение следует...")
-		h.sendMessage(msg)
-		return
-	}
+	// Показываем предложение квеста
+	msg := tgbotapi.NewMessage(message.Chat.ID, questOfferText(quest))
+
+	// Создаем инлайн кнопки
+	acceptBtn := tgbotapi.NewInlineKeyboardButtonData("Принять", fmt.Sprintf("quest_accept_%d", quest.ID))
+	declineBtn := tgbotapi.NewInlineKeyboardButtonData("Отказ", fmt.Sprintf("quest_decline_%d", quest.ID))
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(acceptBtn, declineBtn),
+	)
+	msg.ReplyMarkup = keyboard
+	h.sendMessage(msg)
 }
 
 func (h *BotHandlers) handleDailyQuests(message *tgbotapi.Message) {
@@ -3957,8 +1734,7 @@ func (h *BotHandlers) handleLookPages(message *tgbotapi.Message) {
 	msg.ReplyMarkup = keyboard
 	h.sendMessage(msg)
 
-	// Проверяем прогресс квеста 6 после успешного чтения страницы
-	h.checkLorePagesQuestProgressSequential(message.From.ID, message.Chat.ID, player.ID, firstPage)
+	events.Publish(h.bus, events.PageRead{Player: playerRef(message.From.ID, player.ID, message.Chat.ID), Page: firstPage})
 }
 
 func (h *BotHandlers) handleReadPage(message *tgbotapi.Message) {
@@ -4079,8 +1855,7 @@ func (h *BotHandlers) handleReadPage(message *tgbotapi.Message) {
 	msg := tgbotapi.NewMessage(message.Chat.ID, fullText)
 	h.sendMessage(msg)
 
-	// Проверяем прогресс квеста 6 после успешного чтения страницы
-	h.checkLorePagesQuestProgressSequential(message.From.ID, message.Chat.ID, player.ID, 6)
+	events.Publish(h.bus, events.PageRead{Player: playerRef(message.From.ID, player.ID, message.Chat.ID), Page: 6})
 
 	return
 }
@@ -4121,8 +1896,7 @@ func (h *BotHandlers) handleReadPage1(message *tgbotapi.Message) {
 	msg := tgbotapi.NewMessage(message.Chat.ID, pageText)
 	h.sendMessage(msg)
 
-	// Проверяем прогресс квеста 6 после успешного чтения страницы
-	h.checkLorePagesQuestProgressSequential(message.From.ID, message.Chat.ID, player.ID, 1)
+	events.Publish(h.bus, events.PageRead{Player: playerRef(message.From.ID, player.ID, message.Chat.ID), Page: 1})
 }
 
 func (h *BotHandlers) handleReadPage2(message *tgbotapi.Message) {
@@ -4161,8 +1935,7 @@ func (h *BotHandlers) handleReadPage2(message *tgbotapi.Message) {
 	msg := tgbotapi.NewMessage(message.Chat.ID, pageText)
 	h.sendMessage(msg)
 
-	// Проверяем прогресс квеста 6 после успешного чтения страницы
-	h.checkLorePagesQuestProgressSequential(message.From.ID, message.Chat.ID, player.ID, 2)
+	events.Publish(h.bus, events.PageRead{Player: playerRef(message.From.ID, player.ID, message.Chat.ID), Page: 2})
 }
 
 func (h *BotHandlers) handleReadPage3(message *tgbotapi.Message) {
@@ -4201,8 +1974,7 @@ func (h *BotHandlers) handleReadPage3(message *tgbotapi.Message) {
 	msg := tgbotapi.NewMessage(message.Chat.ID, pageText)
 	h.sendMessage(msg)
 
-	// Проверяем прогресс квеста 6 после успешного чтения страницы
-	h.checkLorePagesQuestProgressSequential(message.From.ID, message.Chat.ID, player.ID, 3)
+	events.Publish(h.bus, events.PageRead{Player: playerRef(message.From.ID, player.ID, message.Chat.ID), Page: 3})
 }
 
 func (h *BotHandlers) handleReadPage4(message *tgbotapi.Message) {
@@ -4240,8 +2012,7 @@ func (h *BotHandlers) handleReadPage4(message *tgbotapi.Message) {
 	msg := tgbotapi.NewMessage(message.Chat.ID, pageText)
 	h.sendMessage(msg)
 
-	// Проверяем прогресс квеста 6 после успешного чтения страницы
-	h.checkLorePagesQuestProgressSequential(message.From.ID, message.Chat.ID, player.ID, 4)
+	events.Publish(h.bus, events.PageRead{Player: playerRef(message.From.ID, player.ID, message.Chat.ID), Page: 4})
 }
 
 func (h *BotHandlers) handleReadPage5(message *tgbotapi.Message) {
@@ -4281,8 +2052,7 @@ func (h *BotHandlers) handleReadPage5(message *tgbotapi.Message) {
 	msg := tgbotapi.NewMessage(message.Chat.ID, pageText)
 	h.sendMessage(msg)
 
-	// Проверяем прогресс квеста 6 после успешного чтения страницы
-	h.checkLorePagesQuestProgressSequential(message.From.ID, message.Chat.ID, player.ID, 5)
+	events.Publish(h.bus, events.PageRead{Player: playerRef(message.From.ID, player.ID, message.Chat.ID), Page: 5})
 }
 
 func (h *BotHandlers) handleReadPage6(message *tgbotapi.Message) {
@@ -4321,8 +2091,7 @@ func (h *BotHandlers) handleReadPage6(message *tgbotapi.Message) {
 	msg := tgbotapi.NewMessage(message.Chat.ID, pageText)
 	h.sendMessage(msg)
 
-	// Проверяем прогресс квеста 6 после успешного чтения страницы
-	h.checkLorePagesQuestProgressSequential(message.From.ID, message.Chat.ID, player.ID, 6)
+	events.Publish(h.bus, events.PageRead{Player: playerRef(message.From.ID, player.ID, message.Chat.ID), Page: 6})
 }
 
 func (h *BotHandlers) handleReadPage7(message *tgbotapi.Message) {
@@ -4397,311 +2166,11 @@ func (h *BotHandlers) handleReadPage8(message *tgbotapi.Message) {
 Обломок кости с выжженным символом. Одинокую статую, стоящую посреди леса, покрытую мхом, но не разрушенную."`
 
 	msg := tgbotapi.NewMessage(message.Chat.ID, pageText)
-	h.sendMessage(msg)
-}
-
-// Функции для сбора в лесу
-func (h *BotHandlers) startGatheringAtPosition(userID int64, chatID int64, resourceName string, duration int, callbackID string, rowStr, colStr string) {
-	row, _ := strconv.Atoi(rowStr)
-	col, _ := strconv.Atoi(colStr)
-
-	h.startGathering(userID, chatID, resourceName, duration, callbackID, row, col)
-}
-
-func (h *BotHandlers) startGathering(userID int64, chatID int64, resourceName string, duration int, callbackID string, row, col int) {
-	// Проверяем, идет ли уже сбор, добыча/рубка или крафт
-	if _, exists := h.gatheringTimers[userID]; exists {
-		msg := tgbotapi.NewMessage(chatID, "Нельзя начинать новую добычу, пока не закончена текущая.")
-		h.sendMessage(msg)
-		callbackConfig := tgbotapi.NewCallback(callbackID, "")
-		h.requestAPI(callbackConfig)
-		return
-	}
-	if _, exists := h.miningTimers[userID]; exists {
-		msg := tgbotapi.NewMessage(chatID, "Нельзя начинать новую добычу, пока не закончена текущая.")
-		h.sendMessage(msg)
-		callbackConfig := tgbotapi.NewCallback(callbackID, "")
-		h.requestAPI(callbackConfig)
-		return
-	}
-	if _, exists := h.choppingTimers[userID]; exists {
-		msg := tgbotapi.NewMessage(chatID, "Нельзя начинать новую добычу, пока не закончена текущая.")
-		h.sendMessage(msg)
-		callbackConfig := tgbotapi.NewCallback(callbackID, "")
-		h.requestAPI(callbackConfig)
-		return
-	}
-	if _, exists := h.craftingTimers[userID]; exists {
-		msg := tgbotapi.NewMessage(chatID, "Нельзя совершать действия пока идет создание предметов.")
-		h.sendMessage(msg)
-		callbackConfig := tgbotapi.NewCallback(callbackID, "")
-		h.requestAPI(callbackConfig)
-		return
-	}
-
-	// Получаем игрока
-	player, err := h.db.GetPlayer(userID)
-	if err != nil {
-		log.Printf("Error getting player: %v", err)
-		return
-	}
-
-	// Проверяем наличие ножа
-	hasTool, durability, err := h.db.HasToolInInventory(player.ID, "Простой нож")
-	if err != nil {
-		log.Printf("Error checking tool: %v", err)
-		return
-	}
-
-	if !hasTool {
-		msg := tgbotapi.NewMessage(chatID, `В инвентаре нет предмета "Простой нож".`)
-		h.sendMessage(msg)
-		callbackConfig := tgbotapi.NewCallback(callbackID, "")
-		h.requestAPI(callbackConfig)
-		return
-	}
-
-	// Отвечаем на callback
-	callbackConfig := tgbotapi.NewCallback(callbackID, "")
-	h.requestAPI(callbackConfig)
-
-	// Удаляем предыдущее сообщение о результате сбора, если оно существует
-	if session, exists := h.gatheringSessions[userID]; exists && session.ResultMessageID != 0 {
-		deleteResultMsg := tgbotapi.NewDeleteMessage(chatID, session.ResultMessageID)
-		h.requestAPI(deleteResultMsg)
-		session.ResultMessageID = 0 // Сбрасываем ID
-	}
-
-	// Отправляем сообщение о начале сбора
-	initialText := fmt.Sprintf(`Идет сбор "%s". Время сбора %d сек.
-		
-%s 0%%`, resourceName, duration, h.createProgressBar(0, 100))
-
-	gatheringMsg := tgbotapi.NewMessage(chatID, initialText)
-	sentMsg, _ := h.sendMessageWithResponse(gatheringMsg)
-
-	// Запускаем горутину для обновления прогресс бара
-	go h.updateGatheringProgress(userID, chatID, sentMsg.MessageID, resourceName, duration, durability, row, col)
-
-	// Создаем заглушку таймера
-	timer := time.NewTimer(time.Duration(duration) * time.Second)
-	h.gatheringTimers[userID] = timer
-}
-
-func (h *BotHandlers) updateGatheringProgress(userID int64, chatID int64, messageID int, resourceName string, totalDuration int, durability int, row, col int) {
-	startTime := time.Now()
-	ticker := time.NewTicker(1 * time.Second)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ticker.C:
-			elapsed := time.Since(startTime).Seconds()
-			progress := int(elapsed)
-
-			if progress >= totalDuration {
-				// Сбор завершен
-				h.completeGathering(userID, chatID, resourceName, durability, messageID, row, col)
-				return
-			}
-
-			// Обновляем прогресс бар
-			percentage := int((elapsed / float64(totalDuration)) * 100)
-			progressBar := h.createProgressBar(progress, totalDuration)
-
-			newText := fmt.Sprintf(`Начался сбор "%s". Время сбора %d сек.
-			
-%s %d%%`, resourceName, totalDuration, progressBar, percentage)
-
-			// Редактируем сообщение
-			editMsg := tgbotapi.NewEditMessageText(chatID, messageID, newText)
-			h.editMessage(editMsg)
-
-		case <-time.After(time.Duration(totalDuration+1) * time.Second):
-			// Таймаут на случай, если что-то пошло не так
-			return
-		}
-	}
-}
-
-func (h *BotHandlers) completeGathering(userID int64, chatID int64, resourceName string, oldDurability int, messageID int, row, col int) {
-	// Получаем игрока
-	player, err := h.db.GetPlayer(userID)
-	if err != nil {
-		log.Printf("Error getting player: %v", err)
-		return
-	}
-
-	// Добавляем ресурс в инвентарь
-	if err := h.db.AddItemToInventory(player.ID, resourceName, 1); err != nil {
-		log.Printf("Error adding item to inventory: %v", err)
-	}
-
-	// Обновляем прочность ножа и сытость
-	if err := h.db.UpdateItemDurability(player.ID, "Простой нож", 1); err != nil {
-		log.Printf("Error updating item durability: %v", err)
-	}
-	if err := h.db.UpdatePlayerSatiety(player.ID, -1); err != nil {
-		log.Printf("Error updating player satiety: %v", err)
-	}
-
-	// Добавляем опыт за сбор
-	levelUp, newLevel, err := h.db.UpdateGatheringExperience(player.ID, 2)
-	if err != nil {
-		log.Printf("Error updating gathering experience: %v", err)
-	}
-
-	// Получаем обновленные данные
-	updatedPlayer, _ := h.db.GetPlayer(userID)
-	updatedGathering, _ := h.db.GetOrCreateGathering(player.ID)
-
-	// Удаляем сообщение о сборе
-	deleteMsg := tgbotapi.NewDeleteMessage(chatID, messageID)
-	h.requestAPI(deleteMsg)
-
-	// Показываем результат с системой уровней для сбора
-	resultText := fmt.Sprintf(`✅ Ты собрал "%s"!
-Получено опыта: 2
-Сытость: %d/100
-Прочность ножа: %d/100
-До следующего уровня: %d опыта`,
-		resourceName,
-		updatedPlayer.Satiety,
-		oldDurability-1,
-		(updatedGathering.Level*100)-updatedGathering.Experience)
-
-	msg := tgbotapi.NewMessage(chatID, resultText)
-	resultResponse, _ := h.sendMessageWithResponse(msg)
-
-	// Если уровень повысился, показываем сообщение о повышении
-	if levelUp {
-		levelUpText := fmt.Sprintf("🎉 Поздравляем! Уровень сбора повышен до %d уровня!", newLevel)
-		levelUpMsg := tgbotapi.NewMessage(chatID, levelUpText)
-		h.sendMessage(levelUpMsg)
-	}
-
-	// Сохраняем ID сообщения с результатом в сессии
-	if session, exists := h.gatheringSessions[userID]; exists {
-		session.ResultMessageID = resultResponse.MessageID
-	}
-
-	// Убираем таймер
-	delete(h.gatheringTimers, userID)
-
-	// Проверяем прогресс квеста 4 (сбор лесных ягод)
-	if resourceName == "Лесная ягода" {
-		h.checkBerryQuestProgress(userID, chatID, player.ID)
-	}
-
-	// Обновляем поле - убираем собранный ресурс
-	if session, exists := h.gatheringSessions[userID]; exists {
-		session.Resources[row][col] = ""
-
-		// Проверяем, остались ли ресурсы в поле
-		totalResources := 0
-		for i := 0; i < 3; i++ {
-			for j := 0; j < 3; j++ {
-				if session.Resources[i][j] != "" {
-					totalResources++
-				}
-			}
-		}
-
-		if totalResources > 0 {
-			// Обновляем инлайн клавиатуру с новым состоянием поля
-			h.updateGatheringField(chatID, session.Resources, session.FieldMessageID)
-			// Обновляем информационное сообщение с актуальными данными
-			h.updateGatheringInfoMessage(userID, chatID, updatedGathering, session.InfoMessageID)
-		} else {
-			// Поле истощено, устанавливаем кулдаун
-			if err := h.db.ExhaustGathering(userID); err != nil {
-				log.Printf("Error exhausting gathering: %v", err)
-			}
-
-			// Устанавливаем таймер кулдауна на 60 секунд
-			h.gatheringCooldowns[userID] = time.Now().Add(60 * time.Second)
-
-			// Удаляем сообщение с полем сбора
-			deleteFieldMsg := tgbotapi.NewDeleteMessage(chatID, session.FieldMessageID)
-			h.requestAPI(deleteFieldMsg)
-
-			// Удаляем сообщение с информацией о сборе
-			deleteInfoMsg := tgbotapi.NewDeleteMessage(chatID, session.InfoMessageID)
-			h.requestAPI(deleteInfoMsg)
-
-			exhaustMsg := tgbotapi.NewMessage(chatID, `⚠️ Ягодные кусты истощены! Необходимо подождать 1 минуту до восстановления.
-Нажми кнопку "🌿 Сбор" чтобы проверить готовность.`)
-			h.sendForestKeyboard(exhaustMsg)
-
-			// Удаляем сессию
-			delete(h.gatheringSessions, userID)
-		}
-	}
-}
-
-func (h *BotHandlers) updateGatheringField(chatID int64, field [][]string, messageID int) {
-	text := "Выберите ресурс для сбора:"
-
-	// Создаем инлайн клавиатуру на основе переданного поля
-	var keyboard [][]tgbotapi.InlineKeyboardButton
-	for i := 0; i < 3; i++ {
-		var row []tgbotapi.InlineKeyboardButton
-		for j := 0; j < 3; j++ {
-			cell := field[i][j]
-			var callbackData string
-
-			switch cell {
-			case "🍇":
-				callbackData = fmt.Sprintf("gathering_berry_%d_%d", i, j)
-			default:
-				callbackData = fmt.Sprintf("gathering_empty_%d_%d", i, j)
-				cell = " "
-			}
-
-			button := tgbotapi.NewInlineKeyboardButtonData(cell, callbackData)
-			row = append(row, button)
-		}
-		keyboard = append(keyboard, row)
-	}
-
-	// Редактируем существующее сообщение вместо отправки нового
-	editMsg := tgbotapi.NewEditMessageTextAndMarkup(chatID, messageID, text, tgbotapi.NewInlineKeyboardMarkup(keyboard...))
-	h.editMessage(editMsg)
-}
-
-func (h *BotHandlers) updateGatheringInfoMessage(userID int64, chatID int64, gathering *models.Gathering, messageID int) {
-	// Вычисляем опыт до следующего уровня
-	expToNext := (gathering.Level * 100) - gathering.Experience
-
-	infoText := fmt.Sprintf(`🌿 Сбор (Уровень %d)
-До следующего уровня: %d опыта
-
-Доступные ресурсы:
-🍇 Ягоды`, gathering.Level, expToNext)
-
-	// Удаляем старое сообщение
-	deleteMsg := tgbotapi.NewDeleteMessage(chatID, messageID)
-	h.requestAPI(deleteMsg)
-
-	// Создаем новое сообщение с клавиатурой
-	gatheringKeyboard := tgbotapi.NewReplyKeyboard(
-		tgbotapi.NewKeyboardButtonRow(
-			tgbotapi.NewKeyboardButton("◀️ Назад"),
-		),
-	)
-	gatheringKeyboard.ResizeKeyboard = true
-
-	newMsg := tgbotapi.NewMessage(chatID, infoText)
-	newMsg.ReplyMarkup = gatheringKeyboard
-	newResponse, _ := h.sendMessageWithResponse(newMsg)
-
-	// Обновляем ID сообщения в сессии
-	if session, exists := h.gatheringSessions[userID]; exists {
-		session.InfoMessageID = newResponse.MessageID
-	}
+	h.sendMessage(msg)
 }
 
-func (h *BotHandlers) startCrafting(userID int64, chatID int64, itemName string, quantity int) {
+// startCrafting списывает ингредиенты и запускает создание quantity раз по рецепту
+func (h *BotHandlers) startCrafting(userID int64, chatID int64, recipe models.Recipe, quantity int) {
 	// Получаем игрока
 	player, err := h.db.GetPlayer(userID)
 	if err != nil {
@@ -4709,59 +2178,92 @@ func (h *BotHandlers) startCrafting(userID int64, chatID int64, itemName string,
 		return
 	}
 
-	// Для березового бруса: потребляем березу (2 березы за 1 брус)
-	if itemName == "Березовый брус" {
-		requiredBirch := quantity * 2
-		err := h.db.ConsumeItem(player.ID, "Береза", requiredBirch)
-		if err != nil {
-			log.Printf("Error consuming birch: %v", err)
-			msg := tgbotapi.NewMessage(chatID, "Произошла ошибка при потреблении ресурсов.")
-			h.sendMessage(msg)
-			return
-		}
+	// Голодный игрок не может работать
+	if recipe.SatietyCost > 0 && !h.survival.CanWork(player.Satiety) {
+		msg := tgbotapi.NewMessage(chatID, starvingText)
+		h.sendMessage(msg)
+		return
 	}
 
-	// Вычисляем общее время крафта
-	var totalDuration int
-	if itemName == "Простая хижина" {
-		totalDuration = 120
-	} else {
-		totalDuration = quantity * 20
+	// Ингредиенты и топливо списываются сразу и целиком: либо все, либо ничего
+	change := models.InventoryChange{Reason: "craft:" + recipe.Key}
+	for _, ingredient := range recipe.Ingredients {
+		change.Consume = append(change.Consume, models.ItemDelta{ItemName: ingredient.ItemName, Quantity: ingredient.Quantity * quantity})
+	}
+	burnedText, ok := h.burnFuel(player.ID, &change, recipe.Fuel*quantity)
+	if !ok {
+		msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("Недостаточно топлива: нужно %d ед.", recipe.Fuel*quantity))
+		h.sendMessage(msg)
+		return
+	}
+	err = h.db.ApplyInventoryChange(player.ID, change)
+	var insufficient *database.InsufficientItemError
+	if errors.As(err, &insufficient) {
+		msg := tgbotapi.NewMessage(chatID, fmt.Sprintf(`Недостаточно предмета "%s".`, insufficient.ItemName))
+		h.sendMessage(msg)
+		return
+	}
+	if err != nil {
+		log.Printf("Error consuming ingredients for %s: %v", recipe.Key, err)
+		msg := tgbotapi.NewMessage(chatID, "Произошла ошибка при потреблении ресурсов.")
+		h.sendMessage(msg)
+		return
 	}
 
-	// Отправляем сообщение о начале крафта
-	craftText := fmt.Sprintf(`Идет создание предмета "%s". Время создания %d сек.
+	if burnedText != "" {
+		msg := tgbotapi.NewMessage(chatID, strings.TrimPrefix(burnedText, "\n\n"))
+		h.sendMessage(msg)
+	}
 
-⏳ 0%%`, itemName, totalDuration)
+	// Вычисляем общее время крафта: голод замедляет работу, баффы от еды ускоряют
+	totalDuration := h.actionDuration(player, recipe.CraftTime*quantity)
 
-	msg := tgbotapi.NewMessage(chatID, craftText)
+	// Отправляем сообщение о начале крафта
+	msg := tgbotapi.NewMessage(chatID, craftingText(recipe, totalDuration, "⏳", 0))
 	response, err := h.sendMessageWithResponse(msg)
 	if err != nil {
 		log.Printf("Error sending craft message: %v", err)
 		return
 	}
 
-	// Запускаем таймер крафта
-	h.craftingTimers[userID] = time.NewTimer(time.Duration(totalDuration) * time.Second)
+	// Запоминаем крафт с абсолютным временем окончания. В ItemName хранится ключ рецепта.
+	now := h.clock.Now()
+	action := &models.TimedAction{
+		PlayerID: userID, Kind: "crafting", ChatID: chatID, MessageID: response.MessageID,
+		ItemName: recipe.Key, Quantity: quantity,
+		StartedAt: now, EndsAt: now.Add(time.Duration(totalDuration) * time.Second),
+	}
+	h.playerState(userID).Crafting = action
 
 	// Запускаем горутину для обновления прогресса
-	go h.updateCraftingProgress(userID, chatID, response.MessageID, itemName, quantity, totalDuration)
+	go h.updateCraftingProgress(userID, chatID, response.MessageID, recipe, quantity, totalDuration, action.StartedAt)
+}
+
+// craftingText - текст сообщения с прогрессом создания
+func craftingText(recipe models.Recipe, totalDuration int, progressBar string, percentage int) string {
+	if recipe.Station == catalog.StationConstruction {
+		return fmt.Sprintf(`Идет строительство объекта "%s". Время строительства %d сек.
+
+%s %d%%`, recipe.ItemName, totalDuration, progressBar, percentage)
+	}
+	return fmt.Sprintf(`Идет создание предмета "%s". Время создания %d сек.
+
+%s %d%%`, recipe.ItemName, totalDuration, progressBar, percentage)
 }
 
-func (h *BotHandlers) updateCraftingProgress(userID int64, chatID int64, messageID int, itemName string, quantity int, totalDuration int) {
-	startTime := time.Now()
-	ticker := time.NewTicker(1 * time.Second)
+func (h *BotHandlers) updateCraftingProgress(userID int64, chatID int64, messageID int, recipe models.Recipe, quantity int, totalDuration int, startTime time.Time) {
+	ticker := h.clock.NewTicker(1 * time.Second)
 	defer ticker.Stop()
 
 	for {
 		select {
-		case <-ticker.C:
-			elapsed := time.Since(startTime).Seconds()
+		case <-ticker.C():
+			elapsed := h.clock.Now().Sub(startTime).Seconds()
 			progress := int(elapsed)
 
 			if progress >= totalDuration {
 				// Крафт завершен
-				h.completeCrafting(userID, chatID, itemName, quantity, messageID)
+				h.completeCrafting(userID, chatID, recipe, quantity, messageID)
 				return
 			}
 
@@ -4769,493 +2271,158 @@ func (h *BotHandlers) updateCraftingProgress(userID int64, chatID int64, message
 			percentage := int((elapsed / float64(totalDuration)) * 100)
 			progressBar := h.createProgressBar(progress, totalDuration)
 
-			newText := fmt.Sprintf(`Идет создание предмета "%s". Время создания %d сек.
-
-%s %d%%`, itemName, totalDuration, progressBar, percentage)
-
 			// Редактируем сообщение
-			editMsg := tgbotapi.NewEditMessageText(chatID, messageID, newText)
+			editMsg := tgbotapi.NewEditMessageText(chatID, messageID, craftingText(recipe, totalDuration, progressBar, percentage))
 			h.editMessage(editMsg)
 
-		case <-time.After(time.Duration(totalDuration+1) * time.Second):
-			// Таймаут на случай, если что-то пошло не так
-			return
 		}
 	}
 }
 
-func (h *BotHandlers) completeCrafting(userID int64, chatID int64, itemName string, quantity int, messageID int) {
+func (h *BotHandlers) completeCrafting(userID int64, chatID int64, recipe models.Recipe, quantity int, messageID int) {
+	// Вызывается из горутины прогресса, поэтому сами захватываем состояние игрока
+	unlock := h.lockPlayer(userID)
+	defer unlock()
+	defer func() { h.playerState(userID).Crafting = nil }()
+
 	player, err := h.db.GetPlayer(userID)
 	if err != nil {
 		log.Printf("Error getting player: %v", err)
 		return
 	}
 
-	if itemName == "Простая хижина" {
-		// Удаляем ресурсы
-		requirements := []struct {
-			ItemName string
-			Quantity int
-		}{
-			{"Береза", 20},
-			{"Березовый брус", 10},
-			{"Камень", 15},
-			{"Лесная ягода", 10},
-		}
-		for _, req := range requirements {
-			err := h.db.RemoveItemFromInventory(player.ID, req.ItemName, req.Quantity)
-			if err != nil {
-				log.Printf("Error removing item from inventory: %v", err)
-			}
-		}
-		// Обновляем статус хижины
-		err = h.db.UpdateSimpleHutBuilt(player.ID, true)
-		if err != nil {
-			log.Printf("Error updating simple hut status: %v", err)
-		}
-		// Проверяем квест 8
-		quest8, err := h.db.GetPlayerQuest(player.ID, 8)
-		if err != nil {
-			log.Printf("Error getting quest 8: %v", err)
-		} else if quest8 != nil {
-			if quest8.Status == "active" {
-				err = h.db.UpdateQuestProgress(player.ID, 8, 1)
-				if err != nil {
-					log.Printf("Error updating quest progress: %v", err)
-				}
-				if quest8.Progress+1 >= quest8.Target {
-					err = h.db.UpdateQuestStatus(player.ID, 8, "completed")
-					if err != nil {
-						log.Printf("Error completing quest 8: %v", err)
-					}
-					err = h.db.UpdatePlayerExperience(player.ID, 10)
-					if err != nil {
-						log.Printf("Error updating player experience: %v", err)
-					}
-					h.addPage8IfNotExists(player.ID)
-					questCompleteText := `🛖 Квест 8: Под крышей ВЫПОЛНЕН!
-Получена награда:
-🎖 10 опыта
-📖 Страница 8 «След древних»`
-					msg := tgbotapi.NewMessage(chatID, questCompleteText)
-					h.sendMessage(msg)
-				}
-			} else if quest8.Status == "completed" {
-				// Если квест уже завершён, но страницы нет — добавить её
-				h.addPage8IfNotExists(player.ID)
-			}
+	crafter := playerRef(userID, player.ID, chatID)
+
+	// Удаляем сообщение о крафте
+	deleteMsg := tgbotapi.NewDeleteMessage(chatID, messageID)
+	h.requestAPI(deleteMsg)
+
+	if recipe.Station == catalog.StationConstruction {
+		if err := h.completeBuilding(crafter, player, recipe, quantity); err != nil {
+			log.Printf("Error completing building %s: %v", recipe.Key, err)
+			h.refundCrafting(chatID, player.ID, recipe, quantity)
 		}
-		// Удаляем сообщение о крафте
-		deleteMsg := tgbotapi.NewDeleteMessage(chatID, messageID)
-		h.requestAPI(deleteMsg)
-		completeText := `✅ Строительство "Простая хижина" завершено!
-Теперь у вас есть укрытие от непогоды.`
-		msg := tgbotapi.NewMessage(chatID, completeText)
-		h.sendMessage(msg)
-		delete(h.craftingTimers, userID)
 		return
 	}
 
-	// Обычный крафт
-	if err := h.db.AddItemToInventory(player.ID, itemName, quantity); err != nil {
+	// Инструменты создаются с полной прочностью
+	produced := recipe.OutputQuantity * quantity
+	err = h.db.ApplyInventoryChange(player.ID, models.InventoryChange{
+		Reason: "craft:" + recipe.Key,
+		Grant:  []models.ItemDelta{{ItemName: recipe.ItemName, Quantity: produced, Durability: recipe.OutputDurability}},
+	})
+	if err != nil {
 		log.Printf("Error adding crafted items to inventory: %v", err)
+		h.refundCrafting(chatID, player.ID, recipe, quantity)
+		return
 	}
-	if err := h.db.UpdatePlayerSatiety(player.ID, -quantity); err != nil {
+
+	if err := h.changeSatiety(crafter, -recipe.SatietyCost*quantity); err != nil {
 		log.Printf("Error updating player satiety: %v", err)
 	}
+
 	updatedPlayer, err := h.db.GetPlayer(userID)
 	if err != nil {
 		log.Printf("Error getting updated player: %v", err)
 		updatedPlayer = player
 	}
-	deleteMsg := tgbotapi.NewDeleteMessage(chatID, messageID)
-	h.requestAPI(deleteMsg)
 	resultText := fmt.Sprintf(`✅ Создание завершено!
 Получено: "%s" x%d
-Сытость: %d/100`, itemName, quantity, updatedPlayer.Satiety)
+Сытость: %d/100`, recipe.ItemName, produced, updatedPlayer.Satiety)
 	msg := tgbotapi.NewMessage(chatID, resultText)
 	h.sendMessage(msg)
-	if itemName == "Березовый брус" {
-		h.checkBirchPlankQuestProgress(userID, chatID, player.ID, quantity)
-	}
-	delete(h.craftingTimers, userID)
-}
-
-func (h *BotHandlers) checkBirchQuestProgress(userID int64, chatID int64, playerID int) {
-	// Проверяем активный квест 1 (рубка березы)
-	quest, err := h.db.GetPlayerQuest(playerID, 1)
-	if err != nil {
-		log.Printf("Error getting quest: %v", err)
-		return
-	}
-
-	// Если квест не активен, ничего не делаем
-	if quest == nil || quest.Status != "active" {
-		return
-	}
-
-	// Увеличиваем прогресс квеста
-	newProgress := quest.Progress + 1
-	err = h.db.UpdateQuestProgress(playerID, 1, newProgress)
-	if err != nil {
-		log.Printf("Error updating quest progress: %v", err)
-		return
-	}
-
-	// Проверяем, выполнен ли квест
-	if newProgress >= quest.Target {
-		// Квест выполнен!
-		err = h.db.UpdateQuestStatus(playerID, 1, "completed")
-		if err != nil {
-			log.Printf("Error completing quest: %v", err)
-			return
-		}
-
-		// Добавляем награды
-		// 10 опыта игроку
-		err = h.db.UpdatePlayerExperience(playerID, 10)
-		if err != nil {
-			log.Printf("Error updating player experience: %v", err)
-		}
-
-		// Добавляем страницу в инвентарь
-		err = h.db.AddItemToInventory(playerID, "📖 Страница 1 «Забытая тишина»", 1)
-		if err != nil {
-			log.Printf("Error adding quest item to inventory: %v", err)
-		}
-
-		// Отправляем сообщение о выполнении квеста
-		questCompleteText := `🪓 Квест 1: Дерево под топор ВЫПОЛНЕН!
-Получена награда:
-🎖 10 опыта
-📖 Страница 1 «Забытая тишина»`
 
-		msg := tgbotapi.NewMessage(chatID, questCompleteText)
-		h.sendMessage(msg)
-	}
+	events.Publish(h.bus, events.ItemCrafted{Player: crafter, RecipeKey: recipe.Key, Item: recipe.ItemName, Quantity: produced})
 }
 
-func (h *BotHandlers) checkBirchPlankQuestProgress(userID int64, chatID int64, playerID int, quantity int) {
-	// Проверяем активный квест 3 (создание березовых брусов)
-	quest, err := h.db.GetPlayerQuest(playerID, 3)
-	if err != nil {
-		log.Printf("Error getting quest 3: %v", err)
-		return
-	}
-
-	// Если квест не активен, ничего не делаем
-	if quest == nil || quest.Status != "active" {
-		return
-	}
-
-	// Увеличиваем прогресс квеста на количество созданных брусов
-	newProgress := quest.Progress + quantity
-	err = h.db.UpdateQuestProgress(playerID, 3, newProgress)
-	if err != nil {
-		log.Printf("Error updating quest 3 progress: %v", err)
-		return
+// refundCrafting возвращает ингредиенты крафта, который не удалось выдать. Топливо
+// к этому времени уже сгорело и не возвращается. Сытость за такой крафт не списывается.
+func (h *BotHandlers) refundCrafting(chatID int64, playerID int, recipe models.Recipe, quantity int) {
+	refund := models.InventoryChange{Reason: "craft_refund:" + recipe.Key}
+	for _, ingredient := range recipe.Ingredients {
+		refund.Grant = append(refund.Grant, models.ItemDelta{ItemName: ingredient.ItemName, Quantity: ingredient.Quantity * quantity})
 	}
 
-	// Проверяем, выполнен ли квест
-	if newProgress >= quest.Target {
-		// Квест выполнен!
-		err = h.db.UpdateQuestStatus(playerID, 3, "completed")
-		if err != nil {
-			log.Printf("Error completing quest 3: %v", err)
-			return
-		}
-
-		// Добавляем награды
-		// 10 опыта игроку
-		err = h.db.UpdatePlayerExperience(playerID, 10)
-		if err != nil {
-			log.Printf("Error updating player experience: %v", err)
-		}
-
-		// Добавляем страницу в инвентарь
-		err = h.db.AddItemToInventory(playerID, "📖 Страница 3 «Пробуждение»", 1)
-		if err != nil {
-			log.Printf("Error adding quest item to inventory: %v", err)
-		}
-
-		// Отправляем сообщение о выполнении квеста
-		questCompleteText := `🪚 Квест 3: Руки мастера ВЫПОЛНЕН!
-Получена награда:
-🎖 10 опыта
-📖 Страница 3 «Пробуждение»`
-
-		msg := tgbotapi.NewMessage(chatID, questCompleteText)
-		h.sendMessage(msg)
+	text := fmt.Sprintf(`Не удалось создать "%s". Ингредиенты возвращены в инвентарь.`, recipe.ItemName)
+	if err := h.db.ApplyInventoryChange(playerID, refund); err != nil {
+		log.Printf("Error refunding ingredients for %s: %v", recipe.Key, err)
+		text = fmt.Sprintf(`Не удалось создать "%s". Произошла ошибка, попробуйте позже.`, recipe.ItemName)
 	}
+	msg := tgbotapi.NewMessage(chatID, text)
+	h.sendMessage(msg)
 }
 
-func (h *BotHandlers) checkStoneQuestProgress(userID int64, chatID int64, playerID int) {
-	// Проверяем активный квест 2 (добыча камня)
-	quest, err := h.db.GetPlayerQuest(playerID, 2)
-	if err != nil {
-		log.Printf("Error getting quest 2: %v", err)
-		return
-	}
-
-	// Если квест не активен, ничего не делаем
-	if quest == nil || quest.Status != "active" {
-		return
-	}
-
-	// Увеличиваем прогресс квеста
-	newProgress := quest.Progress + 1
-	err = h.db.UpdateQuestProgress(playerID, 2, newProgress)
-	if err != nil {
-		log.Printf("Error updating quest 2 progress: %v", err)
-		return
-	}
-
-	// Проверяем, выполнен ли квест
-	if newProgress >= quest.Target {
-		// Квест выполнен!
-		err = h.db.UpdateQuestStatus(playerID, 2, "completed")
-		if err != nil {
-			log.Printf("Error completing quest 2: %v", err)
-			return
-		}
-
-		// Добавляем награды
-		// 10 опыта игроку
-		err = h.db.UpdatePlayerExperience(playerID, 10)
-		if err != nil {
-			log.Printf("Error updating player experience: %v", err)
-		}
-
-		// Добавляем страницу в инвентарь
-		err = h.db.AddItemToInventory(playerID, "📖 Страница 2 «Пепел памяти»", 1)
-		if err != nil {
-			log.Printf("Error adding quest item to inventory: %v", err)
-		}
-
-		// Отправляем сообщение о выполнении квеста
-		questCompleteText := `⛏ Квест 2: Вглубь ВЫПОЛНЕН!
-Получена награда:
-🎖 10 опыта
-📖 Страница 2 «Пепел памяти»`
-
-		msg := tgbotapi.NewMessage(chatID, questCompleteText)
-		h.sendMessage(msg)
+// isBuilt сообщает, возведена ли уже постройка с ключом рецепта
+func (h *BotHandlers) isBuilt(player *models.Player, recipeKey string) bool {
+	switch recipeKey {
+	case "simple_hut":
+		return player.SimpleHutBuilt
 	}
+	return false
 }
 
-func (h *BotHandlers) checkBerryQuestProgress(userID int64, chatID int64, playerID int) {
-	// Проверяем активный квест 4 (сбор лесных ягод)
-	quest, err := h.db.GetPlayerQuest(playerID, 4)
-	if err != nil {
-		log.Printf("Error getting quest 4: %v", err)
-		return
-	}
-
-	// Если квест не активен, ничего не делаем
-	if quest == nil || quest.Status != "active" {
-		return
-	}
-
-	// Увеличиваем прогресс квеста
-	newProgress := quest.Progress + 1
-	err = h.db.UpdateQuestProgress(playerID, 4, newProgress)
-	if err != nil {
-		log.Printf("Error updating quest 4 progress: %v", err)
-		return
-	}
-
-	// Проверяем, выполнен ли квест
-	if newProgress >= quest.Target {
-		// Квест выполнен!
-		err = h.db.UpdateQuestStatus(playerID, 4, "completed")
-		if err != nil {
-			log.Printf("Error completing quest 4: %v", err)
-			return
-		}
-
-		// Добавляем награды
-		// 10 опыта игроку
-		err = h.db.UpdatePlayerExperience(playerID, 10)
-		if err != nil {
-			log.Printf("Error updating player experience: %v", err)
-		}
-
-		// Добавляем страницу в инвентарь
-		err = h.db.AddItemToInventory(playerID, "📖 Страница 4 «Без имени»", 1)
-		if err != nil {
-			log.Printf("Error adding quest item to inventory: %v", err)
+// completeBuilding отмечает постройку возведенной, списывает сытость и сообщает о постройке.
+// Если отметить постройку не удалось, возвращает ошибку: сытость не списывается, а ни
+// сообщения, ни события о постройке нет.
+func (h *BotHandlers) completeBuilding(builder events.Player, player *models.Player, recipe models.Recipe, quantity int) error {
+	var completeText string
+	switch recipe.Key {
+	case "simple_hut":
+		// Обновляем статус хижины
+		if err := h.db.UpdateSimpleHutBuilt(player.ID, true); err != nil {
+			return err
 		}
-
-		// Отправляем сообщение о выполнении квеста
-		questCompleteText := `🍇 Квест 4: Дар леса ВЫПОЛНЕН!
-Получена награда:
-🎖 10 опыта
-📖 Страница 4 «Без имени»`
-
-		msg := tgbotapi.NewMessage(chatID, questCompleteText)
-		h.sendMessage(msg)
-	}
-}
-
-func (h *BotHandlers) checkHuntingQuestProgress(userID int64, chatID int64, playerID int) {
-	// Проверяем активный квест 5 (первая охота)
-	quest, err := h.db.GetPlayerQuest(playerID, 5)
-	if err != nil {
-		log.Printf("Error getting quest 5: %v", err)
-		return
-	}
-
-	// Если квест не активен, ничего не делаем
-	if quest == nil || quest.Status != "active" {
-		return
+		completeText = `✅ Строительство "Простая хижина" завершено!
+Теперь у вас есть укрытие от непогоды.`
+	default:
+		return fmt.Errorf("building %q has no completion handler", recipe.Key)
 	}
 
-	// Увеличиваем прогресс квеста
-	newProgress := quest.Progress + 1
-	err = h.db.UpdateQuestProgress(playerID, 5, newProgress)
-	if err != nil {
-		log.Printf("Error updating quest 5 progress: %v", err)
-		return
+	if err := h.changeSatiety(builder, -recipe.SatietyCost*quantity); err != nil {
+		log.Printf("Error updating player satiety: %v", err)
 	}
 
-	// Проверяем, выполнен ли квест
-	if newProgress >= 1 {
-		// Квест выполнен!
-		err = h.db.UpdateQuestStatus(playerID, 5, "completed")
-		if err != nil {
-			log.Printf("Error completing quest 5: %v", err)
-			return
-		}
-
-		// Добавляем награды
-		// 10 опыта игроку
-		err = h.db.UpdatePlayerExperience(playerID, 10)
-		if err != nil {
-			log.Printf("Error updating player experience: %v", err)
-		}
-
-		// Добавляем страницу 5 в инвентарь
-		err = h.db.AddItemToInventory(playerID, "📖 Страница 5 «Искра перемен»", 1)
-		if err != nil {
-			log.Printf("Error adding quest item to inventory: %v", err)
-		}
+	msg := tgbotapi.NewMessage(builder.ChatID, completeText)
+	h.sendMessage(msg)
 
-		// Отправляем сообщение о выполнении квеста
-		questCompleteText := `🎯 Квест 5: Звериный взгляд ВЫПОЛНЕН!
-Получена награда:
-🎖 10 опыта
-📖 Страница 5 «Искра перемен»`
-		msg := tgbotapi.NewMessage(chatID, questCompleteText)
-		h.sendMessage(msg)
-	}
+	events.Publish(h.bus, events.BuildingBuilt{Player: builder, Building: recipe.Key})
+	return nil
 }
 
-func (h *BotHandlers) checkLorePagesQuestProgressSequential(userID int64, chatID int64, playerID int, pageNumber int) {
-	// Проверяем активный квест 6 (чтение страниц)
-	quest, err := h.db.GetPlayerQuest(playerID, 6)
-	if err != nil {
-		log.Printf("Error getting quest 6: %v", err)
-		return
-	}
-
-	if quest == nil || quest.Status != "active" {
-		return
+// questRewardText перечисляет награду квеста через "+"
+func questRewardText(quest catalog.Quest) string {
+	var rewards []string
+	if quest.Rewards.Experience > 0 {
+		rewards = append(rewards, fmt.Sprintf("🎖 %d опыта", quest.Rewards.Experience))
 	}
-
-	// Прогресс квеста — номер последней прочитанной страницы (0..5)
-	if quest.Progress+1 == pageNumber {
-		newProgress := quest.Progress + 1
-		err = h.db.UpdateQuestProgress(playerID, 6, newProgress)
-		if err != nil {
-			log.Printf("Error updating quest 6 progress: %v", err)
-			return
-		}
-
-		if newProgress == 5 {
-			// Квест выполнен!
-			err = h.db.UpdateQuestStatus(playerID, 6, "completed")
-			if err != nil {
-				log.Printf("Error completing quest 6: %v", err)
-				return
-			}
-
-			// Выдаем награду
-			err = h.db.AddItemToInventory(playerID, "📖 Страница 6 «Наблюдающий лес»", 1)
-			if err != nil {
-				log.Printf("Error adding quest 6 reward: %v", err)
-				return
-			}
-
-			// Добавляем опыт
-			err = h.db.UpdatePlayerExperience(playerID, 10)
-			if err != nil {
-				log.Printf("Error adding quest 6 experience: %v", err)
-				return
-			}
-
-			// Отправляем сообщение о выполнении
-			msg := tgbotapi.NewMessage(chatID, `🎉 Поздравляем! Ты выполнил квест "Живое Хранилище"!
-Награда:
-🎖 +10 опыта
-📖 Страница 6 «Наблюдающий лес»`)
-			h.sendMessage(msg)
+	for _, item := range quest.Rewards.Items {
+		if item.Quantity > 1 {
+			rewards = append(rewards, fmt.Sprintf("%s x%d", item.Item, item.Quantity))
+		} else {
+			rewards = append(rewards, item.Item)
 		}
 	}
+	return strings.Join(rewards, " + ")
 }
 
-func (h *BotHandlers) finishSimpleHutBuilding(userID int64, chatID int64, messageID int) {
-	totalSeconds := 120
-	steps := 12 // обновлять каждые 10 секунд
-
-	// Сразу показываем прогресс-бар 0%
-	bar := h.createProgressBar(0, 100)
-	progressText := fmt.Sprintf("Идет строительство объекта \"Простая хижина\". Время строительства 120 секунд.\n\n%s 0%%", bar)
-	editMsg := tgbotapi.NewEditMessageText(chatID, messageID, progressText)
-	h.requestAPI(editMsg)
-
-	for i := 1; i <= steps; i++ {
-		time.Sleep(time.Duration(totalSeconds/steps) * time.Second)
-		progress := i * 100 / steps
-		bar := h.createProgressBar(progress, 100)
-		progressText := fmt.Sprintf("Идет строительство объекта \"Простая хижина\". Время строительства 120 секунд.\n\n%s %d%%", bar, progress)
-		editMsg := tgbotapi.NewEditMessageText(chatID, messageID, progressText)
-		h.requestAPI(editMsg)
-	}
+func questOfferText(quest catalog.Quest) string {
+	return fmt.Sprintf(`%s
+Задание: %s.
+%s
+Награда: %s`, quest.Title, quest.Task, quest.Hint, questRewardText(quest))
+}
 
-	player, err := h.db.GetPlayer(userID)
-	if err != nil {
-		return
-	}
-	// Снимаем ресурсы
-	h.db.ConsumeItem(player.ID, "Береза", 20)
-	h.db.ConsumeItem(player.ID, "Березовый брус", 10)
-	h.db.ConsumeItem(player.ID, "Камень", 15)
-	h.db.ConsumeItem(player.ID, "Лесная ягода", 10)
-	// Отнимаем 5 сытости
-	h.db.UpdatePlayerSatiety(player.ID, -5)
-	h.db.UpdateSimpleHutBuilt(player.ID, true)
-	updatedPlayer, _ := h.db.GetPlayer(userID)
-	// Удаляем сообщение о прогрессе
-	deleteMsg := tgbotapi.NewDeleteMessage(chatID, messageID)
-	h.requestAPI(deleteMsg)
-	// Выводим финальное сообщение
-	msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("Объект \"Простая хижина\" успешно построен!\nСытость %d/100", updatedPlayer.Satiety))
-	h.sendMessage(msg)
+func questActiveText(quest catalog.Quest, progress *models.Quest) string {
+	return fmt.Sprintf(`Активный квест: %s
+Задание: %s (%d/%d)
+%s
+Награда: %s`, quest.Title, quest.Task, progress.Progress, quest.Objective.Count, quest.Hint, questRewardText(quest))
 }
 
-// Добавить вспомогательную функцию:
-func (h *BotHandlers) addPage8IfNotExists(playerID int) {
-	qty, err := h.db.GetItemQuantityInInventory(playerID, "📖 Страница 8 «След древних»")
-	if err != nil {
-		log.Printf("Error checking for page 8: %v", err)
-		return
-	}
-	if qty == 0 {
-		err = h.db.AddItemToInventory(playerID, "📖 Страница 8 «След древних»", 1)
-		if err != nil {
-			log.Printf("Error adding page 8 to inventory: %v", err)
-		}
-	}
+func questCompleteText(quest catalog.Quest) string {
+	return fmt.Sprintf(`%s ВЫПОЛНЕН!
+Получена награда:
+%s`, quest.Title, strings.ReplaceAll(questRewardText(quest), " + ", "\n"))
 }
 
 func (h *BotHandlers) handleOpenHut(message *tgbotapi.Message) {
@@ -5278,7 +2445,7 @@ func (h *BotHandlers) handleOpenHut(message *tgbotapi.Message) {
 	}
 
 	// Отправляем сообщение о входе в хижину
-	hutText := `🛖 Ты заходишь в свою простую хижину.
+	hutText := fmt.Sprintf(`🛖 Ты заходишь в свою простую хижину.
 
 Деревянные стены скрипят на ветру, но внутри — тепло и спокойно.  
 Костёр ещё тлеет в углу, а рядом лежит твоя нехитрая утварь.  
@@ -5286,7 +2453,7 @@ func (h *BotHandlers) handleOpenHut(message *tgbotapi.Message) {
 
 Здесь ты можешь:
 
-😴 Отдохнуть — восстановить 50 ед. сытости за 30 минут отдыха  /rest`
+😴 Отдохнуть — восстановить %d ед. сытости за 30 минут отдыха  /rest`, h.survival.Rest)
 
 	msg := tgbotapi.NewMessage(message.Chat.ID, hutText)
 	h.sendMessage(msg)
@@ -5296,7 +2463,7 @@ func (h *BotHandlers) handleRest(message *tgbotapi.Message) {
 	userID := message.From.ID
 
 	// Получаем игрока
-	player, err := h.db.GetPlayer(userID)
+	_, err := h.db.GetPlayer(userID)
 	if err != nil {
 		log.Printf("Error getting player: %v", err)
 		msg := tgbotapi.NewMessage(message.Chat.ID, "Произошла ошибка. Попробуйте позже.")
@@ -5305,7 +2472,7 @@ func (h *BotHandlers) handleRest(message *tgbotapi.Message) {
 	}
 
 	// Проверяем, не отдыхает ли уже игрок
-	if _, exists := h.restingTimers[userID]; exists {
+	if h.playerState(userID).Resting != nil {
 		msg := tgbotapi.NewMessage(message.Chat.ID, "Ты уже отдыхаешь. Дождись окончания отдыха.")
 		h.sendMessage(msg)
 		return
@@ -5317,45 +2484,72 @@ func (h *BotHandlers) handleRest(message *tgbotapi.Message) {
 	msg := tgbotapi.NewMessage(message.Chat.ID, progressText)
 	progressMsg, _ := h.sendMessageWithResponse(msg)
 
-	// Запускаем таймер отдыха
-	totalSeconds := 1800 // 30 минут
-	steps := 30          // обновлять каждую минуту
-	messageID := progressMsg.MessageID
+	// Запоминаем отдых с абсолютным временем окончания
+	now := h.clock.Now()
+	action := &models.TimedAction{
+		PlayerID: userID, Kind: "resting", ChatID: message.Chat.ID, MessageID: progressMsg.MessageID,
+		StartedAt: now, EndsAt: now.Add(restDuration),
+	}
+	h.playerState(userID).Resting = action
 
-	// Сохраняем ID сообщения в таймере
-	h.restingTimers[userID] = time.NewTimer(time.Duration(totalSeconds) * time.Second)
+	go h.runRest(userID, message.Chat.ID, progressMsg.MessageID, action.StartedAt, action.EndsAt)
+}
 
-	go func() {
-		for i := 1; i <= steps; i++ {
-			time.Sleep(time.Duration(totalSeconds/steps) * time.Second)
-			progress := i * 100 / steps
-			bar := h.createProgressBar(progress, 100)
-			progressText := fmt.Sprintf("Отдых начался. Время отдыха 30 минут.\n\n%s %d%%", bar, progress)
-			editMsg := tgbotapi.NewEditMessageText(message.Chat.ID, messageID, progressText)
-			h.requestAPI(editMsg)
+// restDuration - длительность отдыха в хижине
+const restDuration = 30 * time.Minute
+
+// runRest раз в минуту обновляет прогресс отдыха и по его окончании восстанавливает сытость.
+// Используется и при возобновлении отдыха после перезапуска бота.
+func (h *BotHandlers) runRest(userID int64, chatID int64, messageID int, startedAt, endsAt time.Time) {
+	total := endsAt.Sub(startedAt)
+	for h.clock.Now().Before(endsAt) {
+		wait := endsAt.Sub(h.clock.Now())
+		if wait > time.Minute {
+			wait = time.Minute
+		}
+		h.clock.Sleep(wait)
+
+		progress := 100
+		if total > 0 && h.clock.Now().Before(endsAt) {
+			progress = int(h.clock.Now().Sub(startedAt) * 100 / total)
 		}
+		bar := h.createProgressBar(progress, 100)
+		progressText := fmt.Sprintf("Отдых начался. Время отдыха 30 минут.\n\n%s %d%%", bar, progress)
+		editMsg := tgbotapi.NewEditMessageText(chatID, messageID, progressText)
+		h.requestAPI(editMsg)
+	}
 
-		// По истечении времени
-		<-h.restingTimers[userID].C
-		delete(h.restingTimers, userID)
+	// По истечении времени
+	unlock := h.lockPlayer(userID)
+	defer unlock()
+	h.playerState(userID).Resting = nil
 
-		// Удаляем сообщение с прогресс-баром
-		deleteMsg := tgbotapi.NewDeleteMessage(message.Chat.ID, messageID)
-		h.requestAPI(deleteMsg)
+	// Удаляем сообщение с прогресс-баром
+	deleteMsg := tgbotapi.NewDeleteMessage(chatID, messageID)
+	h.requestAPI(deleteMsg)
 
-		// Восстанавливаем сытость
-		err = h.db.UpdatePlayerSatiety(player.ID, 50)
-		if err != nil {
-			log.Printf("Error updating player satiety: %v", err)
-			return
-		}
+	player, err := h.db.GetPlayer(userID)
+	if err != nil {
+		log.Printf("Error getting player: %v", err)
+		return
+	}
+
+	// Восстанавливаем сытость
+	err = h.changeSatiety(playerRef(userID, player.ID, chatID), h.survival.Rest)
+	if err != nil {
+		log.Printf("Error updating player satiety: %v", err)
+		return
+	}
 
-		// Получаем обновленные данные игрока
-		updatedPlayer, _ := h.db.GetPlayer(userID)
+	// Получаем обновленные данные игрока
+	updatedPlayer, err := h.db.GetPlayer(userID)
+	if err != nil {
+		log.Printf("Error getting updated player: %v", err)
+		updatedPlayer = player
+	}
 
-		// Отправляем сообщение о завершении отдыха
-		resultText := fmt.Sprintf("Отдых завершен. Восстановлено 50 ед. сытости.\nСытость %d/100", updatedPlayer.Satiety)
-		resultMsg := tgbotapi.NewMessage(message.Chat.ID, resultText)
-		h.sendMessage(resultMsg)
-	}()
+	// Отправляем сообщение о завершении отдыха
+	resultText := fmt.Sprintf("Отдых завершен. Восстановлено %d ед. сытости.\nСытость %d/100", h.survival.Rest, updatedPlayer.Satiety)
+	resultMsg := tgbotapi.NewMessage(chatID, resultText)
+	h.sendMessage(resultMsg)
 }
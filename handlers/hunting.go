@@ -0,0 +1,85 @@
+package handlers
+
+import "reborn_land/models"
+
+// shotResult - чем закончился выстрел по зверю
+type shotResult struct {
+	Hit    bool
+	Killed bool
+	Wounds int  // Попаданий по зверю с учетом этого выстрела
+	Fled   bool // Уцелевший зверь убежал с поля
+	Moved  bool // Уцелевший зверь перебежал в другую клетку
+}
+
+// huntHitChance - шанс попадания в процентах: растет с уровнем охоты и уровнем лука
+func huntHitChance(resource locationResource, level int, tier int) int {
+	return min(95, max(5, resource.Hit+3*(level-1)+5*(tier-1)))
+}
+
+// resolveShot бросает попадание по зверю в клетке и решает, что зверь сделает,
+// если уцелел. Ресурсы без шанса попадания добываются всегда с первого раза.
+func (h *BotHandlers) resolveShot(userID int64, resource locationResource, level int, tool *models.Item, session *models.LocationSession, row, col int) shotResult {
+	if resource.Hit == 0 {
+		return shotResult{Hit: true, Killed: true, Wounds: 1}
+	}
+
+	wounds := 0
+	if session != nil && row < len(session.Wounds) && col < len(session.Wounds[row]) {
+		wounds = session.Wounds[row][col]
+	}
+
+	shot := shotResult{Wounds: wounds}
+	if h.fields.Intn(userID, 100) < huntHitChance(resource, level, tool.Tier) {
+		shot.Hit = true
+		shot.Wounds++
+		if shot.Wounds >= max(1, resource.Health) {
+			shot.Killed = true
+			return shot
+		}
+	}
+
+	// Уцелевший зверь пугается: убегает с поля, перебегает или остается на месте
+	roll := h.fields.Intn(userID, 100)
+	switch {
+	case roll < resource.Flee:
+		shot.Fled = true
+	case roll < resource.Flee+resource.Move:
+		shot.Moved = true
+	}
+	return shot
+}
+
+// setWounds запоминает попадания по зверю в клетке
+func setWounds(session *models.LocationSession, row, col, wounds int) {
+	if len(session.Wounds) != len(session.Resources) {
+		session.Wounds = make([][]int, len(session.Resources))
+	}
+	if len(session.Wounds[row]) != len(session.Resources[row]) {
+		session.Wounds[row] = make([]int, len(session.Resources[row]))
+	}
+	session.Wounds[row][col] = wounds
+}
+
+// moveAnimal переносит зверя вместе с его ранами в случайную пустую клетку.
+// Если пустых клеток нет, зверь остается на месте.
+func (h *BotHandlers) moveAnimal(userID int64, session *models.LocationSession, row, col, wounds int) {
+	type cell struct{ row, col int }
+	var empty []cell
+	for i := range session.Resources {
+		for j := range session.Resources[i] {
+			if session.Resources[i][j] == "" {
+				empty = append(empty, cell{i, j})
+			}
+		}
+	}
+	if len(empty) == 0 {
+		setWounds(session, row, col, wounds)
+		return
+	}
+
+	to := empty[h.fields.Intn(userID, len(empty))]
+	session.Resources[to.row][to.col] = session.Resources[row][col]
+	session.Resources[row][col] = ""
+	setWounds(session, row, col, 0)
+	setWounds(session, to.row, to.col, wounds)
+}
@@ -0,0 +1,256 @@
+package handlers
+
+import (
+	"reborn_land/fieldgen"
+	"reborn_land/models"
+	"testing"
+)
+
+const testUser = int64(1)
+
+// huntingResource возвращает описание зверя с охоты по ключу
+func huntingResource(t *testing.T, key string) locationResource {
+	t.Helper()
+	def, _ := locationByKey("hunting")
+	resource, ok := def.resourceByKey(key)
+	if !ok {
+		t.Fatalf("hunting has no %s", key)
+	}
+	return resource
+}
+
+func TestHuntHitChance(t *testing.T) {
+	tests := []struct {
+		name  string
+		hit   int
+		level int
+		tier  int
+		want  int
+	}{
+		{"base", 60, 1, 1, 60},
+		{"level adds 3 per level", 60, 4, 1, 69},
+		{"tier adds 5 per tier", 60, 1, 3, 70},
+		{"level and tier add up", 50, 5, 4, 77},
+		{"clamped at 95", 90, 10, 4, 95},
+		{"clamped at 5", 1, 1, 1, 5},
+		{"low level and tier do not go below base", 60, 0, 0, 52},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := huntHitChance(locationResource{Hit: tt.hit}, tt.level, tt.tier); got != tt.want {
+				t.Errorf("huntHitChance(%d, %d, %d) = %d, want %d", tt.hit, tt.level, tt.tier, got, tt.want)
+			}
+		})
+	}
+}
+
+// Результат выстрела сверяется с бросками из генератора с тем же зерном:
+// первый бросок - попадание, второй (если зверь уцелел) - побег или перебежка
+func TestResolveShot(t *testing.T) {
+	tests := []struct {
+		name   string
+		res    locationResource
+		wounds int
+		level  int
+		tier   int
+	}{
+		{"rabbit", huntingResource(t, "rabbit"), 0, 1, 1},
+		{"bird with a good bow", huntingResource(t, "bird"), 0, 3, 3},
+		{"fresh deer", huntingResource(t, "deer"), 0, 3, 1},
+		{"wounded deer", huntingResource(t, "deer"), 2, 3, 1},
+		{"wounded boar", huntingResource(t, "boar"), 3, 5, 4},
+		{"always flees", locationResource{Hit: 5, Health: 2, Flee: 100}, 0, 1, 1},
+		{"always moves", locationResource{Hit: 5, Health: 2, Move: 100}, 0, 1, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hits, kills, flees, moves := 0, 0, 0, 0
+			for seed := int64(1); seed <= 200; seed++ {
+				h := &BotHandlers{fields: fieldgen.New(seed)}
+				rolls := fieldgen.New(seed)
+				session := &models.LocationSession{Resources: [][]string{{"🦌"}}, Wounds: [][]int{{tt.wounds}}}
+
+				shot := h.resolveShot(testUser, tt.res, tt.level, &models.Item{Tier: tt.tier}, session, 0, 0)
+
+				hit := rolls.Intn(testUser, 100) < huntHitChance(tt.res, tt.level, tt.tier)
+				want := shotResult{Hit: hit, Wounds: tt.wounds}
+				if hit {
+					want.Wounds++
+				}
+				want.Killed = hit && want.Wounds >= max(1, tt.res.Health)
+				if !want.Killed {
+					roll := rolls.Intn(testUser, 100)
+					want.Fled = roll < tt.res.Flee
+					want.Moved = !want.Fled && roll < tt.res.Flee+tt.res.Move
+				}
+				if shot != want {
+					t.Fatalf("seed %d: shot = %+v, want %+v", seed, shot, want)
+				}
+				// Следующий бросок совпадает: выстрел потратил ровно столько бросков, сколько нужно
+				if h.fields.Intn(testUser, 1000) != rolls.Intn(testUser, 1000) {
+					t.Fatalf("seed %d: shot used a different number of rolls", seed)
+				}
+
+				if shot.Hit {
+					hits++
+				}
+				if shot.Killed {
+					kills++
+				}
+				if shot.Fled {
+					flees++
+				}
+				if shot.Moved {
+					moves++
+				}
+			}
+			if hits == 0 || hits == 200 {
+				t.Errorf("hits = %d of 200, want both hits and misses", hits)
+			}
+			if tt.res.Flee == 100 && flees != 200-kills {
+				t.Errorf("flees = %d, want every survivor (%d)", flees, 200-kills)
+			}
+			if tt.res.Move == 100 && moves != 200-kills {
+				t.Errorf("moves = %d, want every survivor (%d)", moves, 200-kills)
+			}
+		})
+	}
+}
+
+// Ресурсы без шанса попадания добываются с первого раза и не тратят бросков
+func TestResolveShotWithoutHitChance(t *testing.T) {
+	h := &BotHandlers{fields: fieldgen.New(1)}
+	shot := h.resolveShot(testUser, locationResource{Name: "Камень"}, 1, &models.Item{Tier: 1}, nil, 0, 0)
+	if shot != (shotResult{Hit: true, Killed: true, Wounds: 1}) {
+		t.Errorf("shot = %+v", shot)
+	}
+	if h.fields.Intn(testUser, 1000) != fieldgen.New(1).Intn(testUser, 1000) {
+		t.Errorf("shot without hit chance used a roll")
+	}
+}
+
+// Раны копятся от выстрела к выстрелу, и зверь погибает на попадании, равном его здоровью
+func TestResolveShotAccumulatesWounds(t *testing.T) {
+	deer := huntingResource(t, "deer")
+	for seed := int64(1); seed <= 20; seed++ {
+		h := &BotHandlers{fields: fieldgen.New(seed)}
+		session := &models.LocationSession{Resources: [][]string{{deer.Emoji}}}
+		hits := 0
+		for shots := 0; ; shots++ {
+			if shots == 500 {
+				t.Fatalf("seed %d: deer is still alive after %d shots", seed, shots)
+			}
+			shot := h.resolveShot(testUser, deer, 10, &models.Item{Tier: 4}, session, 0, 0)
+			if shot.Hit {
+				hits++
+			}
+			if shot.Wounds != hits {
+				t.Fatalf("seed %d: wounds = %d after %d hits", seed, shot.Wounds, hits)
+			}
+			if shot.Killed {
+				if hits != deer.Health {
+					t.Fatalf("seed %d: killed after %d hits, health %d", seed, hits, deer.Health)
+				}
+				break
+			}
+			setWounds(session, 0, 0, shot.Wounds)
+		}
+	}
+}
+
+func TestMoveAnimal(t *testing.T) {
+	tests := []struct {
+		name  string
+		field [][]string
+		moves bool
+	}{
+		{"to an empty cell", [][]string{{"🦌", "", "🐰"}, {"", "🐗", ""}}, true},
+		{"stays on a full field", [][]string{{"🦌", "🐰"}, {"🐦", "🐗"}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for seed := int64(1); seed <= 20; seed++ {
+				h := &BotHandlers{fields: fieldgen.New(seed)}
+				session := &models.LocationSession{Resources: copyField(tt.field)}
+
+				h.moveAnimal(testUser, session, 0, 0, 2)
+
+				deer := 0
+				for i := range session.Resources {
+					for j := range session.Resources[i] {
+						wounds := 0
+						if i < len(session.Wounds) && j < len(session.Wounds[i]) {
+							wounds = session.Wounds[i][j]
+						}
+						switch {
+						case session.Resources[i][j] == "🦌":
+							deer++
+							if wounds != 2 {
+								t.Errorf("seed %d: deer at %d,%d has %d wounds, want 2", seed, i, j, wounds)
+							}
+							if moved := i != 0 || j != 0; moved != tt.moves {
+								t.Errorf("seed %d: deer moved = %v, want %v", seed, moved, tt.moves)
+							}
+							if tt.moves && tt.field[i][j] != "" {
+								t.Errorf("seed %d: deer moved onto %q", seed, tt.field[i][j])
+							}
+						case wounds != 0:
+							t.Errorf("seed %d: %q at %d,%d has %d wounds", seed, session.Resources[i][j], i, j, wounds)
+						case session.Resources[i][j] != tt.field[i][j] && !(i == 0 && j == 0):
+							t.Errorf("seed %d: cell %d,%d changed to %q", seed, i, j, session.Resources[i][j])
+						}
+					}
+				}
+				if deer != 1 {
+					t.Errorf("seed %d: %d deer on the field, want 1", seed, deer)
+				}
+			}
+		})
+	}
+}
+
+func copyField(field [][]string) [][]string {
+	copied := make([][]string, len(field))
+	for i := range field {
+		copied[i] = append([]string(nil), field[i]...)
+	}
+	return copied
+}
+
+// Стрела подбирается с шансом Recover, иначе тратится
+func TestSpendConsumablesRecoversArrows(t *testing.T) {
+	def, _ := locationByKey("hunting")
+	arrows := def.Consumables[0]
+	recovered := 0
+	for seed := int64(1); seed <= 1000; seed++ {
+		h := &BotHandlers{fields: fieldgen.New(seed)}
+		rolls := fieldgen.New(seed)
+
+		consumed, back := h.spendConsumables(testUser, def)
+		if rolls.Intn(testUser, 100) < arrows.Recover {
+			recovered++
+			if len(consumed) != 0 || len(back) != 1 || back[0].Item != "Стрелы" {
+				t.Fatalf("seed %d: recovered arrow, got consumed %+v, recovered %+v", seed, consumed, back)
+			}
+			continue
+		}
+		if len(back) != 0 || len(consumed) != 1 || consumed[0].ItemName != "Стрелы" || consumed[0].Quantity != arrows.Quantity {
+			t.Fatalf("seed %d: spent arrow, got consumed %+v, recovered %+v", seed, consumed, back)
+		}
+	}
+	// Около 35% стрел возвращается
+	if recovered < 250 || recovered > 450 {
+		t.Errorf("recovered %d of 1000 arrows with %d%% chance", recovered, arrows.Recover)
+	}
+
+	// Расходники без шанса возврата тратятся всегда и не тратят бросков
+	bait := &locationDef{Consumables: []locationConsumable{{Item: "Лесная ягода", Quantity: 2}}}
+	h := &BotHandlers{fields: fieldgen.New(1)}
+	consumed, back := h.spendConsumables(testUser, bait)
+	if len(back) != 0 || len(consumed) != 1 || consumed[0].ItemName != "Лесная ягода" || consumed[0].Quantity != 2 {
+		t.Errorf("consumables without recovery = %+v, %+v", consumed, back)
+	}
+	if h.fields.Intn(testUser, 1000) != fieldgen.New(1).Intn(testUser, 1000) {
+		t.Errorf("consumables without recovery used a roll")
+	}
+}
@@ -0,0 +1,939 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"reborn_land/events"
+	"reborn_land/fieldgen"
+	"reborn_land/models"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// locationResource - ресурс на поле локации
+type locationResource struct {
+	Emoji    string
+	Key      string // Ключ в callback данных: <префикс>_<ключ>_<строка>_<столбец>
+	Name     string // Предмет, который получает игрок, или имя зверя
+	Duration int    // Время добычи в секундах
+	Exp      int    // Опыт локации за добычу
+	MinLevel int    // С какого уровня локации встречается ресурс
+	Tier     int    // Какой уровень инструмента нужен, 0 - любой
+	Weight   int    // Вес ресурса на уровне MinLevel
+	Growth   int    // Прибавка веса за каждый уровень локации сверх MinLevel
+
+	// Дичь на охоте
+	Hit    int // Базовый шанс попадания в процентах, 0 - добывается с первого раза
+	Health int // Сколько попаданий нужно, чтобы добыть зверя
+	Flee   int // Шанс в процентах, что уцелевший зверь убежит с поля
+	Move   int // Шанс в процентах, что уцелевший зверь перебежит в другую клетку
+}
+
+// weight - вес ресурса в локации уровня level, 0 - ресурс еще не открыт
+func (r locationResource) weight(level int) int {
+	if level < r.MinLevel {
+		return 0
+	}
+	return r.Weight + r.Growth*max(0, level-r.MinLevel)
+}
+
+// locationConsumable - предмет, который тратится на каждое действие в локации
+type locationConsumable struct {
+	Item     string
+	Quantity int
+	LeftText string // Строка результата с остатком, например "🏹 Стрел осталось: %d"
+
+	Recover     int    // Шанс в процентах, что предмет не потратится
+	RecoverText string // Строка результата, когда предмет вернулся
+}
+
+// Ограничения Telegram на инлайн клавиатуру поля
+const (
+	maxFieldCols         = 8   // Кнопок в одном ряду
+	maxFieldButtons      = 100 // Кнопок во всей клавиатуре
+	maxCallbackDataBytes = 64  // Байт в callback данных кнопки
+)
+
+// fieldSize - размер поля локации начиная с уровня MinLevel
+type fieldSize struct {
+	MinLevel  int
+	Rows      int
+	Cols      int
+	Resources int // Сколько клеток занято ресурсами на новом поле
+}
+
+// defaultFieldSizes - поле растет с уровнем локации, ресурсов на нем становится больше
+var defaultFieldSizes = []fieldSize{
+	{MinLevel: 1, Rows: 3, Cols: 3, Resources: 3},
+	{MinLevel: 3, Rows: 3, Cols: 4, Resources: 4},
+	{MinLevel: 5, Rows: 4, Cols: 4, Resources: 5},
+	{MinLevel: 8, Rows: 5, Cols: 5, Resources: 7},
+}
+
+// locationDef - описание локации для движка локаций. Вход, поле, добыча,
+// истощение и кулдаун у всех локаций общие, различаются только описания.
+type locationDef struct {
+	Key      string // Ключ в сессиях, кулдаунах, событиях и player_locations
+	Action   string // Вид действия с таймером: "mining", "fishing"...
+	Callback string // Префикс callback данных поля
+	Button   string // Кнопка меню, которая открывает локацию
+	Title    string // Заголовок информационного сообщения
+	Genitive string // Название в родительном падеже: "уровень шахты"
+	InForest bool   // Локация в меню леса, а не в меню добычи
+
+	Resources []locationResource
+	Sizes     []fieldSize // Размер поля по уровню локации, по возрастанию MinLevel
+
+	Tool        string // Вид инструмента из catalog.ToolKinds
+	DropBroken  bool   // Сломанный инструмент пропадает из инвентаря
+	Consumables []locationConsumable
+	Prey        bool          // Добыча - дичь: публикуется AnimalHunted вместо ResourceGathered
+	Cooldown    time.Duration // Сколько локация восстанавливается после истощения
+
+	NoToolText    string // Нет инструмента нужного вида
+	TierText      string // Нужен инструмент уровня %d
+	ToolLabel     string // Инструмент в родительном падеже: "Прочность кирки"
+	BrokenText    string // Инструмент %s сломался
+	CooldownText  string // До восстановления осталось %d сек.
+	ErrorText     string
+	Prompt        string // Текст над полем
+	EmptyCell     string // Как выглядит пустая клетка
+	EmptyText     string // Ответ на нажатие пустой клетки
+	ListTitle     string // Заголовок списка ресурсов
+	ProgressText  string // Ресурс %s, время %d сек.
+	ResultText    string // Добыт ресурс %s
+	BusyText      string // Ответ на "Назад" во время добычи
+	ExhaustedText string
+
+	// Выстрелы, после которых зверь уцелел
+	HitText  string // Зверь %s ранен, попаданий %d из %d
+	MissText string
+	FleeText string // Зверь %s убежал
+	MoveText string // Зверь %s перебежал
+}
+
+// locations - все локации с полем ресурсов
+var locations = []*locationDef{
+	{
+		// Камень и уголь есть всегда, медь, железо и самоцветы открываются
+		// с уровнем шахты и с каждым уровнем встречаются чаще
+		Key: "mine", Action: "mining", Callback: "mine", Button: "⛏ Шахта", Title: "⛏ Шахта", Genitive: "шахты",
+		Resources: []locationResource{
+			{Emoji: "🪨", Key: "stone", Name: "Камень", Duration: 10, Exp: 2, MinLevel: 1, Tier: 1, Weight: 6},
+			{Emoji: "⚫", Key: "coal", Name: "Уголь", Duration: 20, Exp: 2, MinLevel: 1, Tier: 1, Weight: 4},
+			{Emoji: "🟠", Key: "copper", Name: "Медная руда", Duration: 25, Exp: 4, MinLevel: 2, Tier: 1, Weight: 2, Growth: 1},
+			{Emoji: "🟤", Key: "iron", Name: "Железная руда", Duration: 30, Exp: 5, MinLevel: 3, Tier: 2, Weight: 1, Growth: 1},
+			{Emoji: "💎", Key: "gem", Name: "Самоцвет", Duration: 60, Exp: 10, MinLevel: 5, Tier: 2, Weight: 1},
+		},
+		Sizes: defaultFieldSizes, Tool: "pickaxe", Cooldown: time.Minute,
+		NoToolText:    "В инвентаре нет кирки.",
+		TierText:      "нужна кирка %d уровня",
+		ToolLabel:     "кирки",
+		BrokenText:    "💔 %s сломалась!",
+		CooldownText:  "До обновления шахты осталось %d сек.",
+		ErrorText:     "Произошла ошибка при работе с шахтой.",
+		Prompt:        "Выберите ресурс для добычи:",
+		EmptyCell:     " ",
+		EmptyText:     "Здесь нет ресурсов!",
+		ListTitle:     "Доступные ресурсы:",
+		ProgressText:  `Началась добыча ресурса "%s". Время добычи %d сек.`,
+		ResultText:    "✅ Ты добыл %s!",
+		BusyText:      "Идет добыча ресурса.",
+		ExhaustedText: "⚠️ Шахта истощена! Необходимо подождать 1 минуту до восстановления ресурсов.",
+	},
+	{
+		Key: "forest", Action: "chopping", Callback: "forest", Button: "🪓 Рубка", Title: "🪓 Рубка", Genitive: "леса", InForest: true,
+		Resources: []locationResource{
+			{Emoji: "🌳", Key: "birch", Name: "Береза", Duration: 10, Exp: 2, Weight: 1},
+		},
+		Sizes: defaultFieldSizes, Tool: "axe", Cooldown: time.Minute,
+		NoToolText:    "В инвентаре нет топора.",
+		TierText:      "нужен топор %d уровня",
+		ToolLabel:     "топора",
+		BrokenText:    "💔 %s сломался!",
+		CooldownText:  "До обновления леса осталось %d сек.",
+		ErrorText:     "Произошла ошибка при работе с лесом.",
+		Prompt:        "Выберите дерево для рубки:",
+		EmptyCell:     " ",
+		EmptyText:     "Здесь нет деревьев!",
+		ListTitle:     "Доступные ресурсы:",
+		ProgressText:  `Идет рубка дерева "%s". Время рубки %d сек.`,
+		ResultText:    `✅ Ты срубил дерево "%s"!`,
+		BusyText:      "Идет рубка дерева.",
+		ExhaustedText: "⚠️ Лес истощен! Необходимо подождать 1 минуту до восстановления деревьев.",
+	},
+	{
+		Key: "gathering", Action: "gathering", Callback: "gathering", Button: "🌿 Сбор", Title: "🌿 Сбор", Genitive: "сбора", InForest: true,
+		Resources: []locationResource{
+			{Emoji: "🍇", Key: "berry", Name: "Лесная ягода", Duration: 10, Exp: 2, Weight: 1},
+		},
+		Sizes: defaultFieldSizes, Tool: "knife", Cooldown: time.Minute,
+		NoToolText:    "В инвентаре нет ножа.",
+		TierText:      "нужен нож %d уровня",
+		ToolLabel:     "ножа",
+		BrokenText:    "💔 %s сломался!",
+		CooldownText:  "До обновления ягодных кустов осталось %d сек.",
+		ErrorText:     "Произошла ошибка при работе со сбором.",
+		Prompt:        "Выберите ресурс для сбора:",
+		EmptyCell:     " ",
+		EmptyText:     "Здесь нет ягод!",
+		ListTitle:     "Доступные ресурсы:",
+		ProgressText:  `Идет сбор "%s". Время сбора %d сек.`,
+		ResultText:    `✅ Ты собрал "%s"!`,
+		BusyText:      "Идет сбор ягод.",
+		ExhaustedText: "⚠️ Ягодные кусты истощены! Необходимо подождать 1 минуту до восстановления.",
+	},
+	{
+		// На охоте тратятся стрелы, а сломанный лук выбрасывается
+		Key: "hunting", Action: "hunting", Callback: "hunt", Button: "🎯 Охота", Title: "🎯 Охота", Genitive: "охоты", InForest: true,
+		Resources: []locationResource{
+			{Emoji: "🐰", Key: "rabbit", Name: "Кролик", Duration: 20, Exp: 2, Weight: 1,
+				Hit: 70, Health: 1, Flee: 30, Move: 40},
+			{Emoji: "🐦", Key: "bird", Name: "Куропатка", Duration: 20, Exp: 2, Weight: 1,
+				Hit: 55, Health: 1, Flee: 50, Move: 30},
+			{Emoji: "🦌", Key: "deer", Name: "Олень", Duration: 15, Exp: 6, MinLevel: 3, Weight: 1,
+				Hit: 60, Health: 3, Flee: 25, Move: 45},
+			{Emoji: "🐗", Key: "boar", Name: "Кабан", Duration: 15, Exp: 8, MinLevel: 5, Weight: 1,
+				Hit: 50, Health: 4, Flee: 15, Move: 30},
+		},
+		Sizes: defaultFieldSizes, Tool: "bow", DropBroken: true, Prey: true, Cooldown: time.Minute,
+		Consumables: []locationConsumable{
+			{Item: "Стрелы", Quantity: 1, LeftText: "🏹 Стрел осталось: %d", Recover: 35, RecoverText: "🏹 Стрелу удалось подобрать."},
+		},
+		NoToolText:    "В инвентаре нет лука.",
+		TierText:      "нужен лук %d уровня",
+		ToolLabel:     "лука",
+		BrokenText:    "💔 %s сломался!",
+		CooldownText:  "До обновления охотничьих угодий осталось %d сек.",
+		ErrorText:     "Произошла ошибка при работе с охотой.",
+		Prompt:        "Выберите цель для охоты:",
+		EmptyCell:     " ",
+		EmptyText:     "Здесь нет добычи!",
+		ListTitle:     "Доступные ресурсы:",
+		ProgressText:  `Идет охота на "%s". Время охоты %d сек.`,
+		ResultText:    "✅ Охота завершена!\nДобыто: %s",
+		BusyText:      "Идет охота.",
+		ExhaustedText: "⚠️ Охотничьи угодья истощены! Необходимо подождать 1 минуту до восстановления ресурсов.",
+		HitText:       "🎯 Попадание! %s ранен (%d/%d).",
+		MissText:      "💨 Промах!",
+		FleeText:      "🐾 %s убегает с поля.",
+		MoveText:      "🐾 %s перебегает на другое место.",
+	},
+	{
+		// Чем крупнее рыба, тем дольше ее ловить, но и опыта за нее больше
+		Key: "lake", Action: "fishing", Callback: "lake", Button: "🎣 Озеро", Title: "🎣 Озеро", Genitive: "озера",
+		Resources: []locationResource{
+			{Emoji: "🐟", Key: "crucian", Name: "Карась", Duration: 15, Exp: 2, Weight: 1},
+			{Emoji: "🐠", Key: "perch", Name: "Окунь", Duration: 25, Exp: 3, Weight: 1},
+			{Emoji: "🐡", Key: "pike", Name: "Щука", Duration: 40, Exp: 5, Weight: 1},
+		},
+		Sizes: defaultFieldSizes, Tool: "fishing_rod", Cooldown: time.Minute,
+		NoToolText:    "В инвентаре нет удочки.",
+		TierText:      "нужна удочка %d уровня",
+		ToolLabel:     "удочки",
+		BrokenText:    "💔 %s сломалась!",
+		CooldownText:  "До возвращения рыбы в озеро осталось %d сек.",
+		ErrorText:     "Произошла ошибка при работе с озером.",
+		Prompt:        "Выберите место для рыбалки:",
+		EmptyCell:     "🌊",
+		EmptyText:     "Здесь не клюет!",
+		ListTitle:     "Рыба в озере:",
+		ProgressText:  `Ловим рыбу "%s". Время рыбалки %d сек.`,
+		ResultText:    `✅ Ты поймал рыбу "%s"!`,
+		BusyText:      "Идет рыбалка.",
+		ExhaustedText: "⚠️ Рыба в озере перестала клевать! Необходимо подождать 1 минуту.",
+	},
+	{
+		// Травы и злаки встречаются часто, золотой корень - редко
+		Key: "field", Action: "harvesting", Callback: "field", Button: "🌾 Поле", Title: "🌾 Поле", Genitive: "поля",
+		Resources: []locationResource{
+			{Emoji: "🌿", Key: "grass", Name: "Луговая трава", Duration: 10, Exp: 2, Weight: 4},
+			{Emoji: "🌾", Key: "wheat", Name: "Дикая пшеница", Duration: 15, Exp: 2, Weight: 4},
+			{Emoji: "🌼", Key: "root", Name: "Золотой корень", Duration: 30, Exp: 10, Weight: 1},
+		},
+		Sizes: []fieldSize{
+			{MinLevel: 1, Rows: 3, Cols: 3, Resources: 4},
+			{MinLevel: 3, Rows: 3, Cols: 4, Resources: 5},
+			{MinLevel: 5, Rows: 4, Cols: 4, Resources: 6},
+		},
+		Tool: "knife", Cooldown: time.Minute,
+		NoToolText:    "В инвентаре нет ножа.",
+		TierText:      "нужен нож %d уровня",
+		ToolLabel:     "ножа",
+		BrokenText:    "💔 %s сломался!",
+		CooldownText:  "До того как поле снова зарастет, осталось %d сек.",
+		ErrorText:     "Произошла ошибка при работе с полем.",
+		Prompt:        "Выберите растение для сбора:",
+		EmptyCell:     " ",
+		EmptyText:     "Здесь ничего не растет!",
+		ListTitle:     "Доступные ресурсы:",
+		ProgressText:  `Идет сбор "%s". Время сбора %d сек.`,
+		ResultText:    `✅ Ты собрал "%s"!`,
+		BusyText:      "Идет сбор растений.",
+		ExhaustedText: "⚠️ Поле истощено! Необходимо подождать 1 минуту, пока растения отрастут.",
+	},
+}
+
+// Координаты едут в callback данных, и кнопка самого большого поля каждой
+// локации должна в них поместиться
+func init() {
+	for _, def := range locations {
+		for _, s := range def.Sizes {
+			size := def.fieldSize(s.MinLevel)
+			for _, r := range def.Resources {
+				data := fmt.Sprintf("%s_%s_%d_%d", def.Callback, r.Key, size.Rows-1, size.Cols-1)
+				if len(data) > maxCallbackDataBytes {
+					panic(fmt.Sprintf("handlers: callback data %q of %s is longer than %d bytes", data, def.Key, maxCallbackDataBytes))
+				}
+			}
+		}
+	}
+}
+
+// locationByKey, locationByAction, locationByButton и locationByCallback ищут
+// локацию по ключу, виду действия, кнопке меню и callback данным поля
+func locationByKey(key string) (*locationDef, bool) {
+	for _, def := range locations {
+		if def.Key == key {
+			return def, true
+		}
+	}
+	return nil, false
+}
+
+func locationByAction(action string) (*locationDef, bool) {
+	for _, def := range locations {
+		if def.Action == action {
+			return def, true
+		}
+	}
+	return nil, false
+}
+
+func locationByButton(button string) (*locationDef, bool) {
+	for _, def := range locations {
+		if def.Button == button {
+			return def, true
+		}
+	}
+	return nil, false
+}
+
+func locationByCallback(data string) (*locationDef, bool) {
+	for _, def := range locations {
+		if strings.HasPrefix(data, def.Callback+"_") {
+			return def, true
+		}
+	}
+	return nil, false
+}
+
+// resourceByEmoji, resourceByKey и resourceByName ищут ресурс локации по клетке поля,
+// ключу из callback и названию предмета
+func (d *locationDef) resourceByEmoji(emoji string) (locationResource, bool) {
+	for _, r := range d.Resources {
+		if r.Emoji == emoji {
+			return r, true
+		}
+	}
+	return locationResource{}, false
+}
+
+func (d *locationDef) resourceByKey(key string) (locationResource, bool) {
+	for _, r := range d.Resources {
+		if r.Key == key {
+			return r, true
+		}
+	}
+	return locationResource{}, false
+}
+
+func (d *locationDef) resourceByName(name string) (locationResource, bool) {
+	for _, r := range d.Resources {
+		if r.Name == name {
+			return r, true
+		}
+	}
+	return locationResource{}, false
+}
+
+// fieldSize - размер поля для локации уровня level в пределах ограничений Telegram
+func (d *locationDef) fieldSize(level int) fieldSize {
+	size := fieldSize{Rows: 3, Cols: 3, Resources: 3}
+	for _, s := range d.Sizes {
+		if level >= s.MinLevel {
+			size = s
+		}
+	}
+
+	size.Cols = min(max(size.Cols, 1), maxFieldCols)
+	size.Rows = min(max(size.Rows, 1), maxFieldButtons/size.Cols)
+	size.Resources = min(size.Resources, size.Rows*size.Cols)
+
+	return size
+}
+
+// fieldSpec - поле локации уровня level. Каждый открытый ресурс повторяется
+// по своему весу: fieldgen выбирает виды равновероятно.
+func (d *locationDef) fieldSpec(level int) fieldgen.Spec {
+	var kinds []string
+	for _, r := range d.Resources {
+		for i := 0; i < r.weight(level); i++ {
+			kinds = append(kinds, r.Emoji)
+		}
+	}
+	size := d.fieldSize(level)
+	return fieldgen.Spec{Rows: size.Rows, Cols: size.Cols, Resources: size.Resources, Kinds: kinds}
+}
+
+// keyboard - инлайн клавиатура поля локации, раненые звери помечены каплей
+func (d *locationDef) keyboard(field [][]string, wounds [][]int) tgbotapi.InlineKeyboardMarkup {
+	var keyboard [][]tgbotapi.InlineKeyboardButton
+	for i := range field {
+		var row []tgbotapi.InlineKeyboardButton
+		for j := range field[i] {
+			cell := field[i][j]
+			var callbackData string
+
+			if r, ok := d.resourceByEmoji(cell); ok {
+				callbackData = fmt.Sprintf("%s_%s_%d_%d", d.Callback, r.Key, i, j)
+				if i < len(wounds) && j < len(wounds[i]) && wounds[i][j] > 0 {
+					cell += "🩸"
+				}
+			} else {
+				callbackData = fmt.Sprintf("%s_empty_%d_%d", d.Callback, i, j)
+				cell = d.EmptyCell
+			}
+
+			row = append(row, tgbotapi.NewInlineKeyboardButtonData(cell, callbackData))
+		}
+		keyboard = append(keyboard, row)
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(keyboard...)
+}
+
+// infoText - уровень локации и ресурсы: открытые, требующие инструмент получше и закрытые
+func (d *locationDef) infoText(loc *models.Location) string {
+	infoText := fmt.Sprintf(`%s (Уровень %d)
+До следующего уровня: %d опыта
+
+%s`, d.Title, loc.Level, loc.ExpToNext(), d.ListTitle)
+	for _, r := range d.Resources {
+		switch {
+		case loc.Level < r.MinLevel:
+			infoText += fmt.Sprintf("\n🔒 %s - с %d уровня %s", r.Name, r.MinLevel, d.Genitive)
+		case r.Tier > 1:
+			infoText += fmt.Sprintf("\n%s %s - %s", r.Emoji, r.Name, fmt.Sprintf(d.TierText, r.Tier))
+		case r.Health > 1:
+			infoText += fmt.Sprintf("\n%s %s - нужно %d попадания", r.Emoji, r.Name, r.Health)
+		default:
+			infoText += fmt.Sprintf("\n%s %s", r.Emoji, r.Name)
+		}
+	}
+	return infoText
+}
+
+// sendLocationMenu отправляет сообщение с клавиатурой меню, из которого открывается локация
+func (h *BotHandlers) sendLocationMenu(def *locationDef, msg tgbotapi.MessageConfig) {
+	if def.InForest {
+		h.sendForestKeyboard(msg)
+	} else {
+		h.sendGatheringKeyboard(msg)
+	}
+}
+
+// handleLocation открывает новое поле локации, если она не восстанавливается после истощения
+func (h *BotHandlers) handleLocation(message *tgbotapi.Message, def *locationDef) {
+	userID := message.From.ID
+
+	// Проверяем, активен ли кулдаун локации
+	if cooldownEnd, ok := h.playerState(userID).Cooldowns[def.Key]; ok {
+		if h.clock.Now().Before(cooldownEnd) {
+			remainingTime := cooldownEnd.Sub(h.clock.Now())
+			msg := tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf(def.CooldownText, int(remainingTime.Seconds())))
+			h.sendMessage(msg)
+			return
+		}
+		// Кулдаун истек, удаляем его
+		delete(h.playerState(userID).Cooldowns, def.Key)
+	}
+
+	// Получаем игрока
+	player, err := h.db.GetPlayer(userID)
+	if err != nil {
+		log.Printf("Error getting player: %v", err)
+		msg := tgbotapi.NewMessage(message.Chat.ID, "Сначала зарегистрируйтесь с помощью команды /start")
+		h.sendMessage(msg)
+		return
+	}
+
+	// Проверяем сытость игрока
+	if !h.survival.CanWork(player.Satiety) {
+		msg := tgbotapi.NewMessage(message.Chat.ID, starvingText)
+		h.sendMessage(msg)
+		return
+	}
+
+	loc, err := h.db.GetOrCreateLocation(player.ID, def.Key)
+	if err != nil {
+		log.Printf("Error getting %s: %v", def.Key, err)
+		msg := tgbotapi.NewMessage(message.Chat.ID, def.ErrorText)
+		h.sendMessage(msg)
+		return
+	}
+
+	// Если локация была истощена в базе данных, восстанавливаем её
+	if loc.IsExhausted {
+		if err := h.db.SetLocationExhausted(player.ID, def.Key, false); err != nil {
+			log.Printf("Error setting %s exhausted: %v", def.Key, err)
+		}
+		loc.IsExhausted = false
+	}
+
+	h.createLocationSession(userID, message.Chat.ID, def, loc)
+}
+
+// createLocationSession генерирует поле, показывает его и запоминает сессию
+func (h *BotHandlers) createLocationSession(userID int64, chatID int64, def *locationDef, loc *models.Location) {
+	field := h.fields.Field(userID, def.fieldSpec(loc.Level))
+
+	// Сначала отправляем поле с инлайн кнопками
+	fieldMsg := tgbotapi.NewMessage(chatID, def.Prompt)
+	fieldMsg.ReplyMarkup = def.keyboard(field, nil)
+	fieldResponse, _ := h.sendChattableWithResponse(fieldMsg)
+
+	// Затем отправляем информационное сообщение с клавиатурой
+	backKeyboard := tgbotapi.NewReplyKeyboard(
+		tgbotapi.NewKeyboardButtonRow(
+			tgbotapi.NewKeyboardButton("◀️ Назад"),
+		),
+	)
+	backKeyboard.ResizeKeyboard = true
+
+	infoMsg := tgbotapi.NewMessage(chatID, def.infoText(loc))
+	infoMsg.ReplyMarkup = backKeyboard
+	infoResponse, _ := h.sendChattableWithResponse(infoMsg)
+
+	h.playerState(userID).Sessions[def.Key] = &models.LocationSession{
+		PlayerID:       userID,
+		Location:       def.Key,
+		ChatID:         chatID,
+		Resources:      field,
+		StartedAt:      h.clock.Now(),
+		FieldMessageID: fieldResponse.MessageID,
+		InfoMessageID:  infoResponse.MessageID,
+	}
+}
+
+// handleLocationCallback разбирает нажатие клетки поля: <префикс>_<ресурс>_<строка>_<столбец>
+func (h *BotHandlers) handleLocationCallback(userID int64, chatID int64, def *locationDef, data string, callbackID string) {
+	parts := strings.Split(data, "_")
+	if len(parts) != 4 {
+		return
+	}
+
+	resource, ok := def.resourceByKey(parts[1])
+	if !ok {
+		callbackConfig := tgbotapi.NewCallback(callbackID, def.EmptyText)
+		h.requestAPI(callbackConfig)
+		return
+	}
+
+	row, rowErr := strconv.Atoi(parts[2])
+	col, colErr := strconv.Atoi(parts[3])
+	if rowErr != nil || colErr != nil {
+		callbackConfig := tgbotapi.NewCallback(callbackID, def.EmptyText)
+		h.requestAPI(callbackConfig)
+		return
+	}
+
+	h.startLocationAction(userID, chatID, def, resource, callbackID, row, col)
+}
+
+// startLocationAction проверяет инструмент и расходники и запускает добычу ресурса в клетке
+func (h *BotHandlers) startLocationAction(userID int64, chatID int64, def *locationDef, resource locationResource, callbackID string, row, col int) {
+	// В локациях игрок делает одно дело за раз и не работает во время крафта
+	if len(h.playerState(userID).Actions) > 0 {
+		msg := tgbotapi.NewMessage(chatID, "Нельзя начинать новую добычу, пока не закончена текущая.")
+		h.sendMessage(msg)
+		callbackConfig := tgbotapi.NewCallback(callbackID, "")
+		h.requestAPI(callbackConfig)
+		return
+	}
+	if h.playerState(userID).Crafting != nil {
+		msg := tgbotapi.NewMessage(chatID, "Нельзя совершать действия пока идет создание предметов.")
+		h.sendMessage(msg)
+		callbackConfig := tgbotapi.NewCallback(callbackID, "")
+		h.requestAPI(callbackConfig)
+		return
+	}
+
+	// Клетка могла опустеть, пока сообщение с полем оставалось на экране
+	session := h.playerState(userID).Sessions[def.Key]
+	if session == nil || row < 0 || row >= len(session.Resources) || col < 0 || col >= len(session.Resources[row]) ||
+		session.Resources[row][col] != resource.Emoji {
+		callbackConfig := tgbotapi.NewCallback(callbackID, def.EmptyText)
+		h.requestAPI(callbackConfig)
+		return
+	}
+
+	// Получаем игрока
+	player, err := h.db.GetPlayer(userID)
+	if err != nil {
+		log.Printf("Error getting player: %v", err)
+		return
+	}
+
+	// Сытость могла кончиться, пока поле оставалось открытым
+	if !h.survival.CanWork(player.Satiety) {
+		msg := tgbotapi.NewMessage(chatID, starvingText)
+		h.sendMessage(msg)
+		callbackConfig := tgbotapi.NewCallback(callbackID, "")
+		h.requestAPI(callbackConfig)
+		return
+	}
+
+	// Берем лучший инструмент, который годится для этого ресурса
+	tool, toolItem, err := h.bestTool(player.ID, def.Tool, max(1, resource.Tier))
+	if err != nil {
+		log.Printf("Error checking %s: %v", def.Tool, err)
+		return
+	}
+
+	if tool == nil {
+		text := def.NoToolText
+		if resource.Tier > 1 {
+			text = fmt.Sprintf(`Для добычи "%s" %s.`, resource.Name, fmt.Sprintf(def.TierText, resource.Tier))
+		}
+		msg := tgbotapi.NewMessage(chatID, text)
+		h.sendMessage(msg)
+		callbackConfig := tgbotapi.NewCallback(callbackID, "")
+		h.requestAPI(callbackConfig)
+		return
+	}
+
+	// Проверяем расходники, например стрелы
+	for _, consumable := range def.Consumables {
+		quantity, err := h.db.GetItemQuantityInInventory(player.ID, consumable.Item)
+		if err != nil {
+			log.Printf("Error getting %s quantity: %v", consumable.Item, err)
+			quantity = 0
+		}
+		if quantity < consumable.Quantity {
+			msg := tgbotapi.NewMessage(chatID, fmt.Sprintf(`В инвентаре нет предмета "%s".`, consumable.Item))
+			h.sendMessage(msg)
+			callbackConfig := tgbotapi.NewCallback(callbackID, "")
+			h.requestAPI(callbackConfig)
+			return
+		}
+	}
+
+	// Инструмент ускоряет работу, голод замедляет, баффы от еды снова ускоряют
+	duration := h.actionDuration(player, toolDuration(resource.Duration, toolItem))
+
+	// Отвечаем на callback
+	callbackConfig := tgbotapi.NewCallback(callbackID, "")
+	h.requestAPI(callbackConfig)
+
+	// Удаляем предыдущее сообщение о результате, если оно существует
+	if session.ResultMessageID != 0 {
+		deleteResultMsg := tgbotapi.NewDeleteMessage(chatID, session.ResultMessageID)
+		h.requestAPI(deleteResultMsg)
+		session.ResultMessageID = 0
+	}
+
+	// Отправляем сообщение о начале добычи (информационное сообщение остается)
+	initialText := fmt.Sprintf(def.ProgressText+"\n\n%s 0%%", resource.Name, duration, h.createProgressBar(0, 10))
+	sentMsg, _ := h.sendMessageWithResponse(tgbotapi.NewMessage(chatID, initialText))
+
+	// Запоминаем действие с абсолютным временем окончания, чтобы пережить перезапуск
+	now := h.clock.Now()
+	action := &models.TimedAction{
+		PlayerID: userID, Kind: def.Action, ChatID: chatID, MessageID: sentMsg.MessageID,
+		ItemName: resource.Name, Quantity: 1, Durability: tool.Durability, ToolID: tool.ID, ToolName: toolItem.Name, Row: row, Col: col,
+		StartedAt: now, EndsAt: now.Add(time.Duration(duration) * time.Second),
+	}
+	h.playerState(userID).Actions[def.Action] = action
+
+	// Запускаем горутину для обновления прогресс бара
+	go h.updateLocationProgress(def, *action)
+}
+
+// updateLocationProgress обновляет прогресс бар добычи и завершает ее по таймеру
+func (h *BotHandlers) updateLocationProgress(def *locationDef, action models.TimedAction) {
+	ticker := h.clock.NewTicker(1 * time.Second)
+	defer ticker.Stop()
+
+	totalDuration := action.Duration()
+	for {
+		select {
+		case <-ticker.C():
+			elapsed := h.clock.Now().Sub(action.StartedAt).Seconds()
+			progress := int(elapsed)
+
+			if progress >= totalDuration {
+				// Добыча завершена
+				h.completeLocationAction(def, action)
+				return
+			}
+
+			// Обновляем прогресс бар
+			percentage := int((elapsed / float64(totalDuration)) * 100)
+			progressBar := h.createProgressBar(progress, totalDuration)
+
+			newText := fmt.Sprintf(def.ProgressText+"\n\n%s %d%%", action.ItemName, totalDuration, progressBar, percentage)
+			editMsg := tgbotapi.NewEditMessageText(action.ChatID, action.MessageID, newText)
+			h.editMessage(editMsg)
+		}
+	}
+}
+
+// spendConsumables решает, какие расходники действия потрачены, а какие удалось вернуть,
+// например подобрать стрелу после выстрела
+func (h *BotHandlers) spendConsumables(userID int64, def *locationDef) (consumed []models.ItemDelta, recovered []locationConsumable) {
+	for _, consumable := range def.Consumables {
+		if consumable.Recover > 0 && h.fields.Intn(userID, 100) < consumable.Recover {
+			recovered = append(recovered, consumable)
+			continue
+		}
+		consumed = append(consumed, models.ItemDelta{ItemName: consumable.Item, Quantity: consumable.Quantity})
+	}
+	return consumed, recovered
+}
+
+// completeLocationAction выдает добычу, изнашивает инструмент, начисляет опыт и
+// убирает ресурс с поля. Когда поле пустеет, локация истощается до конца кулдауна.
+func (h *BotHandlers) completeLocationAction(def *locationDef, action models.TimedAction) {
+	// Вызывается из горутины прогресса, поэтому сами захватываем состояние игрока
+	userID, chatID := action.PlayerID, action.ChatID
+	unlock := h.lockPlayer(userID)
+	defer unlock()
+
+	// Пока шла работа, сытость могла убыть со временем
+	h.settleSatiety(userID, chatID)
+
+	// Получаем игрока
+	player, err := h.db.GetPlayer(userID)
+	if err != nil {
+		log.Printf("Error getting player: %v", err)
+		return
+	}
+
+	resource, ok := def.resourceByName(action.ItemName)
+	if !ok {
+		resource = locationResource{Name: action.ItemName, Exp: 2}
+	}
+	session := h.playerState(userID).Sessions[def.Key]
+	if session != nil && (action.Row >= len(session.Resources) || action.Col >= len(session.Resources[action.Row])) {
+		session = nil
+	}
+
+	level := 1
+	if loc, err := h.db.GetOrCreateLocation(player.ID, def.Key); err == nil {
+		level = loc.Level
+	}
+
+	// Бросаем попадание: обычные ресурсы добываются всегда, дичь может уцелеть
+	tool := h.workedTool(def.Tool, action.ToolName)
+	shot := h.resolveShot(userID, resource, level, tool, session, action.Row, action.Col)
+
+	// Выдаем добычу, тратим расходники и прочность инструмента одним изменением
+	change := models.InventoryChange{Reason: def.Action}
+	var loot, rare []models.ItemDelta
+	_, buffYield := h.buffBonus(player.ID)
+	quantity := h.toolYield(userID, tool, buffYield)
+	if shot.Killed {
+		loot, rare = h.rollLoot(userID, def, resource, level, tool, quantity)
+		for _, item := range append(append([]models.ItemDelta(nil), loot...), rare...) {
+			change.Grant = addDelta(change.Grant, item.ItemName, item.Quantity)
+		}
+	}
+	consumed, recovered := h.spendConsumables(userID, def)
+	change.Consume = append(change.Consume, consumed...)
+	newDurability := action.Durability - 1
+	broken := newDurability <= 0
+	if broken && def.DropBroken {
+		change.Consume = append(change.Consume, models.ItemDelta{ItemName: action.ToolName, InstanceID: action.ToolID, Quantity: 1})
+	} else {
+		change.Wear = []models.ItemDelta{{ItemName: action.ToolName, InstanceID: action.ToolID, Durability: 1}}
+	}
+	if err := h.db.ApplyInventoryChange(player.ID, change); err != nil {
+		// Изменение откатилось целиком: не засчитываем ни добычу, ни опыт, ни сытость,
+		// клетка остается на поле и ее можно добыть заново
+		log.Printf("Error applying %s result: %v", def.Action, err)
+		delete(h.playerState(userID).Actions, def.Action)
+		deleteMsg := tgbotapi.NewDeleteMessage(chatID, action.MessageID)
+		h.requestAPI(deleteMsg)
+		msg := tgbotapi.NewMessage(chatID, def.ErrorText+" Добыча не засчитана, попробуй еще раз.")
+		h.sendMessage(msg)
+		return
+	}
+
+	worker := playerRef(userID, player.ID, chatID)
+	if shot.Killed && def.Prey {
+		events.Publish(h.bus, events.AnimalHunted{Player: worker, Animal: resource.Name})
+	} else if shot.Killed {
+		for _, item := range change.Grant {
+			events.Publish(h.bus, events.ResourceGathered{Player: worker, Location: def.Key, Resource: item.ItemName, Quantity: item.Quantity})
+		}
+	}
+	if broken {
+		events.Publish(h.bus, events.ToolBroken{Player: worker, Tool: action.ToolName})
+	}
+
+	// Работа в локации отнимает сытость по правилам для этого вида действий
+	if cost := h.survival.ActionCost(def.Action); cost > 0 {
+		if err := h.changeSatiety(worker, -cost); err != nil {
+			log.Printf("Error updating player satiety: %v", err)
+		}
+	}
+
+	// Добавляем опыт локации: за редкие ресурсы дают больше, за ранение зверя - 1
+	expGained := 0
+	switch {
+	case shot.Killed:
+		expGained = resource.Exp
+	case shot.Hit:
+		expGained = 1
+	}
+	var levelUp bool
+	var newLevel int
+	if expGained > 0 {
+		levelUp, newLevel, err = h.db.UpdateLocationExperience(player.ID, def.Key, expGained)
+		if err != nil {
+			log.Printf("Error updating %s experience: %v", def.Key, err)
+		}
+	}
+
+	// Получаем обновленные данные
+	updatedPlayer, err := h.db.GetPlayer(userID)
+	if err != nil {
+		log.Printf("Error getting updated player: %v", err)
+		updatedPlayer = player
+	}
+	loc, _ := h.db.GetOrCreateLocation(player.ID, def.Key)
+
+	// Удаляем сообщение с прогрессом
+	deleteMsg := tgbotapi.NewDeleteMessage(chatID, action.MessageID)
+	h.requestAPI(deleteMsg)
+
+	// Показываем результат
+	var resultText string
+	switch {
+	case shot.Killed:
+		resultText = fmt.Sprintf(def.ResultText, resource.Name)
+		resultText += lootText(loot, rare)
+	case shot.Hit:
+		resultText = fmt.Sprintf(def.HitText, resource.Name, shot.Wounds, resource.Health)
+	default:
+		resultText = def.MissText
+	}
+	if shot.Fled {
+		resultText += "\n" + fmt.Sprintf(def.FleeText, resource.Name)
+	} else if shot.Moved {
+		resultText += "\n" + fmt.Sprintf(def.MoveText, resource.Name)
+	}
+	resultText += fmt.Sprintf("\nПолучено опыта: %d", expGained)
+	resultText += fmt.Sprintf("\nСытость: %d/100", updatedPlayer.Satiety)
+	resultText += h.hungerText(updatedPlayer.Satiety)
+	if broken {
+		resultText += "\n" + fmt.Sprintf(def.BrokenText, action.ToolName)
+	} else {
+		resultText += fmt.Sprintf("\nПрочность %s: %d/%d", def.ToolLabel, newDurability, tool.DurabilityMax)
+	}
+	for _, consumable := range recovered {
+		resultText += "\n" + consumable.RecoverText
+	}
+	for _, consumable := range def.Consumables {
+		left, err := h.db.GetItemQuantityInInventory(player.ID, consumable.Item)
+		if err != nil {
+			log.Printf("Error getting %s quantity: %v", consumable.Item, err)
+		}
+		resultText += "\n" + fmt.Sprintf(consumable.LeftText, left)
+	}
+	if shot.Killed {
+		resultText += yieldText(quantity)
+	}
+	if loc != nil {
+		resultText += fmt.Sprintf("\nДо следующего уровня: %d опыта", loc.ExpToNext())
+	}
+
+	resultResponse, _ := h.sendMessageWithResponse(tgbotapi.NewMessage(chatID, resultText))
+
+	if levelUp {
+		events.Publish(h.bus, events.LevelUp{Player: worker, Location: def.Key, Level: newLevel})
+	}
+
+	// Убираем таймер
+	delete(h.playerState(userID).Actions, def.Action)
+
+	// Обновляем поле: добытый или убежавший зверь пропадает, перебежавший переносится
+	if session == nil {
+		return
+	}
+	session.ResultMessageID = resultResponse.MessageID
+	switch {
+	case shot.Killed || shot.Fled:
+		session.Resources[action.Row][action.Col] = ""
+		if len(session.Wounds) > 0 {
+			setWounds(session, action.Row, action.Col, 0)
+		}
+	case shot.Moved:
+		h.moveAnimal(userID, session, action.Row, action.Col, shot.Wounds)
+	case shot.Hit:
+		setWounds(session, action.Row, action.Col, shot.Wounds)
+	}
+
+	if fieldHasResources(session.Resources) {
+		// Обновляем клавиатуру поля и информационное сообщение
+		editField := tgbotapi.NewEditMessageReplyMarkup(chatID, session.FieldMessageID, def.keyboard(session.Resources, session.Wounds))
+		h.editMessage(editField)
+		if loc != nil {
+			editInfo := tgbotapi.NewEditMessageText(chatID, session.InfoMessageID, def.infoText(loc))
+			h.editMessage(editInfo)
+		}
+		return
+	}
+
+	// Поле пустое: локация истощена до конца кулдауна
+	if err := h.db.SetLocationExhausted(player.ID, def.Key, true); err != nil {
+		log.Printf("Error exhausting %s: %v", def.Key, err)
+	}
+	h.playerState(userID).Cooldowns[def.Key] = h.clock.Now().Add(def.Cooldown)
+
+	h.closeLocationSession(chatID, userID, def)
+
+	exhaustMsg := tgbotapi.NewMessage(chatID, fmt.Sprintf("%s\nНажми кнопку \"%s\" чтобы проверить готовность.", def.ExhaustedText, def.Button))
+	h.sendLocationMenu(def, exhaustMsg)
+}
+
+// closeLocationSession удаляет сообщения поля и информации и забывает сессию локации
+func (h *BotHandlers) closeLocationSession(chatID int64, userID int64, def *locationDef) {
+	session := h.playerState(userID).Sessions[def.Key]
+	if session == nil {
+		return
+	}
+
+	deleteFieldMsg := tgbotapi.NewDeleteMessage(chatID, session.FieldMessageID)
+	h.requestAPI(deleteFieldMsg)
+
+	deleteInfoMsg := tgbotapi.NewDeleteMessage(chatID, session.InfoMessageID)
+	h.requestAPI(deleteInfoMsg)
+
+	delete(h.playerState(userID).Sessions, def.Key)
+}
+
+// fieldHasResources сообщает, остались ли на поле ресурсы
+func fieldHasResources(field [][]string) bool {
+	for _, row := range field {
+		for _, cell := range row {
+			if cell != "" {
+				return true
+			}
+		}
+	}
+	return false
+}
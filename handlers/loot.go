@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"reborn_land/catalog"
+	"reborn_land/models"
+	"strings"
+)
+
+// rollLoot бросает таблицу добычи ресурса локации: обычные предметы и редкие
+// находки, шанс которых растет с уровнем локации и инструмента. Бонус инструмента
+// добавляет лишние единицы к первому гарантированному предмету. Ресурс без
+// таблицы приносит сам себя.
+func (h *BotHandlers) rollLoot(userID int64, def *locationDef, resource locationResource, level int, tool *models.Item, quantity int) (loot []models.ItemDelta, rare []models.ItemDelta) {
+	table, ok := h.loot.Table(def.Key, resource.Key)
+	if !ok {
+		return []models.ItemDelta{{ItemName: resource.Name, Quantity: quantity}}, nil
+	}
+
+	bonus := quantity - 1
+	for _, drop := range table.Drops {
+		if !drop.Guaranteed() && h.fields.Intn(userID, 100) >= drop.ChanceAt(level, tool.Tier) {
+			continue
+		}
+		count := drop.Min
+		if drop.Max > drop.Min {
+			count += h.fields.Intn(userID, drop.Max-drop.Min+1)
+		}
+		if drop.Guaranteed() {
+			count += bonus
+			bonus = 0
+		}
+
+		if drop.Rare() {
+			rare = addDelta(rare, drop.Item, count)
+		} else {
+			loot = addDelta(loot, drop.Item, count)
+		}
+	}
+	return loot, rare
+}
+
+// addDelta добавляет предмет к списку, складывая одинаковые предметы
+func addDelta(deltas []models.ItemDelta, item string, quantity int) []models.ItemDelta {
+	for i := range deltas {
+		if deltas[i].ItemName == item {
+			deltas[i].Quantity += quantity
+			return deltas
+		}
+	}
+	return append(deltas, models.ItemDelta{ItemName: item, Quantity: quantity})
+}
+
+// lootText - строки результата со всей полученной добычей
+func lootText(loot []models.ItemDelta, rare []models.ItemDelta) string {
+	text := "\nПолучено: " + deltasText(loot)
+	if len(rare) > 0 {
+		text += "\n🌟 Редкая находка: " + deltasText(rare)
+	}
+	return text
+}
+
+func deltasText(deltas []models.ItemDelta) string {
+	var parts []string
+	for _, item := range deltas {
+		parts = append(parts, fmt.Sprintf("%s x%d", item.ItemName, item.Quantity))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// checkLoot предупреждает о таблицах добычи, которые не подходят ни к одному ресурсу локации
+func checkLoot(loot *catalog.Loot) {
+	for _, table := range loot.Tables {
+		def, ok := locationByKey(table.Location)
+		if ok {
+			_, ok = def.resourceByKey(table.Resource)
+		}
+		if !ok {
+			log.Printf("Loot table %s/%s does not match any location resource", table.Location, table.Resource)
+		}
+	}
+}
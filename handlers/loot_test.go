@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"reborn_land/catalog"
+	"reborn_land/fieldgen"
+	"reborn_land/models"
+	"testing"
+)
+
+// testLoot - таблица с гарантированным, обычным и редким предметом
+var testLoot = &catalog.Loot{Tables: []catalog.LootTable{{
+	Location: "mine", Resource: "stone",
+	Drops: []catalog.Drop{
+		{Item: "Камень", Chance: 100, Min: 1, Max: 3},
+		{Item: "Уголь", Chance: 50, Min: 1, Max: 1},
+		{Item: "Самоцвет", Chance: 0, Min: 1, Max: 1, PerLevel: 10, PerTier: 20, MaxChance: 60},
+	},
+}}}
+
+func TestDropChanceAt(t *testing.T) {
+	rare := catalog.Drop{Chance: 1, PerLevel: 1, PerTier: 2, MaxChance: 15}
+	tests := []struct {
+		name  string
+		drop  catalog.Drop
+		level int
+		tier  int
+		want  int
+	}{
+		{"base", rare, 1, 1, 1},
+		{"per level", rare, 5, 1, 5},
+		{"per tier", rare, 1, 4, 7},
+		{"level and tier", rare, 5, 3, 9},
+		{"capped by max chance", rare, 20, 4, 15},
+		{"no bonus below first level", rare, 0, 0, 1},
+		{"guaranteed", catalog.Drop{Chance: 100}, 10, 4, 100},
+		{"never above 100", catalog.Drop{Chance: 90, PerLevel: 10}, 5, 1, 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.drop.ChanceAt(tt.level, tt.tier); got != tt.want {
+				t.Errorf("ChanceAt(%d, %d) = %d, want %d", tt.level, tt.tier, got, tt.want)
+			}
+		})
+	}
+}
+
+// Ресурс без таблицы добычи приносит сам себя и не тратит бросков
+func TestRollLootWithoutTable(t *testing.T) {
+	h := &BotHandlers{fields: fieldgen.New(1), loot: &catalog.Loot{}}
+	def, _ := locationByKey("lake")
+	resource, _ := def.resourceByKey("pike")
+
+	loot, rare := h.rollLoot(testUser, def, resource, 1, &models.Item{Tier: 1}, 2)
+	if len(loot) != 1 || loot[0].ItemName != "Щука" || loot[0].Quantity != 2 || len(rare) != 0 {
+		t.Errorf("loot = %+v, rare = %+v; want Щука x2", loot, rare)
+	}
+	if h.fields.Intn(testUser, 1000) != fieldgen.New(1).Intn(testUser, 1000) {
+		t.Errorf("loot without a table used a roll")
+	}
+}
+
+// Добыча сверяется с бросками из генератора с тем же зерном
+func TestRollLoot(t *testing.T) {
+	def, _ := locationByKey("mine")
+	stone, _ := def.resourceByKey("stone")
+	drops := testLoot.Tables[0].Drops
+
+	tests := []struct {
+		name     string
+		level    int
+		tier     int
+		quantity int
+	}{
+		{"simple pickaxe", 1, 1, 1},
+		{"tool bonus goes to the guaranteed drop", 1, 1, 2},
+		{"high level", 4, 1, 1},
+		{"high level and tier", 4, 3, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for seed := int64(1); seed <= 200; seed++ {
+				h := &BotHandlers{fields: fieldgen.New(seed), loot: testLoot}
+				rolls := fieldgen.New(seed)
+
+				loot, rare := h.rollLoot(testUser, def, stone, tt.level, &models.Item{Tier: tt.tier}, tt.quantity)
+
+				want := map[string]int{"Камень": 1 + rolls.Intn(testUser, 3) + tt.quantity - 1}
+				if rolls.Intn(testUser, 100) < drops[1].ChanceAt(tt.level, tt.tier) {
+					want["Уголь"] = 1
+				}
+				wantRare := 0
+				if rolls.Intn(testUser, 100) < drops[2].ChanceAt(tt.level, tt.tier) {
+					wantRare = 1
+				}
+
+				got := make(map[string]int)
+				for _, item := range loot {
+					got[item.ItemName] += item.Quantity
+				}
+				if len(got) != len(want) || got["Камень"] != want["Камень"] || got["Уголь"] != want["Уголь"] {
+					t.Fatalf("seed %d: loot = %+v, want %v", seed, loot, want)
+				}
+				gotRare := 0
+				for _, item := range rare {
+					if item.ItemName != "Самоцвет" {
+						t.Fatalf("seed %d: unexpected rare drop %+v", seed, item)
+					}
+					gotRare += item.Quantity
+				}
+				if gotRare != wantRare {
+					t.Fatalf("seed %d: rare = %+v, want %d Самоцвет", seed, rare, wantRare)
+				}
+			}
+		})
+	}
+}
+
+// Редкий самоцвет в шахте выпадает тем чаще, чем выше уровень шахты и кирки, но не чаще предела
+func TestRareDropChanceRisesWithLevelAndTier(t *testing.T) {
+	items := catalog.Default()
+	loot := catalog.DefaultLoot(items)
+	def, _ := locationByKey("mine")
+	stone, _ := def.resourceByKey("stone")
+
+	rareDrops := func(level, tier int) int {
+		h := &BotHandlers{fields: fieldgen.New(42), loot: loot}
+		count := 0
+		for i := 0; i < 5000; i++ {
+			_, rare := h.rollLoot(testUser, def, stone, level, &models.Item{Tier: tier}, 1)
+			count += len(rare)
+		}
+		return count
+	}
+
+	base, leveled, tooled, capped := rareDrops(1, 1), rareDrops(5, 1), rareDrops(5, 3), rareDrops(30, 4)
+	if !(base < leveled && leveled < tooled && tooled < capped) {
+		t.Errorf("rare drops per 5000: level 1 tier 1 = %d, level 5 = %d, level 5 tier 3 = %d, capped = %d; want rising", base, leveled, tooled, capped)
+	}
+	// Шанс ограничен 10%: около 500 из 5000
+	if capped < 400 || capped > 600 {
+		t.Errorf("capped rare drops = %d of 5000, want about 500", capped)
+	}
+}
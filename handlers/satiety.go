@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"reborn_land/catalog"
+	"reborn_land/events"
+	"reborn_land/models"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// starvingText - ответ на попытку работать с пустой сытостью
+const starvingText = "Сытость 0. Необходимо поесть."
+
+// settleSatiety списывает сытость, убывшую со временем с прошлого учета, и предупреждает,
+// когда голод усилился. Вызывающий код должен удерживать блокировку игрока.
+func (h *BotHandlers) settleSatiety(userID int64, chatID int64) {
+	player, err := h.db.GetPlayer(userID)
+	if err != nil {
+		// Игрок еще не зарегистрирован
+		return
+	}
+
+	every := h.survival.DecayEvery()
+	ticks := int(h.clock.Now().Sub(player.SatietyUpdatedAt) / every)
+	if ticks <= 0 {
+		return
+	}
+	settledAt := player.SatietyUpdatedAt.Add(time.Duration(ticks) * every)
+	if err := h.db.DecayPlayerSatiety(player.ID, ticks, settledAt); err != nil {
+		log.Printf("Error decaying satiety: %v", err)
+		return
+	}
+
+	loss := min(ticks, player.Satiety)
+	if loss == 0 {
+		return
+	}
+	events.Publish(h.bus, events.SatietyChanged{Player: playerRef(userID, player.ID, chatID), Delta: -loss})
+
+	before, _ := h.survival.PenaltyAt(player.Satiety)
+	after, hungry := h.survival.PenaltyAt(player.Satiety - loss)
+	if hungry && after.Slowdown > before.Slowdown {
+		text := fmt.Sprintf("🍖 %s: действия идут на %d%% медленнее. Сытость: %d/100. Поешь: /eat", after.Name, after.Slowdown, player.Satiety-loss)
+		msg := tgbotapi.NewMessage(chatID, text)
+		h.sendMessage(msg)
+	}
+}
+
+// activeBuffs возвращает баффы от еды, которые действуют сейчас
+func (h *BotHandlers) activeBuffs(playerID int) []models.Buff {
+	buffs, err := h.db.GetPlayerBuffs(playerID, h.clock.Now())
+	if err != nil {
+		log.Printf("Error getting player buffs: %v", err)
+		return nil
+	}
+	return buffs
+}
+
+// buffBonus - суммарное ускорение и шанс лишней добычи от действующих баффов
+func (h *BotHandlers) buffBonus(playerID int) (speed int, yield int) {
+	for _, buff := range h.activeBuffs(playerID) {
+		speed += buff.Speed
+		yield += buff.Yield
+	}
+	return speed, yield
+}
+
+// actionDuration - время действия с учетом голода и баффов игрока
+func (h *BotHandlers) actionDuration(player *models.Player, base int) int {
+	speed, _ := h.buffBonus(player.ID)
+	return h.survival.Duration(base, player.Satiety, speed)
+}
+
+// applyFoodBuff накладывает бафф съеденной еды и возвращает строку о нем для сообщения
+func (h *BotHandlers) applyFoodBuff(playerID int, food string) string {
+	buff, ok := h.survival.BuffFor(food)
+	if !ok {
+		return ""
+	}
+
+	now := h.clock.Now()
+	err := h.db.AddPlayerBuff(playerID, models.Buff{
+		ItemName: food, Name: buff.Name, Speed: buff.Speed, Yield: buff.Yield,
+		EndsAt: now.Add(buff.Duration()),
+	}, now)
+	if err != nil {
+		log.Printf("Error adding buff from %s: %v", food, err)
+		return ""
+	}
+	return fmt.Sprintf("\n✨ %s на %d мин.: %s", buff.Name, buff.Minutes, buffEffects(buff.Speed, buff.Yield))
+}
+
+// buffEffects описывает действие баффа
+func buffEffects(speed, yield int) string {
+	var effects []string
+	if speed > 0 {
+		effects = append(effects, fmt.Sprintf("действия быстрее на %d%%", speed))
+	}
+	if yield > 0 {
+		effects = append(effects, fmt.Sprintf("шанс лишней добычи +%d%%", yield))
+	}
+	return strings.Join(effects, ", ")
+}
+
+// hungerText - строка о штрафе голода, пустая, если игрок сыт
+func (h *BotHandlers) hungerText(satiety int) string {
+	penalty, ok := h.survival.PenaltyAt(satiety)
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf("\n🍖 %s: действия идут на %d%% медленнее", penalty.Name, penalty.Slowdown)
+}
+
+// buffsText перечисляет действующие баффы с оставшимся временем
+func (h *BotHandlers) buffsText(buffs []models.Buff) string {
+	var text string
+	for _, buff := range buffs {
+		left := max(1, int(buff.EndsAt.Sub(h.clock.Now()).Minutes()+0.5))
+		text += fmt.Sprintf("\n✨ %s (%s): %s, еще %d мин.", buff.Name, buff.ItemName, buffEffects(buff.Speed, buff.Yield), left)
+	}
+	return text
+}
+
+// checkSurvival предупреждает о тратах сытости для действий, которых нет среди локаций
+func checkSurvival(survival *catalog.Survival) {
+	for action := range survival.Actions {
+		if _, ok := locationByAction(action); !ok {
+			log.Printf("Survival action %q does not match any location", action)
+		}
+	}
+}
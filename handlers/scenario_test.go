@@ -0,0 +1,324 @@
+package handlers
+
+import (
+	"fmt"
+	"reborn_land/catalog"
+	"reborn_land/clock"
+	"reborn_land/database"
+	"reborn_land/fieldgen"
+	"reborn_land/models"
+	"reborn_land/state"
+	"reborn_land/telegramtest"
+	"strings"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// scenario - бот на поддельном Telegram, хранилище в памяти и поддельных часах
+type scenario struct {
+	t       *testing.T
+	db      *database.Memory
+	clk     *clock.Fake
+	bot     *telegramtest.Fake
+	h       *BotHandlers
+	items   *catalog.Catalog
+	recipes *catalog.Recipes
+}
+
+func newScenario(t *testing.T) *scenario {
+	items := catalog.Default()
+	recipes := catalog.DefaultRecipes(items)
+	s := &scenario{
+		t: t, db: database.NewMemory(items, recipes), clk: clock.NewFake(time.Now()), bot: telegramtest.New(),
+		items: items, recipes: recipes,
+	}
+	s.h = s.newHandlers()
+	return s
+}
+
+// newHandlers создает обработчики поверх того же хранилища и часов, как бот после перезапуска
+func (s *scenario) newHandlers() *BotHandlers {
+	return New(s.bot, s.db, s.clk, fieldgen.New(1), catalog.DefaultQuests(s.items, s.recipes), catalog.DefaultLoot(s.items), catalog.DefaultSurvival(s.items))
+}
+
+func (s *scenario) send(userID int64, text string) {
+	s.h.HandleUpdate(s.bot.Text(userID, text))
+}
+
+func (s *scenario) press(userID int64, messageID int, data string) {
+	s.h.HandleUpdate(s.bot.Callback(userID, messageID, data))
+}
+
+// state читает состояние игрока под его блокировкой
+func (s *scenario) state(userID int64, read func(p *state.Player)) {
+	s.h.states.Do(userID, read)
+}
+
+// advanceUntil переводит часы шагами step, пока done не вернет true
+func (s *scenario) advanceUntil(step time.Duration, limit int, done func() bool) {
+	s.t.Helper()
+	for i := 0; i < limit; i++ {
+		if done() {
+			return
+		}
+		s.clk.Advance(step)
+		time.Sleep(time.Millisecond)
+	}
+	if !done() {
+		s.t.Fatalf("condition not reached after %d steps of %v", limit, step)
+	}
+}
+
+// register проходит регистрацию: /start, приветствие с задержками и ввод имени
+func (s *scenario) register(userID int64, name string) *models.Player {
+	s.t.Helper()
+	s.send(userID, "/start")
+	s.advanceUntil(time.Second, 30, func() bool {
+		waiting := false
+		s.state(userID, func(p *state.Player) { waiting = p.WaitingForName })
+		return waiting
+	})
+	s.send(userID, name)
+
+	player, err := s.db.GetPlayer(userID)
+	if err != nil {
+		s.t.Fatalf("player is not registered: %v", err)
+	}
+	return player
+}
+
+// busy сообщает, идет ли у игрока действие вида kind
+func (s *scenario) busy(userID int64, kind string) bool {
+	busy := false
+	s.state(userID, func(p *state.Player) {
+		switch kind {
+		case "crafting":
+			busy = p.Crafting != nil
+		case "resting":
+			busy = p.Resting != nil
+		default:
+			busy = p.Actions[kind] != nil
+		}
+	})
+	return busy
+}
+
+// waitDone переводит часы, пока действие вида kind не завершится
+func (s *scenario) waitDone(userID int64, kind string, step time.Duration) {
+	s.t.Helper()
+	s.advanceUntil(step, 1000, func() bool { return !s.busy(userID, kind) })
+}
+
+// startGather нажимает клетку с ресурсом на поле локации. Если такого ресурса на поле нет,
+// поле открывается заново, а истощенная локация ждет конца кулдауна.
+func (s *scenario) startGather(userID int64, locationKey, resourceKey string) {
+	s.t.Helper()
+	def, _ := locationByKey(locationKey)
+	resource, ok := def.resourceByKey(resourceKey)
+	if !ok {
+		s.t.Fatalf("location %s has no resource %s", locationKey, resourceKey)
+	}
+
+	for attempt := 0; attempt < 20; attempt++ {
+		var data string
+		var fieldID int
+		opened := false
+		s.state(userID, func(p *state.Player) {
+			session := p.Sessions[def.Key]
+			if session == nil {
+				return
+			}
+			opened, fieldID = true, session.FieldMessageID
+			for i := range session.Resources {
+				for j := range session.Resources[i] {
+					if session.Resources[i][j] == resource.Emoji && data == "" {
+						data = fmt.Sprintf("%s_%s_%d_%d", def.Callback, resource.Key, i, j)
+					}
+				}
+			}
+		})
+
+		switch {
+		case data != "":
+			s.press(userID, fieldID, data)
+			if !s.busy(userID, def.Action) {
+				last, _ := s.bot.Last(userID)
+				s.t.Fatalf("%s did not start: %q", def.Action, last.Text)
+			}
+			return
+		case opened:
+			s.send(userID, "◀️ Назад")
+		default:
+			s.send(userID, def.Button)
+			if !s.hasSession(userID, def.Key) {
+				s.clk.Advance(def.Cooldown)
+			}
+		}
+	}
+	s.t.Fatalf("no %s found in %s", resourceKey, locationKey)
+}
+
+// gather выполняет одно действие над ресурсом и ждет его завершения
+func (s *scenario) gather(userID int64, locationKey, resourceKey string) {
+	s.t.Helper()
+	def, _ := locationByKey(locationKey)
+	s.startGather(userID, locationKey, resourceKey)
+	s.waitDone(userID, def.Action, time.Second)
+}
+
+func (s *scenario) hasSession(userID int64, location string) bool {
+	opened := false
+	s.state(userID, func(p *state.Player) { opened = p.Sessions[location] != nil })
+	return opened
+}
+
+func (s *scenario) quantity(playerID int, item string) int {
+	s.t.Helper()
+	quantity, err := s.db.GetItemQuantityInInventory(playerID, item)
+	if err != nil {
+		s.t.Fatalf("inventory quantity of %s: %v", item, err)
+	}
+	return quantity
+}
+
+func (s *scenario) questStatus(playerID int, questID int) string {
+	s.t.Helper()
+	quest, err := s.db.GetPlayerQuest(playerID, questID)
+	if err != nil {
+		s.t.Fatalf("quest %d: %v", questID, err)
+	}
+	if quest == nil {
+		return ""
+	}
+	return quest.Status
+}
+
+// sent сообщает, отправлял ли бот в чат сообщение с текстом text
+func (s *scenario) sent(chatID int64, text string) bool {
+	for _, sent := range s.bot.Texts(chatID) {
+		if strings.Contains(sent, text) {
+			return true
+		}
+	}
+	return false
+}
+
+// acceptQuest открывает ЛОР-квесты и принимает предложенный квест
+func (s *scenario) acceptQuest(userID int64, playerID int, questID int) {
+	s.t.Helper()
+	s.send(userID, "📖 Лор")
+	offer, _ := s.bot.Last(userID)
+	if !strings.Contains(offer.Text, fmt.Sprintf("Квест %d", questID)) {
+		s.t.Fatalf("quest %d is not offered: %q", questID, offer.Text)
+	}
+	if _, ok := offer.Markup.(tgbotapi.InlineKeyboardMarkup); !ok {
+		s.t.Fatalf("quest offer has no inline buttons: %T", offer.Markup)
+	}
+
+	s.press(userID, offer.ID, fmt.Sprintf("quest_accept_%d", questID))
+	if status := s.questStatus(playerID, questID); status != "active" {
+		s.t.Fatalf("quest %d status after accept = %q, want active", questID, status)
+	}
+}
+
+// Новый игрок регистрируется, выполняет первый квест рубкой березы и второй -
+// добычей камня в шахте. Проверяются записанные отправки, правки и удаления
+// сообщений, а также инвентарь, экземпляры инструментов и квесты в хранилище.
+func TestScenarioRegisterGatherAndCompleteQuests(t *testing.T) {
+	s := newScenario(t)
+	const u = int64(1001)
+
+	player := s.register(u, "Тестер")
+	if !s.sent(u, "✅ Регистрация прошла успешно!") {
+		t.Fatalf("no registration message, sent: %q", s.bot.Texts(u))
+	}
+	if player.Name != "Тестер" || player.Satiety != 100 {
+		t.Fatalf("registered player = %+v", player)
+	}
+	// Стартовый набор выдается один раз, при создании игрока
+	for item, want := range map[string]int{"Стрелы": 100, "Простой лук": 1, "Простой топор": 1, "Простая кирка": 1, "Лесная ягода": 10} {
+		if got := s.quantity(player.ID, item); got != want {
+			t.Errorf("starter %s = %d, want %d", item, got, want)
+		}
+	}
+
+	// Квест 1: наруби 5 берез
+	s.acceptQuest(u, player.ID, 1)
+	chops := 0
+	for s.questStatus(player.ID, 1) != "completed" {
+		if chops == 10 {
+			t.Fatalf("quest 1 is not completed after %d chops", chops)
+		}
+		s.gather(u, "forest", "birch")
+		chops++
+	}
+	if !s.sent(u, "Квест 1: Дерево под топор ВЫПОЛНЕН!") {
+		t.Errorf("no quest 1 completion message")
+	}
+	if got := s.quantity(player.ID, "Береза"); got < 5 {
+		t.Errorf("birch = %d, want at least 5", got)
+	}
+	if got := s.quantity(player.ID, "📖 Страница 1 «Забытая тишина»"); got != 1 {
+		t.Errorf("quest 1 reward page = %d, want 1", got)
+	}
+
+	// Каждая рубка изнашивает один и тот же экземпляр топора
+	axe, err := s.db.GetTool(player.ID, "Простой топор")
+	if err != nil || axe == nil {
+		t.Fatalf("axe instance: %v, %v", axe, err)
+	}
+	if axe.Durability != 100-chops {
+		t.Errorf("axe durability = %d after %d chops, want %d", axe.Durability, chops, 100-chops)
+	}
+
+	// Прогресс добычи редактируется, а сообщение с прогрессом удаляется по завершении
+	if len(s.bot.Edited()) == 0 {
+		t.Errorf("progress messages were never edited")
+	}
+	if len(s.bot.Deleted()) < chops {
+		t.Errorf("deleted %d messages, want at least one per chop (%d)", len(s.bot.Deleted()), chops)
+	}
+
+	// Квест 2: добудь 3 камня
+	s.send(u, "◀️ Назад")
+	s.acceptQuest(u, player.ID, 2)
+	mined := 0
+	for s.questStatus(player.ID, 2) != "completed" {
+		if mined == 10 {
+			t.Fatalf("quest 2 is not completed after %d stones", mined)
+		}
+		s.gather(u, "mine", "stone")
+		mined++
+	}
+	if !s.sent(u, "Квест 2: Вглубь ВЫПОЛНЕН!") {
+		t.Errorf("no quest 2 completion message")
+	}
+	if got := s.quantity(player.ID, "📖 Страница 2 «Пепел памяти»"); got != 1 {
+		t.Errorf("quest 2 reward page = %d, want 1", got)
+	}
+	pickaxe, err := s.db.GetTool(player.ID, "Простая кирка")
+	if err != nil || pickaxe == nil || pickaxe.Durability != 100-mined {
+		t.Errorf("pickaxe after %d stones = %+v, %v", mined, pickaxe, err)
+	}
+
+	// Награды квестов: по 10 опыта и записи в журнале инвентаря
+	updated, _ := s.db.GetPlayer(u)
+	if updated.Experience != 20 {
+		t.Errorf("experience = %d, want 20", updated.Experience)
+	}
+	ledger, err := s.db.GetInventoryLedger(player.ID, 100)
+	if err != nil {
+		t.Fatalf("ledger: %v", err)
+	}
+	rewards := 0
+	for _, entry := range ledger {
+		if strings.HasPrefix(entry.Reason, "quest:") {
+			rewards++
+		}
+	}
+	if rewards != 2 {
+		t.Errorf("quest reward ledger entries = %d, want 2", rewards)
+	}
+}
@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"reborn_land/clock"
+	"reborn_land/models"
+	"reborn_land/state"
+	"testing"
+	"time"
+)
+
+// action возвращает копию идущего действия вида kind
+func (s *scenario) action(userID int64, kind string) models.TimedAction {
+	s.t.Helper()
+	var action *models.TimedAction
+	s.state(userID, func(p *state.Player) {
+		switch kind {
+		case "crafting":
+			action = p.Crafting
+		case "resting":
+			action = p.Resting
+		default:
+			action = p.Actions[kind]
+		}
+	})
+	if action == nil {
+		s.t.Fatalf("no %s action", kind)
+	}
+	return *action
+}
+
+// advanceTo переводит часы до момента at и дает горутинам прогресса отработать
+func (s *scenario) advanceTo(at time.Time) {
+	if d := at.Sub(s.clk.Now()); d > 0 {
+		s.clk.Advance(d)
+	}
+	time.Sleep(20 * time.Millisecond)
+}
+
+// restart имитирует перезапуск бота: горутины старых обработчиков остаются на
+// прежних часах, которые больше не идут, а новые обработчики восстанавливают
+// состояние из хранилища и работают на новых часах с того же момента.
+func (s *scenario) restart() {
+	s.t.Helper()
+	s.clk = clock.NewFake(s.clk.Now())
+	s.h = s.newHandlers()
+	if err := s.h.Restore(); err != nil {
+		s.t.Fatalf("Restore: %v", err)
+	}
+}
+
+func (s *scenario) grant(playerID int, item string, quantity int) {
+	s.t.Helper()
+	err := s.db.ApplyInventoryChange(playerID, models.InventoryChange{
+		Reason: "test",
+		Grant:  []models.ItemDelta{{ItemName: item, Quantity: quantity}},
+	})
+	if err != nil {
+		s.t.Fatalf("grant %s: %v", item, err)
+	}
+}
+
+// startBirchPlank запускает создание одного березового бруса на верстаке
+func (s *scenario) startBirchPlank(userID int64, playerID int) {
+	s.t.Helper()
+	s.grant(playerID, "Береза", 2)
+	s.press(userID, 1, "craft_birch_plank")
+	s.send(userID, "1")
+	if !s.busy(userID, "crafting") {
+		last, _ := s.bot.Last(userID)
+		s.t.Fatalf("crafting did not start: %q", last.Text)
+	}
+}
+
+// Добыча в шахте завершается ровно по истечении своего времени
+func TestTimersMiningCompletesAtDeadline(t *testing.T) {
+	s := newScenario(t)
+	const u = int64(2001)
+	player := s.register(u, "Шахтер")
+
+	s.startGather(u, "mine", "stone")
+	s.clk.BlockUntil(1)
+	action := s.action(u, "mining")
+	if got := s.quantity(player.ID, "Камень"); got != 0 {
+		t.Fatalf("stone before mining = %d", got)
+	}
+
+	s.advanceTo(action.EndsAt.Add(-time.Second))
+	if !s.busy(u, "mining") || s.quantity(player.ID, "Камень") != 0 {
+		t.Fatalf("mining finished a second before its deadline")
+	}
+	if len(s.bot.Edited()) == 0 {
+		t.Errorf("mining progress was never edited")
+	}
+
+	s.advanceTo(action.EndsAt)
+	s.waitDone(u, "mining", time.Second)
+	if got := s.quantity(player.ID, "Камень"); got == 0 {
+		t.Errorf("no stone after mining")
+	}
+	if pickaxe, _ := s.db.GetTool(player.ID, "Простая кирка"); pickaxe == nil || pickaxe.Durability != 99 {
+		t.Errorf("pickaxe after one stone = %+v", pickaxe)
+	}
+}
+
+// Создание предмета списывает ингредиенты сразу, а выдает результат по истечении времени
+func TestTimersCraftingCompletesAtDeadline(t *testing.T) {
+	s := newScenario(t)
+	const u = int64(2002)
+	player := s.register(u, "Плотник")
+
+	s.startBirchPlank(u, player.ID)
+	s.clk.BlockUntil(1)
+	action := s.action(u, "crafting")
+	if got := s.quantity(player.ID, "Береза"); got != 0 {
+		t.Errorf("birch after start = %d, want 0", got)
+	}
+
+	s.advanceTo(action.EndsAt.Add(-time.Second))
+	if !s.busy(u, "crafting") || s.quantity(player.ID, "Березовый брус") != 0 {
+		t.Fatalf("crafting finished a second before its deadline")
+	}
+
+	s.advanceTo(action.EndsAt)
+	s.waitDone(u, "crafting", time.Second)
+	if got := s.quantity(player.ID, "Березовый брус"); got != 1 {
+		t.Errorf("birch plank = %d, want 1", got)
+	}
+	if !s.sent(u, "✅ Создание завершено!") {
+		t.Errorf("no crafting completion message")
+	}
+}
+
+// Отдых в хижине идет 30 минут и восстанавливает сытость
+func TestTimersRestRestoresSatiety(t *testing.T) {
+	s := newScenario(t)
+	const u = int64(2003)
+	player := s.register(u, "Отшельник")
+	s.db.UpdateSimpleHutBuilt(player.ID, true)
+	s.db.UpdatePlayerSatiety(player.ID, -70)
+
+	s.send(u, "/rest")
+	s.clk.BlockUntil(1)
+	action := s.action(u, "resting")
+	if got := action.EndsAt.Sub(action.StartedAt); got != restDuration {
+		t.Fatalf("rest duration = %v, want %v", got, restDuration)
+	}
+	before, _ := s.db.GetPlayer(u)
+
+	s.advanceTo(action.EndsAt.Add(-time.Minute))
+	if !s.busy(u, "resting") {
+		t.Fatalf("rest finished a minute before its deadline")
+	}
+
+	s.waitDone(u, "resting", time.Minute)
+	after, _ := s.db.GetPlayer(u)
+	if want := min(before.Satiety+s.h.survival.Rest, 100); after.Satiety != want {
+		t.Errorf("satiety after rest = %d, want %d", after.Satiety, want)
+	}
+	if !s.sent(u, "Отдых завершен.") {
+		t.Errorf("no rest completion message")
+	}
+}
+
+// После перезапуска бота начатые добыча, крафт и отдых продолжаются и завершаются
+// в срок, сохраненный до перезапуска
+func TestTimersRestoreAfterRestart(t *testing.T) {
+	s := newScenario(t)
+	const miner, crafter, sleeper = int64(2011), int64(2012), int64(2013)
+	minerPlayer := s.register(miner, "Шахтер")
+	crafterPlayer := s.register(crafter, "Плотник")
+	sleeperPlayer := s.register(sleeper, "Отшельник")
+	s.db.UpdateSimpleHutBuilt(sleeperPlayer.ID, true)
+	s.db.UpdatePlayerSatiety(sleeperPlayer.ID, -70)
+
+	s.startGather(miner, "mine", "stone")
+	s.startBirchPlank(crafter, crafterPlayer.ID)
+	s.send(sleeper, "/rest")
+	mining, crafting, resting := s.action(miner, "mining"), s.action(crafter, "crafting"), s.action(sleeper, "resting")
+	satiety, _ := s.db.GetPlayer(sleeper)
+
+	s.advanceTo(s.clk.Now().Add(2 * time.Second))
+	s.restart()
+	s.clk.BlockUntil(3)
+
+	// Восстановлены те же действия с теми же сроками
+	for userID, want := range map[int64]models.TimedAction{miner: mining, crafter: crafting, sleeper: resting} {
+		if got := s.action(userID, want.Kind); !got.EndsAt.Equal(want.EndsAt) || got.MessageID != want.MessageID {
+			t.Errorf("restored %s = %+v, want %+v", want.Kind, got, want)
+		}
+	}
+
+	s.advanceTo(mining.EndsAt.Add(-time.Second))
+	if !s.busy(miner, "mining") {
+		t.Fatalf("restored mining finished before its deadline")
+	}
+
+	s.waitDone(miner, "mining", time.Second)
+	s.waitDone(crafter, "crafting", time.Second)
+	s.waitDone(sleeper, "resting", time.Minute)
+
+	if got := s.quantity(minerPlayer.ID, "Камень"); got == 0 {
+		t.Errorf("no stone after restored mining")
+	}
+	if got := s.quantity(crafterPlayer.ID, "Березовый брус"); got != 1 {
+		t.Errorf("birch plank after restored crafting = %d, want 1", got)
+	}
+	if after, _ := s.db.GetPlayer(sleeper); after.Satiety != min(satiety.Satiety+s.h.survival.Rest, 100) {
+		t.Errorf("satiety after restored rest = %d, want %d", after.Satiety, min(satiety.Satiety+s.h.survival.Rest, 100))
+	}
+
+	// Завершенные действия больше не сохранены и не восстанавливаются повторно
+	actions, err := s.db.GetTimedActions()
+	if err != nil {
+		t.Fatalf("GetTimedActions: %v", err)
+	}
+	if len(actions) != 0 {
+		t.Errorf("timed actions left after completion: %+v", actions)
+	}
+}
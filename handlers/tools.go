@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"reborn_land/models"
+)
+
+// bestTool возвращает экземпляр инструмента вида kind, которым игрок будет работать, и его
+// описание из справочника. Выбранный игроком инструмент уровня не ниже minTier берется первым,
+// иначе - инструмент самого высокого уровня, но не ниже minTier. Если подходящего нет, возвращает nil.
+func (h *BotHandlers) bestTool(playerID int, kind string, minTier int) (*models.InventoryItem, *models.Item, error) {
+	tools, err := h.db.GetToolItems(kind)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// GetTool возвращает выбранный экземпляр раньше остальных
+	var best *models.InventoryItem
+	var bestItem *models.Item
+	for i, tool := range tools {
+		if tool.Tier < minTier {
+			break
+		}
+		instance, err := h.db.GetTool(playerID, tool.Name)
+		if err != nil {
+			return nil, nil, err
+		}
+		if instance == nil {
+			continue
+		}
+		if instance.Equipped {
+			return instance, &tools[i], nil
+		}
+		if best == nil {
+			best, bestItem = instance, &tools[i]
+		}
+	}
+	return best, bestItem, nil
+}
+
+// workedTool ищет в справочнике инструмент вида kind, которым шла работа. Если
+// инструмента нет в справочнике, возвращает простой инструмент с этим названием.
+func (h *BotHandlers) workedTool(kind string, name string) *models.Item {
+	tools, err := h.db.GetToolItems(kind)
+	if err != nil {
+		log.Printf("Error getting %s tools: %v", kind, err)
+	}
+
+	for i := range tools {
+		if tools[i].Name == name {
+			return &tools[i]
+		}
+	}
+	return &models.Item{Name: name, Type: "tool", DurabilityMax: 100, Tool: kind, Tier: 1}
+}
+
+// toolDuration - время работы инструментом: лучшие инструменты работают быстрее
+func toolDuration(base int, tool *models.Item) int {
+	return max(1, base*(100-tool.Speed)/100)
+}
+
+// toolYield - сколько единиц ресурса принесла работа: инструмент с бонусом
+// добычи и баффы от еды (bonus) иногда дают лишнюю единицу
+func (h *BotHandlers) toolYield(userID int64, tool *models.Item, bonus int) int {
+	if chance := tool.Yield + bonus; chance > 0 && h.fields.Intn(userID, 100) < chance {
+		return 2
+	}
+	return 1
+}
+
+// yieldText - строка результата о лишней добыче
+func yieldText(quantity int) string {
+	if quantity <= 1 {
+		return ""
+	}
+	return fmt.Sprintf("\n✨ Удачная работа принесла лишнюю добычу: x%d", quantity)
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import "testing"
+
+// Выбранный игроком инструмент берется в работу, даже если есть инструмент уровнем выше,
+// но только когда его уровня хватает для ресурса
+func TestBestToolPrefersEquippedInstance(t *testing.T) {
+	s := newScenario(t)
+	const u = int64(3001)
+	player := s.register(u, "Лесоруб")
+	s.grant(player.ID, "Каменный топор", 1)
+
+	tool, item, err := s.h.bestTool(player.ID, "axe", 1)
+	if err != nil || tool == nil || item.Name != "Каменный топор" {
+		t.Fatalf("best axe without equipped = %+v, %+v, %v; want Каменный топор", tool, item, err)
+	}
+
+	simple, _ := s.db.GetTool(player.ID, "Простой топор")
+	if _, err := s.db.EquipTool(player.ID, simple.ID); err != nil {
+		t.Fatalf("EquipTool: %v", err)
+	}
+	tool, item, err = s.h.bestTool(player.ID, "axe", 1)
+	if err != nil || tool == nil || tool.ID != simple.ID || item.Name != "Простой топор" {
+		t.Errorf("best axe with equipped simple axe = %+v, %+v, %v; want the equipped instance", tool, item, err)
+	}
+
+	// Для ресурса второго уровня выбранного простого топора мало
+	tool, item, err = s.h.bestTool(player.ID, "axe", 2)
+	if err != nil || tool == nil || item.Name != "Каменный топор" {
+		t.Errorf("best axe for tier 2 = %+v, %+v, %v; want Каменный топор", tool, item, err)
+	}
+
+	// Работа изнашивает именно выбранный экземпляр
+	s.gather(u, "forest", "birch")
+	if worn, _ := s.db.GetTool(player.ID, "Простой топор"); worn.Durability != 99 {
+		t.Errorf("equipped axe durability = %d, want 99", worn.Durability)
+	}
+	stone, _ := s.db.GetTool(player.ID, "Каменный топор")
+	if def, _ := s.items.ByName("Каменный топор"); stone.Durability != def.DurabilityMax {
+		t.Errorf("stone axe was worn: %+v", stone)
+	}
+
+	if tool, _, _ := s.h.bestTool(player.ID, "pickaxe", 5); tool != nil {
+		t.Errorf("found a pickaxe above the best tier: %+v", tool)
+	}
+}
@@ -1,10 +1,19 @@
 package main
 
 import (
+	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"reborn_land/catalog"
+	"reborn_land/clock"
 	"reborn_land/config"
 	"reborn_land/database"
+	"reborn_land/dispatcher"
+	"reborn_land/fieldgen"
 	"reborn_land/handlers"
+	"syscall"
+	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
@@ -13,12 +22,48 @@ func main() {
 	// Загружаем конфигурацию
 	cfg := config.Load()
 
+	// Команда migrate управляет схемой базы и не запускает бота
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrate(cfg, os.Args[2:])
+		return
+	}
+
 	if cfg.TelegramToken == "" {
 		log.Fatal("TELEGRAM_TOKEN is required. Please set it in environment variables or .env file")
 	}
 
+	// Загружаем и проверяем справочник предметов
+	items, err := catalog.Load(cfg.ItemsFile)
+	if err != nil {
+		log.Fatalf("Failed to load item catalog: %v", err)
+	}
+
+	// Рецепты проверяются по справочнику предметов
+	recipes, err := catalog.LoadRecipes(cfg.RecipesFile, items)
+	if err != nil {
+		log.Fatalf("Failed to load recipes: %v", err)
+	}
+
+	// Квесты ссылаются на предметы и постройки
+	questBook, err := catalog.LoadQuests(cfg.QuestsFile, items, recipes)
+	if err != nil {
+		log.Fatalf("Failed to load quests: %v", err)
+	}
+
+	// Таблицы добычи ссылаются на предметы
+	loot, err := catalog.LoadLoot(cfg.LootFile, items)
+	if err != nil {
+		log.Fatalf("Failed to load loot tables: %v", err)
+	}
+
+	// Баффы правил сытости ссылаются на еду из справочника
+	survival, err := catalog.LoadSurvival(cfg.SurvivalFile, items)
+	if err != nil {
+		log.Fatalf("Failed to load survival rules: %v", err)
+	}
+
 	// Подключаемся к базе данных
-	db, err := database.New(cfg.DatabaseURL)
+	db, err := database.New(cfg.DatabaseURL, items, recipes)
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
@@ -34,7 +79,17 @@ func main() {
 	log.Printf("Authorized on account %s", bot.Self.UserName)
 
 	// Создаем обработчики
-	botHandlers := handlers.New(bot, db)
+	// У каждого игрока своя последовательность полей; FIELD_SEED делает их воспроизводимыми
+	fieldSeed := cfg.FieldSeed
+	if fieldSeed == 0 {
+		fieldSeed = time.Now().UnixNano()
+	}
+	botHandlers := handlers.New(bot, db, clock.Real(), fieldgen.NewPerPlayer(fieldSeed), questBook, loot, survival)
+
+	// Возобновляем сессии, действия и кулдауны, прерванные перезапуском
+	if err := botHandlers.Restore(); err != nil {
+		log.Printf("Failed to restore player state: %v", err)
+	}
 
 	// Настраиваем получение обновлений
 	u := tgbotapi.NewUpdate(0)
@@ -42,8 +97,63 @@ func main() {
 
 	updates := bot.GetUpdatesChan(u)
 
+	// Обновления разных игроков обрабатываются параллельно, одного игрока - по порядку
+	updateDispatcher := dispatcher.New(cfg.Workers, botHandlers.HandleUpdate)
+
+	// По сигналу останавливаем получение обновлений; канал закроется после текущего запроса
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-stop
+		log.Println("Shutting down, waiting for pending updates...")
+		bot.StopReceivingUpdates()
+	}()
+
 	// Обрабатываем обновления
 	for update := range updates {
-		botHandlers.HandleUpdate(update)
+		updateDispatcher.Dispatch(update)
+	}
+
+	// Дожидаемся обработки уже полученных обновлений
+	updateDispatcher.Close()
+	// и асинхронных подписчиков шины событий
+	botHandlers.Events().Wait()
+	log.Println("Bot stopped")
+}
+
+// runMigrate выполняет migrate up, migrate down или migrate status
+func runMigrate(cfg *config.Config, args []string) {
+	command := "up"
+	if len(args) > 0 {
+		command = args[0]
+	}
+
+	db, err := database.Open(cfg.DatabaseURL)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	switch command {
+	case "up":
+		err = db.MigrateUp()
+	case "down":
+		err = db.MigrateDown()
+	case "status":
+		var statuses []database.MigrationStatus
+		statuses, err = db.MigrationStatuses()
+		for _, status := range statuses {
+			applied := "pending"
+			if status.Applied {
+				applied = "applied " + status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%4d  %-30s %s\n", status.Version, status.Name, applied)
+		}
+	default:
+		log.Fatalf("Unknown migrate command %q, expected up, down or status", command)
+	}
+
+	if err != nil {
+		log.Fatalf("Migration failed: %v", err)
 	}
 }
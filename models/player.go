@@ -3,38 +3,102 @@ package models
 import "time"
 
 type Player struct {
-	ID             int       `json:"id"`
-	TelegramID     int64     `json:"telegram_id"`
-	Name           string    `json:"name"`
-	Level          int       `json:"level"`
-	Experience     int       `json:"experience"`
-	Satiety        int       `json:"satiety"`
-	CreatedAt      time.Time `json:"created_at"`
-	SimpleHutBuilt bool      `json:"simple_hut_built"`
+	ID               int       `json:"id"`
+	TelegramID       int64     `json:"telegram_id"`
+	Name             string    `json:"name"`
+	Level            int       `json:"level"`
+	Experience       int       `json:"experience"`
+	Satiety          int       `json:"satiety"`
+	SatietyUpdatedAt time.Time `json:"satiety_updated_at"` // До этого момента убывание сытости уже учтено
+	CreatedAt        time.Time `json:"created_at"`
+	SimpleHutBuilt   bool      `json:"simple_hut_built"`
+}
+
+// Buff - действующий бафф от съеденной еды
+type Buff struct {
+	ItemName string    `json:"item_name"` // Еда, которая дала бафф
+	Name     string    `json:"name"`
+	Speed    int       `json:"speed"` // На сколько процентов быстрее идут действия
+	Yield    int       `json:"yield"` // Шанс в процентах добыть лишнюю единицу ресурса
+	EndsAt   time.Time `json:"ends_at"`
 }
 
 type Item struct {
-	ID            int    `json:"id"`
-	Name          string `json:"name"`
-	Type          string `json:"type"`
-	DurabilityMax int    `json:"durability_max"`
+	ID            int      `json:"id"`
+	Key           string   `json:"key"`
+	Name          string   `json:"name"`
+	Type          string   `json:"type"`
+	DurabilityMax int      `json:"durability_max"`
+	BurnValue     int      `json:"burn_value"` // Единиц топлива в печи и костре, 0 - не горит
+	Satiety       int      `json:"satiety"`    // Сытость от еды, 0 - несъедобно
+	Tool          string   `json:"tool"`       // Вид инструмента: "pickaxe", "axe"...
+	Tier          int      `json:"tier"`       // Уровень инструмента, от 1 у простых
+	Speed         int      `json:"speed"`      // На сколько процентов инструмент сокращает время работы
+	Yield         int      `json:"yield"`      // Шанс в процентах добыть лишнюю единицу ресурса
+	Description   string   `json:"description"`
+	Flags         []string `json:"flags"`
 }
 
+// InventoryItem - запись инвентаря. Инструменты хранятся отдельными экземплярами
+// с количеством 1 и собственной прочностью, остальные предметы - стопками.
 type InventoryItem struct {
-	ID         int    `json:"id"`
-	PlayerID   int    `json:"player_id"`
-	ItemID     int    `json:"item_id"`
-	ItemName   string `json:"item_name"`
-	Quantity   int    `json:"quantity"`
-	Durability int    `json:"durability"`
-	Type       string `json:"type"`
+	ID            int               `json:"id"`
+	PlayerID      int               `json:"player_id"`
+	ItemID        int               `json:"item_id"`
+	ItemName      string            `json:"item_name"`
+	Quantity      int               `json:"quantity"`
+	Durability    int               `json:"durability"`
+	DurabilityMax int               `json:"durability_max"`
+	Type          string            `json:"type"`
+	Equipped      bool              `json:"equipped"`   // Инструмент выбран игроком для добычи
+	Attributes    map[string]string `json:"attributes"` // Дополнительные свойства экземпляра
+}
+
+// IsTool сообщает, что запись - экземпляр инструмента
+func (i InventoryItem) IsTool() bool {
+	return i.DurabilityMax > 0
+}
+
+// ItemDelta - предмет в изменении инвентаря
+type ItemDelta struct {
+	ItemName   string            `json:"item_name"`
+	InstanceID int               `json:"instance_id"` // Конкретный экземпляр инструмента; 0 - выбрать автоматически
+	Quantity   int               `json:"quantity"`    // Сколько списать или выдать
+	Durability int               `json:"durability"`  // Прочность выдаваемого инструмента (0 - полная); для износа - сколько прочности снять
+	Attributes map[string]string `json:"attributes"`  // Свойства выдаваемых экземпляров инструмента
 }
 
+// InventoryChange - изменение инвентаря, которое применяется целиком или не применяется вовсе
+type InventoryChange struct {
+	Reason  string      `json:"reason"`  // Причина для журнала inventory_ledger, например "craft:axe"
+	Consume []ItemDelta `json:"consume"` // Списать; если чего-то не хватает, изменение отменяется
+	Wear    []ItemDelta `json:"wear"`    // Снизить прочность инструмента на Durability
+	Grant   []ItemDelta `json:"grant"`   // Выдать
+}
+
+// LedgerEntry - запись журнала изменений инвентаря
+type LedgerEntry struct {
+	ID              int       `json:"id"`
+	PlayerID        int       `json:"player_id"`
+	ItemName        string    `json:"item_name"`
+	QuantityDelta   int       `json:"quantity_delta"`
+	DurabilityDelta int       `json:"durability_delta"`
+	Reason          string    `json:"reason"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// Recipe - рецепт создания предмета или постройки
 type Recipe struct {
-	ID          int                `json:"id"`
-	ItemID      int                `json:"item_id"`
-	ItemName    string             `json:"item_name"`
-	Ingredients []RecipeIngredient `json:"ingredients"`
+	ID               int                `json:"id"`
+	Key              string             `json:"key"`       // Используется в команде /create_<key>
+	ItemName         string             `json:"item_name"` // Результат рецепта
+	OutputQuantity   int                `json:"output_quantity"`
+	OutputDurability int                `json:"output_durability"` // Прочность результата, 0 - не инструмент
+	Station          string             `json:"station"`           // "верстак", "печь", "костер", "постройки"
+	CraftTime        int                `json:"craft_time"`        // Секунд на одно создание
+	SatietyCost      int                `json:"satiety_cost"`      // Сытости на одно создание
+	Fuel             int                `json:"fuel"`              // Единиц топлива на одно создание в печи
+	Ingredients      []RecipeIngredient `json:"ingredients"`
 }
 
 type RecipeIngredient struct {
@@ -43,64 +107,37 @@ type RecipeIngredient struct {
 	Quantity int    `json:"quantity"`
 }
 
-type Mine struct {
-	ID          int       `json:"id"`
-	PlayerID    int       `json:"player_id"`
-	Level       int       `json:"level"`
-	Experience  int       `json:"experience"`
-	LastUsed    time.Time `json:"last_used"`
-	IsExhausted bool      `json:"is_exhausted"`
+// FurnaceJob - партия в очереди печи. Партии идут одна за другой,
+// готовую партию игрок забирает, когда ему удобно.
+type FurnaceJob struct {
+	ID        int       `json:"id"`
+	PlayerID  int       `json:"player_id"`
+	RecipeKey string    `json:"recipe_key"`
+	ItemName  string    `json:"item_name"` // Результат партии
+	Quantity  int       `json:"quantity"`  // Сколько предметов получит игрок
+	StartedAt time.Time `json:"started_at"`
+	EndsAt    time.Time `json:"ends_at"`
 }
 
-type MineSession struct {
-	PlayerID        int64      `json:"player_id"`
-	Resources       [][]string `json:"resources"` // 3x3 массив ресурсов
-	IsActive        bool       `json:"is_active"`
-	IsMining        bool       `json:"is_mining"`
-	StartedAt       time.Time  `json:"started_at"`
-	FieldMessageID  int        `json:"field_message_id"`  // ID сообщения с полем шахты
-	InfoMessageID   int        `json:"info_message_id"`   // ID сообщения с информацией о шахте
-	ResultMessageID int        `json:"result_message_id"` // ID сообщения с результатом добычи
+// Ready сообщает, готова ли партия к моменту now
+func (j FurnaceJob) Ready(now time.Time) bool {
+	return !j.EndsAt.After(now)
 }
 
-type Forest struct {
+// Location - прогресс игрока в локации: шахта, лес, сбор, охота, озеро, поле
+type Location struct {
 	ID          int       `json:"id"`
 	PlayerID    int       `json:"player_id"`
+	Location    string    `json:"location"` // Ключ локации, как в LocationSession
 	Level       int       `json:"level"`
 	Experience  int       `json:"experience"`
 	LastUsed    time.Time `json:"last_used"`
 	IsExhausted bool      `json:"is_exhausted"`
 }
 
-type Gathering struct {
-	ID          int       `json:"id"`
-	PlayerID    int       `json:"player_id"`
-	Level       int       `json:"level"`
-	Experience  int       `json:"experience"`
-	LastUsed    time.Time `json:"last_used"`
-	IsExhausted bool      `json:"is_exhausted"`
-}
-
-type ForestSession struct {
-	PlayerID        int64      `json:"player_id"`
-	Resources       [][]string `json:"resources"` // 3x3 массив ресурсов
-	IsActive        bool       `json:"is_active"`
-	IsChopping      bool       `json:"is_chopping"`
-	StartedAt       time.Time  `json:"started_at"`
-	FieldMessageID  int        `json:"field_message_id"`  // ID сообщения с полем леса
-	InfoMessageID   int        `json:"info_message_id"`   // ID сообщения с информацией о лесе
-	ResultMessageID int        `json:"result_message_id"` // ID сообщения с результатом рубки
-}
-
-type GatheringSession struct {
-	PlayerID        int64      `json:"player_id"`
-	Resources       [][]string `json:"resources"` // 3x3 массив ресурсов
-	IsActive        bool       `json:"is_active"`
-	IsGathering     bool       `json:"is_gathering"`
-	StartedAt       time.Time  `json:"started_at"`
-	FieldMessageID  int        `json:"field_message_id"`  // ID сообщения с полем сбора
-	InfoMessageID   int        `json:"info_message_id"`   // ID сообщения с информацией о сборе
-	ResultMessageID int        `json:"result_message_id"` // ID сообщения с результатом сбора
+// ExpToNext - сколько опыта осталось до следующего уровня
+func (l *Location) ExpToNext() int {
+	return l.Level*100 - l.Experience
 }
 
 type Quest struct {
@@ -114,22 +151,45 @@ type Quest struct {
 	CompletedAt *time.Time `json:"completed_at"`
 }
 
-type Hunting struct {
-	ID          int       `json:"id"`
-	PlayerID    int       `json:"player_id"`
-	Level       int       `json:"level"`
-	Experience  int       `json:"experience"`
-	LastUsed    time.Time `json:"last_used"`
-	IsExhausted bool      `json:"is_exhausted"`
+// LocationSession - открытое поле локации (шахта, лес, сбор, охота, озеро, поле).
+// Хранится в состоянии игрока и сохраняется в базе, чтобы пережить перезапуск.
+type LocationSession struct {
+	PlayerID        int64      `json:"player_id"` // Telegram ID игрока
+	Location        string     `json:"location"`  // "mine", "forest", "gathering", "hunting", "lake", "field"
+	ChatID          int64      `json:"chat_id"`
+	Resources       [][]string `json:"resources"`
+	Wounds          [][]int    `json:"wounds,omitempty"` // Попадания по зверю в клетке, только на охоте
+	FieldMessageID  int        `json:"field_message_id"`
+	InfoMessageID   int        `json:"info_message_id"`
+	ResultMessageID int        `json:"result_message_id"`
+	StartedAt       time.Time  `json:"started_at"`
 }
 
-type HuntingSession struct {
-	PlayerID        int64      `json:"player_id"`
-	Resources       [][]string `json:"resources"` // 3x3 массив ресурсов
-	IsActive        bool       `json:"is_active"`
-	IsHunting       bool       `json:"is_hunting"`
-	StartedAt       time.Time  `json:"started_at"`
-	FieldMessageID  int        `json:"field_message_id"`  // ID сообщения с полем охоты
-	InfoMessageID   int        `json:"info_message_id"`   // ID сообщения с информацией об охоте
-	ResultMessageID int        `json:"result_message_id"` // ID сообщения с результатом охоты
+// TimedAction - действие игрока, которое завершается по таймеру (добыча, крафт, отдых)
+type TimedAction struct {
+	PlayerID   int64     `json:"player_id"` // Telegram ID игрока
+	Kind       string    `json:"kind"`      // "mining", "chopping", "gathering", "hunting", "fishing", "harvesting", "crafting", "resting"
+	ChatID     int64     `json:"chat_id"`
+	MessageID  int       `json:"message_id"` // ID сообщения с прогресс-баром
+	ItemName   string    `json:"item_name"`  // Добываемый ресурс или создаваемый предмет
+	Quantity   int       `json:"quantity"`
+	Durability int       `json:"durability"` // Прочность инструмента на момент начала
+	ToolID     int       `json:"tool_id"`    // Экземпляр инструмента, который изнашивается
+	ToolName   string    `json:"tool_name"`  // Название этого инструмента
+	Row        int       `json:"row"`
+	Col        int       `json:"col"`
+	StartedAt  time.Time `json:"started_at"`
+	EndsAt     time.Time `json:"ends_at"`
+}
+
+// Duration возвращает полную длительность действия в секундах
+func (a *TimedAction) Duration() int {
+	return int(a.EndsAt.Sub(a.StartedAt).Seconds())
+}
+
+// Cooldown - время восстановления истощенной локации
+type Cooldown struct {
+	PlayerID int64     `json:"player_id"` // Telegram ID игрока
+	Location string    `json:"location"`
+	EndsAt   time.Time `json:"ends_at"`
 }
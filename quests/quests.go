@@ -0,0 +1,146 @@
+// Package quests продвигает квесты игроков по игровым событиям.
+// Описания квестов берутся из catalog/quests.json.
+package quests
+
+import (
+	"reborn_land/catalog"
+	"reborn_land/models"
+	"strconv"
+)
+
+// Event - игровое событие, которое может продвинуть квесты
+type Event struct {
+	Type     string // Совпадает с типом цели квеста, например catalog.ObjectiveGather
+	Target   string // Предмет, ключ постройки или номер страницы
+	Quantity int
+}
+
+// Store - операции с данными, которые нужны движку квестов
+type Store interface {
+	GetPlayerQuest(playerID int, questID int) (*models.Quest, error)
+	CreateQuest(playerID int, questID int, target int) error
+	UpdateQuestProgress(playerID int, questID int, progress int) error
+	CompleteQuest(playerID int, questID int, experience int, reward models.InventoryChange) error
+}
+
+// Engine сопоставляет события с активными квестами игрока
+type Engine struct {
+	store  Store
+	quests []catalog.Quest
+	byID   map[int]catalog.Quest
+}
+
+// New создает движок для набора квестов
+func New(store Store, book *catalog.Quests) *Engine {
+	e := &Engine{store: store, quests: book.Quests, byID: make(map[int]catalog.Quest, len(book.Quests))}
+	for _, quest := range book.Quests {
+		e.byID[quest.ID] = quest
+	}
+	return e
+}
+
+// Get возвращает описание квеста по ID
+func (e *Engine) Get(id int) (catalog.Quest, bool) {
+	quest, ok := e.byID[id]
+	return quest, ok
+}
+
+// Handle продвигает все активные квесты игрока, подходящие под событие,
+// и возвращает квесты, выполненные этим событием
+func (e *Engine) Handle(playerID int, event Event) ([]catalog.Quest, error) {
+	var completed []catalog.Quest
+
+	for _, quest := range e.quests {
+		objective := quest.Objective
+		if objective.Type != event.Type || (objective.Target != "" && objective.Target != event.Target) {
+			continue
+		}
+
+		progress, err := e.store.GetPlayerQuest(playerID, quest.ID)
+		if err != nil {
+			return completed, err
+		}
+		if progress == nil || progress.Status != "active" {
+			continue
+		}
+
+		newProgress := progress.Progress + event.Quantity
+		if objective.Ordered {
+			// Засчитывается только следующий по порядку номер, например страница 3 после 2
+			number, err := strconv.Atoi(event.Target)
+			if err != nil || number != progress.Progress+1 {
+				continue
+			}
+			newProgress = number
+		}
+
+		if err := e.store.UpdateQuestProgress(playerID, quest.ID, newProgress); err != nil {
+			return completed, err
+		}
+		if newProgress >= objective.Count {
+			if err := e.Complete(playerID, quest); err != nil {
+				return completed, err
+			}
+			completed = append(completed, quest)
+		}
+	}
+
+	return completed, nil
+}
+
+// Complete отмечает квест выполненным и выдает награду: либо все вместе, либо ничего
+func (e *Engine) Complete(playerID int, quest catalog.Quest) error {
+	change := models.InventoryChange{Reason: "quest:" + strconv.Itoa(quest.ID)}
+	for _, reward := range quest.Rewards.Items {
+		change.Grant = append(change.Grant, models.ItemDelta{ItemName: reward.Item, Quantity: reward.Quantity})
+	}
+	return e.store.CompleteQuest(playerID, quest.ID, quest.Rewards.Experience, change)
+}
+
+// Current возвращает первый невыполненный квест цепочки, требования которого выполнены.
+// Если игроку он еще не предлагался, квест создается со статусом "available".
+// ok равен false, когда вся цепочка выполнена.
+func (e *Engine) Current(playerID int) (quest catalog.Quest, progress *models.Quest, ok bool, err error) {
+	for _, quest := range e.quests {
+		progress, err := e.store.GetPlayerQuest(playerID, quest.ID)
+		if err != nil {
+			return catalog.Quest{}, nil, false, err
+		}
+		if progress != nil && progress.Status == "completed" {
+			continue
+		}
+
+		ready, err := e.requirementsMet(playerID, quest)
+		if err != nil {
+			return catalog.Quest{}, nil, false, err
+		}
+		if !ready {
+			continue
+		}
+
+		if progress == nil {
+			if err := e.store.CreateQuest(playerID, quest.ID, quest.Objective.Count); err != nil {
+				return catalog.Quest{}, nil, false, err
+			}
+			if progress, err = e.store.GetPlayerQuest(playerID, quest.ID); err != nil {
+				return catalog.Quest{}, nil, false, err
+			}
+		}
+		return quest, progress, true, nil
+	}
+
+	return catalog.Quest{}, nil, false, nil
+}
+
+func (e *Engine) requirementsMet(playerID int, quest catalog.Quest) (bool, error) {
+	for _, required := range quest.Requires {
+		progress, err := e.store.GetPlayerQuest(playerID, required)
+		if err != nil {
+			return false, err
+		}
+		if progress == nil || progress.Status != "completed" {
+			return false, nil
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,149 @@
+package state
+
+import (
+	"reborn_land/models"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Player хранит всё оперативное состояние одного игрока: активные сессии,
+// действия с таймером, кулдауны локаций и ожидание ввода.
+// Поля можно читать и изменять только под блокировкой игрока (см. Manager.Lock).
+type Player struct {
+	mu sync.Mutex
+
+	WaitingForName          bool
+	WaitingForCraftQuantity string // Ожидание количества для крафта (значение - ключ рецепта)
+
+	Sessions  map[string]*models.LocationSession // Открытые поля локаций по ключу локации
+	Actions   map[string]*models.TimedAction     // Действия в локациях по виду действия ("mining", "fishing"...)
+	Cooldowns map[string]time.Time               // Время окончания кулдауна по ключу локации
+
+	Crafting *models.TimedAction // Создание предметов
+	Resting  *models.TimedAction // Отдых в хижине
+
+	Location string // Текущее местоположение игрока
+
+	Persisted string // Последний сохраненный в базе снимок состояния
+}
+
+// Unlock освобождает состояние игрока, захваченное через Manager.Lock.
+func (p *Player) Unlock() {
+	p.mu.Unlock()
+}
+
+// Snapshot - часть состояния игрока, которая переживает перезапуск бота
+type Snapshot struct {
+	Sessions  []models.LocationSession `json:"sessions"`
+	Actions   []models.TimedAction     `json:"actions"`
+	Cooldowns []models.Cooldown        `json:"cooldowns"`
+}
+
+// Snapshot собирает сохраняемую часть состояния. Истекшие кулдауны пропускаются.
+// Записи идут в порядке ключей, чтобы одинаковое состояние давало одинаковый снимок.
+func (p *Player) Snapshot(playerID int64, now time.Time) Snapshot {
+	var snapshot Snapshot
+
+	for _, location := range sortedKeys(p.Sessions) {
+		session := *p.Sessions[location]
+		session.PlayerID, session.Location = playerID, location
+		snapshot.Sessions = append(snapshot.Sessions, session)
+	}
+
+	for _, kind := range sortedKeys(p.Actions) {
+		snapshot.Actions = append(snapshot.Actions, *p.Actions[kind])
+	}
+	for _, action := range []*models.TimedAction{p.Crafting, p.Resting} {
+		if action != nil {
+			snapshot.Actions = append(snapshot.Actions, *action)
+		}
+	}
+
+	for _, location := range sortedKeys(p.Cooldowns) {
+		if endsAt := p.Cooldowns[location]; endsAt.After(now) {
+			snapshot.Cooldowns = append(snapshot.Cooldowns, models.Cooldown{PlayerID: playerID, Location: location, EndsAt: endsAt})
+		}
+	}
+
+	return snapshot
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// RestoreSession восстанавливает сессию локации из сохраненной записи
+func (p *Player) RestoreSession(s models.LocationSession) {
+	p.Sessions[s.Location] = &s
+}
+
+// RestoreAction восстанавливает действие с таймером из сохраненной записи
+func (p *Player) RestoreAction(a models.TimedAction) {
+	action := &a
+	switch a.Kind {
+	case "crafting":
+		p.Crafting = action
+	case "resting":
+		p.Resting = action
+	default:
+		p.Actions[a.Kind] = action
+	}
+}
+
+// RestoreCooldown восстанавливает кулдаун локации из сохраненной записи
+func (p *Player) RestoreCooldown(c models.Cooldown) {
+	p.Cooldowns[c.Location] = c.EndsAt
+}
+
+// Manager владеет состоянием всех игроков и сериализует доступ к состоянию
+// каждого из них. Разные игроки блокируются независимо друг от друга.
+type Manager struct {
+	mu      sync.Mutex
+	players map[int64]*Player
+}
+
+func NewManager() *Manager {
+	return &Manager{
+		players: make(map[int64]*Player),
+	}
+}
+
+// Lock захватывает состояние игрока для эксклюзивного доступа.
+// Вызывающий обязан вызвать Unlock у полученного значения.
+func (m *Manager) Lock(playerID int64) *Player {
+	p := m.Get(playerID)
+	p.mu.Lock()
+	return p
+}
+
+// Get возвращает состояние игрока, создавая его при необходимости.
+// Сама блокировка игрока не захватывается: вызывающий код должен уже
+// удерживать её через Lock (например, внутри обработки обновления).
+func (m *Manager) Get(playerID int64) *Player {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	p, exists := m.players[playerID]
+	if !exists {
+		p = &Player{
+			Sessions:  make(map[string]*models.LocationSession),
+			Actions:   make(map[string]*models.TimedAction),
+			Cooldowns: make(map[string]time.Time),
+		}
+		m.players[playerID] = p
+	}
+	return p
+}
+
+// Do выполняет fn под блокировкой состояния игрока
+func (m *Manager) Do(playerID int64, fn func(p *Player)) {
+	p := m.Lock(playerID)
+	defer p.Unlock()
+	fn(p)
+}
@@ -0,0 +1,256 @@
+// Package telegramtest содержит поддельный Telegram клиент для сценарных тестов обработчиков.
+package telegramtest
+
+import (
+	"sync"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Message - сообщение, отправленное ботом, в его текущем состоянии
+type Message struct {
+	ChatID   int64
+	ID       int
+	Text     string
+	Markup   interface{} // Клавиатура: ReplyKeyboardMarkup или InlineKeyboardMarkup
+	Deleted  bool
+	Edits    int // Сколько раз сообщение редактировалось
+	Original tgbotapi.MessageConfig
+}
+
+// Fake записывает все, что бот отправляет в Telegram, и реализует handlers.Messenger.
+// Безопасен для использования из нескольких горутин.
+type Fake struct {
+	mu        sync.Mutex
+	messages  []*Message
+	byID      map[int]*Message
+	edited    []tgbotapi.Chattable
+	deleted   []tgbotapi.DeleteMessageConfig
+	callbacks []tgbotapi.CallbackConfig
+	requests  []tgbotapi.Chattable
+
+	nextMessageID int
+	nextUpdateID  int
+}
+
+func New() *Fake {
+	return &Fake{byID: make(map[int]*Message)}
+}
+
+// Send записывает отправку или изменение сообщения
+func (f *Fake) Send(c tgbotapi.Chattable) (tgbotapi.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch msg := c.(type) {
+	case tgbotapi.MessageConfig:
+		f.nextMessageID++
+		sent := &Message{ChatID: msg.ChatID, ID: f.nextMessageID, Text: msg.Text, Markup: msg.ReplyMarkup, Original: msg}
+		f.messages = append(f.messages, sent)
+		f.byID[sent.ID] = sent
+		return tgbotapi.Message{MessageID: sent.ID, Chat: &tgbotapi.Chat{ID: msg.ChatID}, Text: msg.Text}, nil
+	case tgbotapi.EditMessageTextConfig, tgbotapi.EditMessageReplyMarkupConfig:
+		return f.edit(c), nil
+	default:
+		f.record(c)
+		return tgbotapi.Message{}, nil
+	}
+}
+
+// Request записывает удаление сообщений, ответы на нажатия и прочие запросы
+func (f *Fake) Request(c tgbotapi.Chattable) (*tgbotapi.APIResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.record(c)
+	return &tgbotapi.APIResponse{Ok: true}, nil
+}
+
+// edit применяет редактирование к записанному сообщению
+func (f *Fake) edit(c tgbotapi.Chattable) tgbotapi.Message {
+	f.edited = append(f.edited, c)
+
+	var chatID int64
+	var messageID int
+	var text *string
+	var markup *tgbotapi.InlineKeyboardMarkup
+	switch edit := c.(type) {
+	case tgbotapi.EditMessageTextConfig:
+		chatID, messageID, text, markup = edit.ChatID, edit.MessageID, &edit.Text, edit.ReplyMarkup
+	case tgbotapi.EditMessageReplyMarkupConfig:
+		chatID, messageID, markup = edit.ChatID, edit.MessageID, edit.ReplyMarkup
+	}
+
+	result := tgbotapi.Message{MessageID: messageID, Chat: &tgbotapi.Chat{ID: chatID}}
+	if existing, ok := f.byID[messageID]; ok {
+		if text != nil {
+			existing.Text = *text
+		}
+		if markup != nil {
+			existing.Markup = *markup
+		}
+		existing.Edits++
+		result.Text = existing.Text
+	}
+	return result
+}
+
+func (f *Fake) record(c tgbotapi.Chattable) {
+	switch req := c.(type) {
+	case tgbotapi.EditMessageTextConfig, tgbotapi.EditMessageReplyMarkupConfig:
+		f.edit(req)
+	case tgbotapi.DeleteMessageConfig:
+		f.deleted = append(f.deleted, req)
+		if existing, ok := f.byID[req.MessageID]; ok {
+			existing.Deleted = true
+		}
+	case tgbotapi.CallbackConfig:
+		f.callbacks = append(f.callbacks, req)
+	default:
+		f.requests = append(f.requests, c)
+	}
+}
+
+// Sent возвращает копии всех отправленных сообщений в порядке отправки
+func (f *Fake) Sent() []Message {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	sent := make([]Message, 0, len(f.messages))
+	for _, msg := range f.messages {
+		sent = append(sent, *msg)
+	}
+	return sent
+}
+
+// Texts возвращает тексты отправленных в чат сообщений в порядке отправки
+func (f *Fake) Texts(chatID int64) []string {
+	var texts []string
+	for _, msg := range f.Sent() {
+		if msg.ChatID == chatID {
+			texts = append(texts, msg.Text)
+		}
+	}
+	return texts
+}
+
+// Last возвращает последнее отправленное в чат сообщение
+func (f *Fake) Last(chatID int64) (Message, bool) {
+	sent := f.Sent()
+	for i := len(sent) - 1; i >= 0; i-- {
+		if sent[i].ChatID == chatID {
+			return sent[i], true
+		}
+	}
+	return Message{}, false
+}
+
+// Get возвращает сообщение по ID в его текущем состоянии
+func (f *Fake) Get(messageID int) (Message, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	msg, ok := f.byID[messageID]
+	if !ok {
+		return Message{}, false
+	}
+	return *msg, true
+}
+
+// Edited возвращает все запросы на редактирование сообщений
+func (f *Fake) Edited() []tgbotapi.Chattable {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]tgbotapi.Chattable(nil), f.edited...)
+}
+
+// Deleted возвращает все запросы на удаление сообщений
+func (f *Fake) Deleted() []tgbotapi.DeleteMessageConfig {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]tgbotapi.DeleteMessageConfig(nil), f.deleted...)
+}
+
+// Callbacks возвращает все ответы на нажатия inline кнопок
+func (f *Fake) Callbacks() []tgbotapi.CallbackConfig {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]tgbotapi.CallbackConfig(nil), f.callbacks...)
+}
+
+// Requests возвращает прочие запросы к API
+func (f *Fake) Requests() []tgbotapi.Chattable {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]tgbotapi.Chattable(nil), f.requests...)
+}
+
+// Reset забывает все записанное
+func (f *Fake) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.messages = nil
+	f.byID = make(map[int]*Message)
+	f.edited = nil
+	f.deleted = nil
+	f.callbacks = nil
+	f.requests = nil
+}
+
+// Text создает обновление с текстовым сообщением игрока. Чат совпадает с ID игрока,
+// как в личной переписке с ботом.
+func (f *Fake) Text(userID int64, text string) tgbotapi.Update {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.nextUpdateID++
+	f.nextMessageID++
+	return tgbotapi.Update{
+		UpdateID: f.nextUpdateID,
+		Message: &tgbotapi.Message{
+			MessageID: f.nextMessageID,
+			From:      &tgbotapi.User{ID: userID},
+			Chat:      &tgbotapi.Chat{ID: userID, Type: "private"},
+			Text:      text,
+		},
+	}
+}
+
+// Command создает обновление с командой, например Command(1, "start") для /start
+func (f *Fake) Command(userID int64, command string) tgbotapi.Update {
+	update := f.Text(userID, "/"+command)
+	update.Message.Entities = []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: len(command) + 1}}
+	return update
+}
+
+// Callback создает обновление с нажатием inline кнопки под сообщением messageID
+func (f *Fake) Callback(userID int64, messageID int, data string) tgbotapi.Update {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.nextUpdateID++
+	return tgbotapi.Update{
+		UpdateID: f.nextUpdateID,
+		CallbackQuery: &tgbotapi.CallbackQuery{
+			ID:   "callback",
+			From: &tgbotapi.User{ID: userID},
+			Message: &tgbotapi.Message{
+				MessageID: messageID,
+				Chat:      &tgbotapi.Chat{ID: userID, Type: "private"},
+			},
+			Data: data,
+		},
+	}
+}
+
+// Replay по порядку передает обновления обработчику, например BotHandlers.HandleUpdate
+func (f *Fake) Replay(handle func(tgbotapi.Update), updates ...tgbotapi.Update) {
+	for _, update := range updates {
+		handle(update)
+	}
+}